@@ -10,6 +10,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -20,7 +21,8 @@ type PlexMediaServerSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Version is the version of Plex Media server deployed on the cluster
+	// Version is the version of Plex Media server deployed on the cluster. With Updates, a version
+	// other than "latest" pins Plex to that version and pauses updates.
 	// +optional
 	Version string `json:"version,omitempty"`
 
@@ -28,6 +30,29 @@ type PlexMediaServerSpec struct {
 	// +optional
 	ClaimToken string `json:"claimToken,omitempty"`
 
+	// Suspend stops Plex Media Server by scaling its StatefulSet to 0 replicas, for example for
+	// maintenance. Services, storage, and other objects are kept, and Plex is started again when
+	// Suspend is set to false.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Replicas is the number of Plex Media Server replicas, which can be 0 or 1. Plex does not
+	// support running more than one replica against the same configuration. Replicas is set by the
+	// scale subresource, so that `kubectl scale` can stop and start Plex. Setting Replicas to 0 is
+	// the same as setting Suspend to true. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +kubebuilder:default=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Updates enables automatic updates of Plex Media Server. The operator periodically checks
+	// plex.tv for the newest release on the update channel, and updates Plex inside the
+	// maintenance window. Unless Version pins Plex, the version the operator deploys is recorded
+	// in the plex.adambkaplan.com/version annotation.
+	// +optional
+	Updates *PlexUpdatesSpec `json:"updates,omitempty"`
+
 	// Storage configures the persistent volume claim attributes for Plex Media Server's backing
 	// volumes:
 	//
@@ -40,6 +65,296 @@ type PlexMediaServerSpec struct {
 	// Networking configures network options for the Plex Media Server, such as an external-facing service.
 	// +optional
 	Networking PlexNetworkSpec `json:"networking,omitempty"`
+
+	// Preferences configures Plex Media Server's preferences, which are stored in Preferences.xml
+	// on the config volume. Preferences are applied before Plex starts, and Plex is restarted when
+	// they change.
+	// +optional
+	Preferences *PlexPreferencesSpec `json:"preferences,omitempty"`
+
+	// TLS configures a custom TLS certificate for Plex Media Server. The certificate is converted to
+	// the PKCS#12 format required by Plex, and Plex is restarted when the certificate is renewed.
+	// +optional
+	TLS *PlexTLSSpec `json:"tls,omitempty"`
+
+	// TokenSecretRef references a key in a Secret in the PlexMediaServer's namespace that holds an
+	// X-Plex-Token for the server. The operator uses the token to query Plex's HTTP API.
+	// +optional
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+
+	// Probes configures the startup, readiness, and liveness probes of the Plex container. By
+	// default, each probe checks Plex's /identity endpoint, and the startup probe allows Plex up to
+	// 10 minutes to migrate its database on first boot.
+	// +optional
+	Probes PlexProbesSpec `json:"probes,omitempty"`
+
+	// Termination configures how Plex is shut down when its pod is deleted, for example when Plex is
+	// restarted to apply new preferences or upgraded.
+	// +optional
+	Termination PlexTerminationSpec `json:"termination,omitempty"`
+
+	// SecurityContext configures the security context of Plex's pod and containers.
+	// +optional
+	SecurityContext *PlexSecurityContextSpec `json:"securityContext,omitempty"`
+
+	// PodTemplateOverride is a partial pod template that is strategically merged on top of the pod
+	// template rendered for Plex, for settings the operator does not manage such as sidecars, extra
+	// volumes, host aliases, or DNS settings. The override must not change the labels that select
+	// Plex's pod or the image of the plex container. If the merged template is not valid, the last
+	// valid override is kept and the PodTemplateOverrideApplied condition reports why.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	PodTemplateOverride *runtime.RawExtension `json:"podTemplateOverride,omitempty"`
+
+	// CommonLabels are added to every object the operator manages for Plex, including Plex's pod.
+	// The operator's app.kubernetes.io and plex.adambkaplan.com labels take precedence.
+	// +optional
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// CommonAnnotations are added to every object the operator manages for Plex, including Plex's
+	// pod.
+	// +optional
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+
+	// PodLabels are added to Plex's pod. The operator's app.kubernetes.io and plex.adambkaplan.com
+	// labels take precedence.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are added to Plex's pod, for example to configure a service mesh or secret
+	// injector. PodAnnotations take precedence over CommonAnnotations.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+}
+
+// PlexSecurityContextSpec configures the security context of Plex Media Server's pod and containers
+type PlexSecurityContextSpec struct {
+
+	// Preset applies a set of security settings. Restricted runs Plex as a non-root user without
+	// privilege escalation or capabilities and with the runtime's default seccomp profile, so that
+	// Plex can be admitted into namespaces that enforce the restricted Pod Security Standard. The
+	// preset requires an image of Plex that can start as a non-root user. The plexinc/pms-docker
+	// image starts Plex through s6-overlay, which must run as root, so Plex does not start with the
+	// preset. The fields below take precedence over the preset.
+	// +optional
+	// +kubebuilder:validation:Enum=Restricted
+	Preset string `json:"preset,omitempty"`
+
+	// RunAsUser is the user ID Plex's containers run as. Defaults to 1000 with the Restricted
+	// preset, except on OpenShift where the user ID is assigned from the namespace's range.
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// RunAsGroup is the group ID Plex's containers run as. Defaults to 1000 with the Restricted
+	// preset, except on OpenShift.
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+
+	// FSGroup is the group that owns Plex's volumes. Defaults to 1000 with the Restricted preset,
+	// except on OpenShift.
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+
+	// FSGroupChangePolicy sets when the ownership of Plex's volumes is changed to the FSGroup. Can
+	// be OnRootMismatch or Always. Defaults to OnRootMismatch with the Restricted preset, which
+	// avoids walking large media libraries each time Plex starts.
+	// +optional
+	// +kubebuilder:validation:Enum=OnRootMismatch;Always
+	FSGroupChangePolicy *corev1.PodFSGroupChangePolicy `json:"fsGroupChangePolicy,omitempty"`
+
+	// SeccompProfile is the seccomp profile of Plex's pod. Defaults to RuntimeDefault with the
+	// Restricted preset.
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+
+	// DropCapabilities are the Linux capabilities dropped from Plex's containers. Defaults to ALL
+	// with the Restricted preset.
+	// +optional
+	DropCapabilities []corev1.Capability `json:"dropCapabilities,omitempty"`
+
+	// ReadOnlyRootFilesystem mounts the root filesystem of Plex's containers as read only. The
+	// paths Plex writes to outside of its volumes, /tmp and /run, are mounted from emptyDir volumes.
+	// +optional
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// PlexUpdatesSpec configures automatic updates of Plex Media Server
+type PlexUpdatesSpec struct {
+
+	// Channel is the release channel Plex is updated from, Public or Beta. The Beta channel
+	// requires the X-Plex-Token of a Plex Pass account, referenced by tokenSecretRef. Defaults to
+	// Public.
+	// +optional
+	// +kubebuilder:validation:Enum=Public;Beta
+	// +kubebuilder:default=Public
+	Channel string `json:"channel,omitempty"`
+
+	// PollInterval is how often the operator checks plex.tv for a new release. Defaults to 6h.
+	// +optional
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+
+	// MaintenanceWindow restricts when Plex is updated, since updating restarts Plex and stops
+	// active streams. If not set, Plex is updated as soon as a new release is found.
+	// +optional
+	MaintenanceWindow *PlexMaintenanceWindow `json:"maintenanceWindow,omitempty"`
+}
+
+// PlexMaintenanceWindow is a recurring window of time in which Plex can be updated
+type PlexMaintenanceWindow struct {
+
+	// Schedule is a cron expression for when the window opens, with five fields: minute, hour, day
+	// of month, month, and day of week. Fields accept `*`, lists, ranges, and steps. The schedule
+	// is evaluated in UTC. For example, "0 3 * * 1" opens the window at 03:00 every Monday.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+
+	// Duration is how long the window stays open. Defaults to 1h.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// PlexTerminationSpec configures how Plex Media Server is shut down
+type PlexTerminationSpec struct {
+
+	// GracePeriodSeconds is how long Plex is given to stop transcodes and flush its database before
+	// it is killed. Defaults to 120 seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+
+	// DisablePreStopHook removes the preStop hook that stops active transcodes and shuts Plex down
+	// before its container is sent SIGTERM.
+	// +optional
+	DisablePreStopHook bool `json:"disablePreStopHook,omitempty"`
+}
+
+// PlexProbesSpec configures the probes of the Plex container
+type PlexProbesSpec struct {
+
+	// Startup configures the startup probe, which holds off the readiness and liveness probes until
+	// Plex starts serving. Defaults to checking every 10 seconds, failing after 60 attempts.
+	// +optional
+	Startup *PlexProbeOptions `json:"startup,omitempty"`
+
+	// Readiness configures the readiness probe, which removes Plex from its Services' endpoints
+	// while it is not serving. Defaults to checking every 10 seconds, failing after 3 attempts.
+	// +optional
+	Readiness *PlexProbeOptions `json:"readiness,omitempty"`
+
+	// Liveness configures the liveness probe, which restarts Plex if it stops responding. Defaults
+	// to checking every 30 seconds, failing after 5 attempts.
+	// +optional
+	Liveness *PlexProbeOptions `json:"liveness,omitempty"`
+}
+
+// PlexProbeOptions tunes a probe of the Plex container. Unset fields use the probe's defaults.
+type PlexProbeOptions struct {
+
+	// Disabled removes the probe from the Plex container.
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+
+	// InitialDelaySeconds is the number of seconds after the container starts before the probe is
+	// run.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is how often the probe is run, in seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is the number of seconds after which the probe times out.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failures after which the probe fails.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// PlexDNSSpec configures a DNS record for Plex's external Service, published by ExternalDNS
+type PlexDNSSpec struct {
+
+	// Hostname is the host name of the DNS record.
+	// +kubebuilder:validation:MinLength=1
+	Hostname string `json:"hostname"`
+
+	// Mode sets how the DNS record is published. Annotation sets ExternalDNS's hostname annotation on
+	// the external Service. DNSEndpoint creates a DNSEndpoint that points at the external Service's
+	// load balancer, and requires ExternalDNS's CRD source. Defaults to Annotation.
+	// +optional
+	// +kubebuilder:validation:Enum=Annotation;DNSEndpoint
+	Mode string `json:"mode,omitempty"`
+
+	// TTL is the time to live of the DNS record, in seconds.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	TTL *int64 `json:"ttl,omitempty"`
+}
+
+// PlexTLSSpec configures a custom TLS certificate for Plex Media Server
+type PlexTLSSpec struct {
+
+	// SecretRef references a kubernetes.io/tls Secret in the PlexMediaServer's namespace, such as a
+	// Secret issued by cert-manager.
+	SecretRef corev1.LocalObjectReference `json:"secretRef"`
+
+	// Domain is the domain name of the certificate, which Plex uses when clients connect with the
+	// custom certificate.
+	// +kubebuilder:validation:MinLength=1
+	Domain string `json:"domain"`
+}
+
+// PlexPreferencesSpec configures Plex Media Server's preferences
+type PlexPreferencesSpec struct {
+
+	// FriendlyName is the name of the server shown to Plex clients.
+	// +optional
+	FriendlyName string `json:"friendlyName,omitempty"`
+
+	// SecureConnections sets whether clients must use secure connections. Can be one of Required,
+	// Preferred, or Disabled.
+	// +optional
+	// +kubebuilder:validation:Enum=Required;Preferred;Disabled
+	SecureConnections string `json:"secureConnections,omitempty"`
+
+	// RemoteAccess publishes the server to plex.tv so that it can be accessed outside of the local
+	// network.
+	// +optional
+	RemoteAccess *bool `json:"remoteAccess,omitempty"`
+
+	// RelayEnabled allows clients to connect through the Plex relay when a direct connection
+	// cannot be established.
+	// +optional
+	RelayEnabled *bool `json:"relayEnabled,omitempty"`
+
+	// TranscoderQuality sets the quality of transcoded media. Can be one of Automatic,
+	// PreferHigherSpeed, PreferHigherQuality, or MakeMyCPUHurt.
+	// +optional
+	// +kubebuilder:validation:Enum=Automatic;PreferHigherSpeed;PreferHigherQuality;MakeMyCPUHurt
+	TranscoderQuality string `json:"transcoderQuality,omitempty"`
+
+	// WANTotalMaxUploadRate limits the total upload rate for remote streams, in kbps. Zero means
+	// unlimited.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	WANTotalMaxUploadRate *int32 `json:"wanTotalMaxUploadRate,omitempty"`
+
+	// WANPerStreamMaxUploadRate limits the upload rate of each remote stream, in kbps. Zero means
+	// unlimited.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	WANPerStreamMaxUploadRate *int32 `json:"wanPerStreamMaxUploadRate,omitempty"`
+
+	// Additional sets other preferences in Preferences.xml, keyed by the preference's attribute
+	// name. Preferences set by other fields take precedence, and names that are not valid XML
+	// attribute names are ignored.
+	// +optional
+	Additional map[string]string `json:"additional,omitempty"`
 }
 
 // PlexStorageSpec defines persistent volume claim attributes for the
@@ -58,6 +373,36 @@ type PlexStorageSpec struct {
 	// Data specifies the volume claim attributes for Plex Media Server's media data
 	// +optional
 	Data *PlexStorageOptions `json:"data,omitempty"`
+
+	// Ownership adds an init container that changes or checks the ownership of Plex's volumes
+	// before Plex starts. This helps when Plex uses an existing NFS share or a migrated config
+	// directory whose files are owned by another user. The result is reported in the
+	// VolumesAccessible status condition.
+	// +optional
+	Ownership *PlexVolumeOwnershipSpec `json:"ownership,omitempty"`
+}
+
+// PlexVolumeOwnershipSpec configures how the ownership of Plex Media Server's volumes is fixed or
+// checked
+type PlexVolumeOwnershipSpec struct {
+
+	// Mode sets what the init container does. Chown changes the owner of files that are not owned
+	// by the user and group, and requires the init container to run as root. Check only checks that
+	// the user can read the volumes and write to the config and transcode volumes, and mounts the
+	// volumes read only. Chown is not allowed with the Restricted security context preset, where
+	// the volumes are checked instead.
+	// +kubebuilder:validation:Enum=Chown;Check
+	Mode string `json:"mode"`
+
+	// UID is the user ID that should own Plex's files. Defaults to securityContext.runAsUser, or
+	// 1000.
+	// +optional
+	UID *int64 `json:"uid,omitempty"`
+
+	// GID is the group ID that should own Plex's files. Defaults to securityContext.runAsGroup, or
+	// 1000.
+	// +optional
+	GID *int64 `json:"gid,omitempty"`
 }
 
 // PlexStorageOptions configures a PersistentVolumeClaim used by the Plex Media Server
@@ -102,6 +447,231 @@ type PlexNetworkSpec struct {
 	// EnableRoku opens Plex Companion ports used to access Plex via Roku devices.
 	// +optional
 	EnableRoku bool `json:"enableRoku,omitempty"`
+
+	// LANNetworks configures the networks that Plex treats as local. Clients on local networks are
+	// not subject to remote bandwidth limits and can be allowed to connect without signing in.
+	// +optional
+	LANNetworks *PlexLANNetworksSpec `json:"lanNetworks,omitempty"`
+
+	// IPFamilyPolicy sets the IP family policy of Plex's Services. Can be one of SingleStack,
+	// PreferDualStack, or RequireDualStack. If not set, the cluster's default policy is used.
+	// +optional
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	IPFamilyPolicy string `json:"ipFamilyPolicy,omitempty"`
+
+	// IPFamilies sets the IP families of Plex's Services, in order of preference. The first family
+	// is the primary family of each Service and cannot be changed after the Service is created.
+	// +optional
+	// +kubebuilder:validation:MaxItems=2
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+
+	// Ports overrides the Service and container ports used by Plex's features. Ports that are not
+	// listed use Plex's default port numbers.
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Ports []PlexPortSpec `json:"ports,omitempty"`
+
+	// CustomConnections are additional URLs Plex advertises to clients as custom server access
+	// URLs. URLs for the external Service, Ingress, and Route are advertised by the operator.
+	// +optional
+	CustomConnections []string `json:"customConnections,omitempty"`
+
+	// DNS publishes a DNS record for Plex's external Service through ExternalDNS. The host name is
+	// advertised to clients as a custom server access URL.
+	// +optional
+	DNS *PlexDNSSpec `json:"dns,omitempty"`
+
+	// Ingress configures an Ingress to expose Plex's web interface outside of the cluster.
+	// +optional
+	Ingress *PlexIngressSpec `json:"ingress,omitempty"`
+
+	// Gateway configures Gateway API routes to expose Plex outside of the cluster.
+	// +optional
+	Gateway *PlexGatewaySpec `json:"gateway,omitempty"`
+
+	// Route configures an OpenShift Route to expose Plex's web interface outside of the cluster.
+	// Requires the route.openshift.io API.
+	// +optional
+	Route *PlexRouteSpec `json:"route,omitempty"`
+
+	// NetworkPolicy configures a NetworkPolicy that restricts traffic to and from the Plex Media
+	// Server pod. Ports for discovery, DLNA, and Roku are only allowed if they are enabled.
+	// +optional
+	NetworkPolicy *PlexNetworkPolicySpec `json:"networkPolicy,omitempty"`
+}
+
+// PlexNetworkPolicySpec configures the NetworkPolicy for Plex Media Server
+type PlexNetworkPolicySpec struct {
+
+	// AllowedCIDRs are the IP address ranges allowed to access Plex. If neither CIDRs nor
+	// namespaces are allowed, traffic is allowed from all sources on Plex's enabled ports.
+	// +optional
+	AllowedCIDRs []string `json:"allowedCIDRs,omitempty"`
+
+	// AllowedNamespaces selects the namespaces whose pods are allowed to access Plex, such as the
+	// namespace of an ingress controller. An empty selector allows all namespaces.
+	// +optional
+	AllowedNamespaces *metav1.LabelSelector `json:"allowedNamespaces,omitempty"`
+
+	// Egress restricts outbound traffic from Plex. If not set, all outbound traffic is allowed.
+	// +optional
+	Egress *PlexNetworkPolicyEgress `json:"egress,omitempty"`
+}
+
+// PlexNetworkPolicyEgress restricts outbound traffic from Plex Media Server to DNS, plex.tv,
+// and NFS servers
+type PlexNetworkPolicyEgress struct {
+
+	// PlexTVCIDRs are the IP address ranges used to reach plex.tv over HTTPS. NetworkPolicies
+	// cannot select host names, so the ranges must be listed.
+	// +kubebuilder:validation:MinItems=1
+	PlexTVCIDRs []string `json:"plexTVCIDRs"`
+
+	// NFSCIDRs are the IP address ranges of NFS servers that provide Plex's storage.
+	// +optional
+	NFSCIDRs []string `json:"nfsCIDRs,omitempty"`
+}
+
+// PlexLANNetworksSpec configures the networks Plex treats as local
+type PlexLANNetworksSpec struct {
+
+	// CIDRs are IP address ranges that Plex treats as local, such as the home network.
+	// +optional
+	CIDRs []string `json:"cidrs,omitempty"`
+
+	// IncludeClusterNetworks adds the cluster's pod and service networks, so that clients running
+	// on the cluster are treated as local. Pod networks are discovered from each Node.
+	// +optional
+	IncludeClusterNetworks bool `json:"includeClusterNetworks,omitempty"`
+
+	// ServiceCIDRs are the cluster's service IP address ranges. The service network cannot be
+	// discovered from the Kubernetes API, and is only added if cluster networks are included.
+	// +optional
+	ServiceCIDRs []string `json:"serviceCIDRs,omitempty"`
+}
+
+// PlexPortSpec overrides the port numbers for one of Plex's ports
+type PlexPortSpec struct {
+
+	// Name is the name of the Plex port. Can be one of plex, roku, dlna-udp, dlna-tcp,
+	// discovery-0, discovery-1, discovery-2, or discovery-3.
+	// +kubebuilder:validation:Enum=plex;roku;dlna-udp;dlna-tcp;discovery-0;discovery-1;discovery-2;discovery-3
+	Name string `json:"name"`
+
+	// Port is the port exposed by Plex's Services. Defaults to Plex's standard port for the
+	// feature, which is the port Plex listens on in its container. For example, set to 443 to expose Plex's web interface on the standard HTTPS port.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port,omitempty"`
+
+	// NodePort is the port opened on every node if the external Service is a NodePort or
+	// LoadBalancer Service. If not set, a node port is allocated by Kubernetes.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	NodePort int32 `json:"nodePort,omitempty"`
+}
+
+// PlexRouteSpec configures the OpenShift Route used to access Plex Media Server
+type PlexRouteSpec struct {
+
+	// Host is the host name used to access Plex through the Route. If empty, the host name is
+	// generated by OpenShift.
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// TLSTermination sets how TLS is terminated for the Route. Can be one of edge or passthrough.
+	// Passthrough routes send encrypted traffic directly to Plex.
+	// +optional
+	// +kubebuilder:validation:Enum=edge;passthrough
+	TLSTermination string `json:"tlsTermination,omitempty"`
+}
+
+// PlexGatewaySpec configures Gateway API routes for Plex Media Server
+type PlexGatewaySpec struct {
+
+	// ParentRef references the Gateway that Plex's HTTPRoute attaches to.
+	ParentRef PlexGatewayParentRef `json:"parentRef"`
+
+	// Hostnames are the host names matched by Plex's HTTPRoute.
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// RokuParentRef references the Gateway listener used to route Plex Companion traffic for Roku
+	// devices. If set and Roku access is enabled, a TCPRoute is created for the Roku port.
+	// +optional
+	RokuParentRef *PlexGatewayParentRef `json:"rokuParentRef,omitempty"`
+
+	// DiscoveryParentRef references the Gateway used to route GDM network discovery traffic. If
+	// set and network discovery is enabled, a UDPRoute is created for each discovery port.
+	// +optional
+	DiscoveryParentRef *PlexGatewayParentRef `json:"discoveryParentRef,omitempty"`
+}
+
+// PlexGatewayParentRef references a Gateway, or a listener on a Gateway
+type PlexGatewayParentRef struct {
+
+	// Name is the name of the Gateway.
+	Name string `json:"name"`
+
+	// Namespace is the namespace of the Gateway. Defaults to the namespace of the
+	// PlexMediaServer.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName is the name of the Gateway listener the route attaches to.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// PlexIngressSpec configures the Ingress used to access Plex Media Server
+type PlexIngressSpec struct {
+
+	// Host is the fully qualified domain name used to access Plex through the Ingress.
+	// The host is also advertised to Plex clients as a custom server access URL.
+	Host string `json:"host"`
+
+	// IngressClassName is the name of the IngressClass used to implement the Ingress.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// Annotations are added to the Ingress, and can be used to configure the ingress controller.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TLS configures TLS termination for the Ingress.
+	// +optional
+	TLS *PlexIngressTLS `json:"tls,omitempty"`
+}
+
+// PlexIngressTLS configures TLS termination for Plex's Ingress
+type PlexIngressTLS struct {
+
+	// SecretName is the name of the Secret containing the TLS certificate and key for the Ingress
+	// host. If an issuer is referenced, the generated certificate is stored in this Secret.
+	// Defaults to "<name>-tls" if an issuer is referenced.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// IssuerRef references a cert-manager Issuer or ClusterIssuer. If set, a cert-manager
+	// Certificate is created for the Ingress host.
+	// +optional
+	IssuerRef *PlexCertificateIssuerRef `json:"issuerRef,omitempty"`
+}
+
+// PlexCertificateIssuerRef references a cert-manager certificate issuer
+type PlexCertificateIssuerRef struct {
+
+	// Name is the name of the issuer.
+	Name string `json:"name"`
+
+	// Kind is the kind of the issuer. Can be one of Issuer or ClusterIssuer.
+	// +optional
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +kubebuilder:default=Issuer
+	Kind string `json:"kind,omitempty"`
 }
 
 // PlexMediaServerStatus defines the observed state of PlexMediaServer
@@ -116,10 +686,194 @@ type PlexMediaServerStatus struct {
 	// Conditions reports the condition of the Plex Media Server
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Routes reports the status of the Gateway API routes for the Plex Media Server
+	// +optional
+	Routes []PlexRouteStatus `json:"routes,omitempty"`
+
+	// CustomConnections are the URLs Plex advertises to clients as custom server access URLs
+	// +optional
+	CustomConnections []string `json:"customConnections,omitempty"`
+
+	// Endpoints are the URLs Plex can be reached at, inside and outside of the cluster
+	// +optional
+	Endpoints []PlexEndpoint `json:"endpoints,omitempty"`
+
+	// ExternalURL is the first URL Plex can be reached at from outside of the cluster
+	// +optional
+	ExternalURL string `json:"externalURL,omitempty"`
+
+	// DNS reports the addresses Plex's DNS record resolves to
+	// +optional
+	DNS *PlexDNSStatus `json:"dns,omitempty"`
+
+	// Server reports the identity of the running Plex Media Server, queried from Plex's HTTP API
+	// +optional
+	Server *PlexServerStatus `json:"server,omitempty"`
+
+	// Pod reports the state of the Plex Media Server pod
+	// +optional
+	Pod *PlexPodStatus `json:"pod,omitempty"`
+
+	// Replicas is the number of Plex Media Server pods, reported for the scale subresource
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Selector is the label selector of the Plex Media Server pod, reported for the scale
+	// subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+
+	// Updates reports the versions found by automatic updates
+	// +optional
+	Updates *PlexUpdatesStatus `json:"updates,omitempty"`
+}
+
+// PlexUpdatesStatus reports the state of automatic updates
+type PlexUpdatesStatus struct {
+
+	// AvailableVersion is the newest version of Plex Media Server on the update channel.
+	// +optional
+	AvailableVersion string `json:"availableVersion,omitempty"`
+
+	// CurrentVersion is the version of Plex Media Server the operator deploys.
+	// +optional
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	// LastChecked is when the operator last checked plex.tv for a new release.
+	// +optional
+	LastChecked *metav1.Time `json:"lastChecked,omitempty"`
+}
+
+// PlexPodStatus reports the state of the Plex Media Server pod
+type PlexPodStatus struct {
+
+	// Name is the name of the pod.
+	Name string `json:"name"`
+
+	// Phase is the pod's phase.
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// Reason explains why the pod is not running, such as CrashLoopBackOff, ImagePullBackOff, or
+	// Unschedulable.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human readable description of the reason.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RestartCount is the total number of container restarts in the pod.
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+
+	// LastTermination reports the most recent container termination in the pod.
+	// +optional
+	LastTermination *PlexContainerTermination `json:"lastTermination,omitempty"`
+}
+
+// PlexContainerTermination reports how a container in the Plex Media Server pod terminated
+type PlexContainerTermination struct {
+
+	// Container is the name of the container.
+	Container string `json:"container"`
+
+	// ExitCode is the container's exit code.
+	ExitCode int32 `json:"exitCode"`
+
+	// Reason is the reason the container terminated, such as Error or OOMKilled.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is the container's termination message.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// FinishedAt is the time the container terminated.
+	// +optional
+	FinishedAt metav1.Time `json:"finishedAt,omitempty"`
+}
+
+// PlexServerStatus reports the identity of the running Plex Media Server. Fields other than the
+// machine identifier, version, and claimed state require an X-Plex-Token.
+type PlexServerStatus struct {
+
+	// MachineIdentifier uniquely identifies the server to Plex.
+	MachineIdentifier string `json:"machineIdentifier"`
+
+	// Version is the version of Plex Media Server that is running.
+	Version string `json:"version"`
+
+	// Claimed is true if the server has been claimed by a Plex account.
+	Claimed bool `json:"claimed"`
+
+	// FriendlyName is the name of the server shown to Plex clients.
+	// +optional
+	FriendlyName string `json:"friendlyName,omitempty"`
+
+	// Platform is the operating system the server runs on.
+	// +optional
+	Platform string `json:"platform,omitempty"`
+
+	// MyPlexSigninState is the server's plex.tv sign-in state, such as ok or unauthorized.
+	// +optional
+	MyPlexSigninState string `json:"myPlexSigninState,omitempty"`
+
+	// MyPlexUsername is the plex.tv account the server is signed in with.
+	// +optional
+	MyPlexUsername string `json:"myPlexUsername,omitempty"`
+
+	// AppURL opens the server in the Plex web app at app.plex.tv.
+	// +optional
+	AppURL string `json:"appURL,omitempty"`
+}
+
+// PlexEndpoint is a URL Plex can be reached at
+type PlexEndpoint struct {
+
+	// Type is how the endpoint reaches Plex. Can be one of Internal, Ingress, Route, DNS,
+	// LoadBalancer, or NodePort.
+	Type string `json:"type"`
+
+	// URL is the URL of Plex's web port.
+	URL string `json:"url"`
+}
+
+// PlexDNSStatus reports the status of Plex's DNS record
+type PlexDNSStatus struct {
+
+	// Hostname is the host name of the DNS record.
+	Hostname string `json:"hostname"`
+
+	// Addresses are the addresses the host name resolves to from the operator.
+	// +optional
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// PlexRouteStatus reports the status of a Gateway API route managed by the operator
+type PlexRouteStatus struct {
+
+	// Kind is the kind of the route.
+	Kind string `json:"kind"`
+
+	// Name is the name of the route.
+	Name string `json:"name"`
+
+	// Conditions are the Accepted and ResolvedRefs conditions reported by the route's parent
+	// Gateways. If the parents disagree, a condition is False if any parent reports it as False.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.server.version`
+// +kubebuilder:printcolumn:name="Claimed",type=boolean,JSONPath=`.status.server.claimed`
+// +kubebuilder:printcolumn:name="External URL",type=string,JSONPath=`.status.externalURL`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // PlexMediaServer is the Schema for the plexmediaservers API
 type PlexMediaServer struct {
@@ -11,10 +11,240 @@ SPDX-License-Identifier: Apache-2.0
 package v1alpha1
 
 import (
-	"k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexCertificateIssuerRef) DeepCopyInto(out *PlexCertificateIssuerRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexCertificateIssuerRef.
+func (in *PlexCertificateIssuerRef) DeepCopy() *PlexCertificateIssuerRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexCertificateIssuerRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexContainerTermination) DeepCopyInto(out *PlexContainerTermination) {
+	*out = *in
+	in.FinishedAt.DeepCopyInto(&out.FinishedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexContainerTermination.
+func (in *PlexContainerTermination) DeepCopy() *PlexContainerTermination {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexContainerTermination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexDNSSpec) DeepCopyInto(out *PlexDNSSpec) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexDNSSpec.
+func (in *PlexDNSSpec) DeepCopy() *PlexDNSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexDNSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexDNSStatus) DeepCopyInto(out *PlexDNSStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexDNSStatus.
+func (in *PlexDNSStatus) DeepCopy() *PlexDNSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexDNSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexEndpoint) DeepCopyInto(out *PlexEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexEndpoint.
+func (in *PlexEndpoint) DeepCopy() *PlexEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexGatewayParentRef) DeepCopyInto(out *PlexGatewayParentRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexGatewayParentRef.
+func (in *PlexGatewayParentRef) DeepCopy() *PlexGatewayParentRef {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexGatewayParentRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexGatewaySpec) DeepCopyInto(out *PlexGatewaySpec) {
+	*out = *in
+	out.ParentRef = in.ParentRef
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RokuParentRef != nil {
+		in, out := &in.RokuParentRef, &out.RokuParentRef
+		*out = new(PlexGatewayParentRef)
+		**out = **in
+	}
+	if in.DiscoveryParentRef != nil {
+		in, out := &in.DiscoveryParentRef, &out.DiscoveryParentRef
+		*out = new(PlexGatewayParentRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexGatewaySpec.
+func (in *PlexGatewaySpec) DeepCopy() *PlexGatewaySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexGatewaySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexIngressSpec) DeepCopyInto(out *PlexIngressSpec) {
+	*out = *in
+	if in.IngressClassName != nil {
+		in, out := &in.IngressClassName, &out.IngressClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(PlexIngressTLS)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexIngressSpec.
+func (in *PlexIngressSpec) DeepCopy() *PlexIngressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexIngressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexIngressTLS) DeepCopyInto(out *PlexIngressTLS) {
+	*out = *in
+	if in.IssuerRef != nil {
+		in, out := &in.IssuerRef, &out.IssuerRef
+		*out = new(PlexCertificateIssuerRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexIngressTLS.
+func (in *PlexIngressTLS) DeepCopy() *PlexIngressTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexIngressTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexLANNetworksSpec) DeepCopyInto(out *PlexLANNetworksSpec) {
+	*out = *in
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceCIDRs != nil {
+		in, out := &in.ServiceCIDRs, &out.ServiceCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexLANNetworksSpec.
+func (in *PlexLANNetworksSpec) DeepCopy() *PlexLANNetworksSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexLANNetworksSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexMaintenanceWindow) DeepCopyInto(out *PlexMaintenanceWindow) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexMaintenanceWindow.
+func (in *PlexMaintenanceWindow) DeepCopy() *PlexMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlexMediaServer) DeepCopyInto(out *PlexMediaServer) {
 	*out = *in
@@ -77,8 +307,73 @@ func (in *PlexMediaServerList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlexMediaServerSpec) DeepCopyInto(out *PlexMediaServerSpec) {
 	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Updates != nil {
+		in, out := &in.Updates, &out.Updates
+		*out = new(PlexUpdatesSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Storage.DeepCopyInto(&out.Storage)
-	out.Networking = in.Networking
+	in.Networking.DeepCopyInto(&out.Networking)
+	if in.Preferences != nil {
+		in, out := &in.Preferences, &out.Preferences
+		*out = new(PlexPreferencesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(PlexTLSSpec)
+		**out = **in
+	}
+	if in.TokenSecretRef != nil {
+		in, out := &in.TokenSecretRef, &out.TokenSecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Probes.DeepCopyInto(&out.Probes)
+	in.Termination.DeepCopyInto(&out.Termination)
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(PlexSecurityContextSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplateOverride != nil {
+		in, out := &in.PodTemplateOverride, &out.PodTemplateOverride
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CommonLabels != nil {
+		in, out := &in.CommonLabels, &out.CommonLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CommonAnnotations != nil {
+		in, out := &in.CommonAnnotations, &out.CommonAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodLabels != nil {
+		in, out := &in.PodLabels, &out.PodLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PodAnnotations != nil {
+		in, out := &in.PodAnnotations, &out.PodAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexMediaServerSpec.
@@ -96,11 +391,48 @@ func (in *PlexMediaServerStatus) DeepCopyInto(out *PlexMediaServerStatus) {
 	*out = *in
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
+		*out = make([]metav1.Condition, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]PlexRouteStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CustomConnections != nil {
+		in, out := &in.CustomConnections, &out.CustomConnections
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]PlexEndpoint, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(PlexDNSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Server != nil {
+		in, out := &in.Server, &out.Server
+		*out = new(PlexServerStatus)
+		**out = **in
+	}
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(PlexPodStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Updates != nil {
+		in, out := &in.Updates, &out.Updates
+		*out = new(PlexUpdatesStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexMediaServerStatus.
@@ -113,9 +445,109 @@ func (in *PlexMediaServerStatus) DeepCopy() *PlexMediaServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexNetworkPolicyEgress) DeepCopyInto(out *PlexNetworkPolicyEgress) {
+	*out = *in
+	if in.PlexTVCIDRs != nil {
+		in, out := &in.PlexTVCIDRs, &out.PlexTVCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NFSCIDRs != nil {
+		in, out := &in.NFSCIDRs, &out.NFSCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexNetworkPolicyEgress.
+func (in *PlexNetworkPolicyEgress) DeepCopy() *PlexNetworkPolicyEgress {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexNetworkPolicyEgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexNetworkPolicySpec) DeepCopyInto(out *PlexNetworkPolicySpec) {
+	*out = *in
+	if in.AllowedCIDRs != nil {
+		in, out := &in.AllowedCIDRs, &out.AllowedCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = new(PlexNetworkPolicyEgress)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexNetworkPolicySpec.
+func (in *PlexNetworkPolicySpec) DeepCopy() *PlexNetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexNetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlexNetworkSpec) DeepCopyInto(out *PlexNetworkSpec) {
 	*out = *in
+	if in.LANNetworks != nil {
+		in, out := &in.LANNetworks, &out.LANNetworks
+		*out = new(PlexLANNetworksSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]v1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]PlexPortSpec, len(*in))
+		copy(*out, *in)
+	}
+	if in.CustomConnections != nil {
+		in, out := &in.CustomConnections, &out.CustomConnections
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNS != nil {
+		in, out := &in.DNS, &out.DNS
+		*out = new(PlexDNSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ingress != nil {
+		in, out := &in.Ingress, &out.Ingress
+		*out = new(PlexIngressSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Gateway != nil {
+		in, out := &in.Gateway, &out.Gateway
+		*out = new(PlexGatewaySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(PlexRouteSpec)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(PlexNetworkPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexNetworkSpec.
@@ -128,6 +560,245 @@ func (in *PlexNetworkSpec) DeepCopy() *PlexNetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexPodStatus) DeepCopyInto(out *PlexPodStatus) {
+	*out = *in
+	if in.LastTermination != nil {
+		in, out := &in.LastTermination, &out.LastTermination
+		*out = new(PlexContainerTermination)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexPodStatus.
+func (in *PlexPodStatus) DeepCopy() *PlexPodStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexPodStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexPortSpec) DeepCopyInto(out *PlexPortSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexPortSpec.
+func (in *PlexPortSpec) DeepCopy() *PlexPortSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexPortSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexPreferencesSpec) DeepCopyInto(out *PlexPreferencesSpec) {
+	*out = *in
+	if in.RemoteAccess != nil {
+		in, out := &in.RemoteAccess, &out.RemoteAccess
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RelayEnabled != nil {
+		in, out := &in.RelayEnabled, &out.RelayEnabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.WANTotalMaxUploadRate != nil {
+		in, out := &in.WANTotalMaxUploadRate, &out.WANTotalMaxUploadRate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WANPerStreamMaxUploadRate != nil {
+		in, out := &in.WANPerStreamMaxUploadRate, &out.WANPerStreamMaxUploadRate
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Additional != nil {
+		in, out := &in.Additional, &out.Additional
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexPreferencesSpec.
+func (in *PlexPreferencesSpec) DeepCopy() *PlexPreferencesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexPreferencesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexProbeOptions) DeepCopyInto(out *PlexProbeOptions) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexProbeOptions.
+func (in *PlexProbeOptions) DeepCopy() *PlexProbeOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexProbeOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexProbesSpec) DeepCopyInto(out *PlexProbesSpec) {
+	*out = *in
+	if in.Startup != nil {
+		in, out := &in.Startup, &out.Startup
+		*out = new(PlexProbeOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(PlexProbeOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(PlexProbeOptions)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexProbesSpec.
+func (in *PlexProbesSpec) DeepCopy() *PlexProbesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexProbesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexRouteSpec) DeepCopyInto(out *PlexRouteSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexRouteSpec.
+func (in *PlexRouteSpec) DeepCopy() *PlexRouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexRouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexRouteStatus) DeepCopyInto(out *PlexRouteStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexRouteStatus.
+func (in *PlexRouteStatus) DeepCopy() *PlexRouteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexRouteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexSecurityContextSpec) DeepCopyInto(out *PlexSecurityContextSpec) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroupChangePolicy != nil {
+		in, out := &in.FSGroupChangePolicy, &out.FSGroupChangePolicy
+		*out = new(v1.PodFSGroupChangePolicy)
+		**out = **in
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(v1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DropCapabilities != nil {
+		in, out := &in.DropCapabilities, &out.DropCapabilities
+		*out = make([]v1.Capability, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexSecurityContextSpec.
+func (in *PlexSecurityContextSpec) DeepCopy() *PlexSecurityContextSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexSecurityContextSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexServerStatus) DeepCopyInto(out *PlexServerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexServerStatus.
+func (in *PlexServerStatus) DeepCopy() *PlexServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlexStorageOptions) DeepCopyInto(out *PlexStorageOptions) {
 	*out = *in
@@ -139,7 +810,7 @@ func (in *PlexStorageOptions) DeepCopyInto(out *PlexStorageOptions) {
 	}
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
-		*out = new(v1.LabelSelector)
+		*out = new(metav1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
 }
@@ -172,6 +843,11 @@ func (in *PlexStorageSpec) DeepCopyInto(out *PlexStorageSpec) {
 		*out = new(PlexStorageOptions)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Ownership != nil {
+		in, out := &in.Ownership, &out.Ownership
+		*out = new(PlexVolumeOwnershipSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexStorageSpec.
@@ -183,3 +859,108 @@ func (in *PlexStorageSpec) DeepCopy() *PlexStorageSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexTLSSpec) DeepCopyInto(out *PlexTLSSpec) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexTLSSpec.
+func (in *PlexTLSSpec) DeepCopy() *PlexTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexTerminationSpec) DeepCopyInto(out *PlexTerminationSpec) {
+	*out = *in
+	if in.GracePeriodSeconds != nil {
+		in, out := &in.GracePeriodSeconds, &out.GracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexTerminationSpec.
+func (in *PlexTerminationSpec) DeepCopy() *PlexTerminationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexTerminationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexUpdatesSpec) DeepCopyInto(out *PlexUpdatesSpec) {
+	*out = *in
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaintenanceWindow != nil {
+		in, out := &in.MaintenanceWindow, &out.MaintenanceWindow
+		*out = new(PlexMaintenanceWindow)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexUpdatesSpec.
+func (in *PlexUpdatesSpec) DeepCopy() *PlexUpdatesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexUpdatesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexUpdatesStatus) DeepCopyInto(out *PlexUpdatesStatus) {
+	*out = *in
+	if in.LastChecked != nil {
+		in, out := &in.LastChecked, &out.LastChecked
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexUpdatesStatus.
+func (in *PlexUpdatesStatus) DeepCopy() *PlexUpdatesStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexUpdatesStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlexVolumeOwnershipSpec) DeepCopyInto(out *PlexVolumeOwnershipSpec) {
+	*out = *in
+	if in.UID != nil {
+		in, out := &in.UID, &out.UID
+		*out = new(int64)
+		**out = **in
+	}
+	if in.GID != nil {
+		in, out := &in.GID, &out.GID
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlexVolumeOwnershipSpec.
+func (in *PlexVolumeOwnershipSpec) DeepCopy() *PlexVolumeOwnershipSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlexVolumeOwnershipSpec)
+	in.DeepCopyInto(out)
+	return out
+}
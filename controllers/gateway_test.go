@@ -0,0 +1,118 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/reconcilers"
+)
+
+var _ = Describe("Gateway API routes", func() {
+
+	var (
+		plex          *v1alpha1.PlexMediaServer
+		testNamespace *corev1.Namespace
+		ctx           context.Context
+	)
+
+	JustBeforeEach(func() {
+		ctx, testNamespace = InitTestEnvironment(k8sClient, plex)
+	})
+
+	JustAfterEach(func() {
+		TearDownTestEnvironment(ctx, k8sClient, plex, testNamespace)
+	})
+
+	When("a gateway is referenced with Roku access enabled", func() {
+
+		BeforeEach(func() {
+			plex = &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: RandomName("gateway"),
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						EnableRoku: true,
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name:      "gateway",
+								Namespace: "infra",
+							},
+							Hostnames: []string{"plex.example.com"},
+							RokuParentRef: &v1alpha1.PlexGatewayParentRef{
+								Name:        "gateway",
+								Namespace:   "infra",
+								SectionName: "roku",
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("creates an HTTPRoute for the Plex Media Server port", func() {
+			route := &unstructured.Unstructured{}
+			route.SetGroupVersionKind(reconcilers.HTTPRouteGVK)
+			By("finding the HTTPRoute")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, route)
+				if err != nil {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			By("checking the HTTPRoute spec")
+			hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostnames).To(ConsistOf("plex.example.com"))
+			parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parentRefs).To(ConsistOf(map[string]interface{}{
+				"name":      "gateway",
+				"namespace": "infra",
+			}))
+			rules, _, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(rules)).To(Equal(1))
+			backendRefs, _, err := unstructured.NestedSlice(rules[0].(map[string]interface{}), "backendRefs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backendRefs).To(ConsistOf(map[string]interface{}{
+				"name": plex.Name,
+				"port": int64(32400),
+			}))
+		})
+
+		It("creates a TCPRoute for the Roku port", func() {
+			route := &unstructured.Unstructured{}
+			route.SetGroupVersionKind(reconcilers.TCPRouteGVK)
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name + "-roku"}, route)
+				if err != nil {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			parentRefs, _, err := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parentRefs).To(ConsistOf(map[string]interface{}{
+				"name":        "gateway",
+				"namespace":   "infra",
+				"sectionName": "roku",
+			}))
+		})
+	})
+})
@@ -0,0 +1,120 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+var _ = Describe("Ingress", func() {
+
+	var (
+		plex          *v1alpha1.PlexMediaServer
+		testNamespace *corev1.Namespace
+		ctx           context.Context
+	)
+
+	JustBeforeEach(func() {
+		ctx, testNamespace = InitTestEnvironment(k8sClient, plex)
+	})
+
+	JustAfterEach(func() {
+		TearDownTestEnvironment(ctx, k8sClient, plex, testNamespace)
+	})
+
+	When("an ingress with TLS is enabled", func() {
+
+		BeforeEach(func() {
+			plex = &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: RandomName("ingress"),
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							Annotations: map[string]string{
+								"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+							},
+							TLS: &v1alpha1.PlexIngressTLS{
+								SecretName: "plex-cert",
+							},
+						},
+					},
+				},
+			}
+		})
+
+		It("creates an Ingress that routes traffic to the Plex Media Server", func() {
+			ingress := &networkingv1.Ingress{}
+			By("finding the ingress")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, ingress)
+				if err != nil {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			By("checking the ingress spec")
+			Expect(ingress.Annotations).To(HaveKeyWithValue("nginx.ingress.kubernetes.io/proxy-body-size", "0"))
+			Expect(len(ingress.Spec.Rules)).To(Equal(1))
+			rule := ingress.Spec.Rules[0]
+			Expect(rule.Host).To(Equal("plex.example.com"))
+			Expect(rule.HTTP).NotTo(BeNil())
+			Expect(len(rule.HTTP.Paths)).To(Equal(1))
+			Expect(rule.HTTP.Paths[0].Backend.Service).NotTo(BeNil())
+			Expect(rule.HTTP.Paths[0].Backend.Service.Name).To(Equal(plex.Name))
+			Expect(rule.HTTP.Paths[0].Backend.Service.Port.Name).To(Equal("plex"))
+			Expect(ingress.Spec.TLS).To(ConsistOf(networkingv1.IngressTLS{
+				Hosts:      []string{"plex.example.com"},
+				SecretName: "plex-cert",
+			}))
+		})
+
+		It("deletes the ingress if the ingress options are later removed", func() {
+			ingress := &networkingv1.Ingress{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, ingress)
+				if err != nil {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			var err error
+			Eventually(func() bool {
+				currentPlex := &v1alpha1.PlexMediaServer{}
+				err = k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, currentPlex)
+				if err != nil {
+					return true
+				}
+				currentPlex.Spec.Networking.Ingress = nil
+				err = k8sClient.Update(ctx, currentPlex, &client.UpdateOptions{})
+				if errors.IsConflict(err) {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() bool {
+				err = k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, &networkingv1.Ingress{})
+				return errors.IsNotFound(err)
+			}, retryTimeout, retryInterval).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,83 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package controllers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+var _ = Describe("Network policy", func() {
+
+	var (
+		plex          *v1alpha1.PlexMediaServer
+		testNamespace *corev1.Namespace
+		ctx           context.Context
+	)
+
+	JustBeforeEach(func() {
+		ctx, testNamespace = InitTestEnvironment(k8sClient, plex)
+	})
+
+	JustAfterEach(func() {
+		TearDownTestEnvironment(ctx, k8sClient, plex, testNamespace)
+	})
+
+	When("a network policy is enabled with Roku access", func() {
+
+		BeforeEach(func() {
+			plex = &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: RandomName("netpol"),
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						EnableRoku: true,
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{
+							AllowedCIDRs: []string{"192.168.0.0/16"},
+						},
+					},
+				},
+			}
+		})
+
+		It("allows traffic to the Plex and Roku ports", func() {
+			policy := &networkingv1.NetworkPolicy{}
+			By("finding the network policy")
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, policy)
+				if err != nil {
+					return false
+				}
+				return true
+			}, retryTimeout, retryInterval).Should(BeTrue())
+			By("checking the network policy spec")
+			Expect(policy.Spec.PodSelector.MatchLabels).To(HaveKeyWithValue("plex.adambkaplan.com/instance", plex.Name))
+			Expect(len(policy.Spec.Ingress)).To(Equal(1))
+			foundPorts := []int32{}
+			for _, port := range policy.Spec.Ingress[0].Ports {
+				Expect(port.Port).NotTo(BeNil())
+				foundPorts = append(foundPorts, port.Port.IntVal)
+			}
+			Expect(foundPorts).To(ConsistOf(int32(8324), int32(32400)))
+			Expect(policy.Spec.Ingress[0].From).To(ConsistOf(networkingv1.NetworkPolicyPeer{
+				IPBlock: &networkingv1.IPBlock{
+					CIDR: "192.168.0.0/16",
+				},
+			}))
+		})
+	})
+})
@@ -8,25 +8,43 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	plexv1alpha1 "github.com/adambkaplan/plex-operator/api/v1alpha1"
 	"github.com/adambkaplan/plex-operator/pkg/reconcilers"
 )
 
+// tlsSecretRefField indexes PlexMediaServers by the name of their TLS Secret
+const tlsSecretRefField = "spec.tls.secretRef.name"
+
 // PlexMediaServerReconciler reconciles a PlexMediaServer object
 type PlexMediaServerReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Platform reconcilers.Platform
 }
 
 // +kubebuilder:rbac:groups=plex.adambkaplan.com,resources=plexmediaservers,verbs=get;list;watch;create;update;patch;delete
@@ -34,6 +52,20 @@ type PlexMediaServerReconciler struct {
 // +kubebuilder:rbac:groups=plex.adambkaplan.com,resources=plexmediaservers/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;tcproutes;udproutes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -61,15 +93,25 @@ func (r *PlexMediaServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{Requeue: true}, err
 	}
 
-	reconcilers := []reconcilers.Reconciler{
-		reconcilers.NewServiceReconciler(r.Client, log, r.Scheme),
-		reconcilers.NewExternalServiceReconciler(r.Client, log, r.Scheme),
-		reconcilers.NewStatefulSetReconciler(r.Client, log, r.Scheme),
-		reconcilers.NewStatusReconciler(r.Client, log, r.Scheme),
+	plexReconcilers := []reconcilers.Reconciler{
+		reconcilers.NewUpdatesReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewServiceReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewExternalServiceReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewDNSEndpointReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewNetworkPolicyReconciler(r.Client, log, r.Scheme, r.Recorder, r.Platform),
+		reconcilers.NewIngressReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewCertificateReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewGatewayReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewRouteReconciler(r.Client, log, r.Scheme, r.Recorder, r.Platform),
+		reconcilers.NewTLSSecretReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewPreferencesReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewServiceAccountReconciler(r.Client, log, r.Scheme, r.Recorder),
+		reconcilers.NewStatefulSetReconciler(r.Client, log, r.Scheme, r.Recorder, r.Platform),
+		reconcilers.NewStatusReconciler(r.Client, log, r.Scheme, r.Recorder, r.Platform),
 	}
 	requeueResult := false
 	plex := currentPlex.DeepCopy()
-	for _, r := range reconcilers {
+	for _, r := range plexReconcilers {
 		requeue, err := r.Reconcile(ctx, plex)
 		if err != nil {
 			return ctrl.Result{Requeue: true}, err
@@ -77,14 +119,168 @@ func (r *PlexMediaServerReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		requeueResult = requeueResult || requeue
 	}
 	log.WithValues("requeue", requeueResult).Info("finised reconcile")
-	return ctrl.Result{Requeue: requeueResult}, nil
+	if requeueResult {
+		return ctrl.Result{Requeue: true}, nil
+	}
+	// Reconcile again to check for updates, or to apply an update when the maintenance window opens
+	return ctrl.Result{RequeueAfter: reconcilers.UpdatesRequeueAfter(plex, time.Now())}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PlexMediaServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &plexv1alpha1.PlexMediaServer{}, tlsSecretRefField,
+		tlsSecretRefIndex); err != nil {
+		return err
+	}
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&plexv1alpha1.PlexMediaServer{}).
 		Owns(&appsv1.StatefulSet{}).
+		// Load balancer status changes on the external Service update Plex's custom connections
 		Owns(&corev1.Service{}).
-		Complete(r)
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&corev1.ConfigMap{}).
+		// Only the metadata of Secrets is cached - the manager's client reads Secrets from the API
+		// server
+		Owns(&corev1.Secret{}, ctrlbuilder.OnlyMetadata).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&source.Kind{Type: &corev1.Node{}},
+			handler.EnqueueRequestsFromMapFunc(r.plexForNodes),
+			ctrlbuilder.WithPredicates(nodeNetworkChanged())).
+		Watches(&source.Kind{Type: &corev1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.plexForTLSSecret),
+			ctrlbuilder.OnlyMetadata).
+		// Plex's pod is owned by the StatefulSet, so its status changes are mapped to Plex by label
+		Watches(&source.Kind{Type: &corev1.Pod{}},
+			handler.EnqueueRequestsFromMapFunc(plexForPod),
+			ctrlbuilder.WithPredicates(podStatusChanged()))
+	if r.Platform.OpenShift {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(reconcilers.RouteGVK)
+		builder = builder.Owns(route)
+	}
+	// Gateway API routes, ExternalDNS endpoints, and cert-manager Certificates are optional - only
+	// watch them if their APIs are installed
+	for _, gvk := range []schema.GroupVersionKind{
+		reconcilers.CertificateGVK,
+		reconcilers.HTTPRouteGVK,
+		reconcilers.TCPRouteGVK,
+		reconcilers.UDPRouteGVK,
+		reconcilers.DNSEndpointGVK,
+	} {
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			r.Log.WithValues("kind", gvk.Kind).Info("API not installed, skipping watch")
+			continue
+		}
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(gvk)
+		builder = builder.Owns(route)
+	}
+	return builder.Complete(r)
+}
+
+// plexForNodes returns reconcile requests for each PlexMediaServer that treats the cluster's
+// networks as local or is exposed through a NodePort Service, so that Plex is updated when Nodes
+// join or leave the cluster.
+func (r *PlexMediaServerReconciler) plexForNodes(obj client.Object) []reconcile.Request {
+	plexList := &plexv1alpha1.PlexMediaServerList{}
+	if err := r.Client.List(context.Background(), plexList); err != nil {
+		r.Log.Error(err, "failed to list PlexMediaServers")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, plex := range plexList.Items {
+		lanSpec := plex.Spec.Networking.LANNetworks
+		includeClusterNetworks := lanSpec != nil && lanSpec.IncludeClusterNetworks
+		nodePort := plex.Spec.Networking.ExternalServiceType == corev1.ServiceTypeNodePort
+		if !includeClusterNetworks && !nodePort {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name},
+		})
+	}
+	return requests
+}
+
+// plexForTLSSecret returns reconcile requests for each PlexMediaServer that uses the TLS Secret, so
+// that Plex's certificate is updated when it is renewed.
+func (r *PlexMediaServerReconciler) plexForTLSSecret(obj client.Object) []reconcile.Request {
+	plexList := &plexv1alpha1.PlexMediaServerList{}
+	if err := r.Client.List(context.Background(), plexList, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{tlsSecretRefField: obj.GetName()}); err != nil {
+		r.Log.Error(err, "failed to list PlexMediaServers")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, plex := range plexList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name},
+		})
+	}
+	return requests
+}
+
+// tlsSecretRefIndex returns the name of the PlexMediaServer's TLS Secret, if it has one
+func tlsSecretRefIndex(obj client.Object) []string {
+	plex, ok := obj.(*plexv1alpha1.PlexMediaServer)
+	if !ok || plex.Spec.TLS == nil || plex.Spec.TLS.SecretRef.Name == "" {
+		return nil
+	}
+	return []string{plex.Spec.TLS.SecretRef.Name}
+}
+
+// plexForPod returns a reconcile request for the PlexMediaServer that the pod belongs to, so that
+// the pod's state is reported in Plex's status
+func plexForPod(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()["plex.adambkaplan.com/instance"]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name},
+		},
+	}
+}
+
+// podStatusChanged filters pod updates to those that change the pod's phase, conditions, or
+// container states
+func podStatusChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldPod, ok := e.ObjectOld.(*corev1.Pod)
+			if !ok {
+				return false
+			}
+			newPod, ok := e.ObjectNew.(*corev1.Pod)
+			if !ok {
+				return false
+			}
+			return oldPod.Status.Phase != newPod.Status.Phase ||
+				!equality.Semantic.DeepEqual(oldPod.Status.Conditions, newPod.Status.Conditions) ||
+				!equality.Semantic.DeepEqual(oldPod.Status.InitContainerStatuses, newPod.Status.InitContainerStatuses) ||
+				!equality.Semantic.DeepEqual(oldPod.Status.ContainerStatuses, newPod.Status.ContainerStatuses)
+		},
+	}
+}
+
+// nodeNetworkChanged filters Node updates to those that change the Node's pod networks or
+// addresses
+func nodeNetworkChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, ok := e.ObjectOld.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			newNode, ok := e.ObjectNew.(*corev1.Node)
+			if !ok {
+				return false
+			}
+			return oldNode.Spec.PodCIDR != newNode.Spec.PodCIDR ||
+				!equality.Semantic.DeepEqual(oldNode.Spec.PodCIDRs, newNode.Spec.PodCIDRs) ||
+				!equality.Semantic.DeepEqual(oldNode.Status.Addresses, newNode.Status.Addresses)
+		},
+	}
 }
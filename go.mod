@@ -12,4 +12,5 @@ require (
 	k8s.io/apimachinery v0.19.2
 	k8s.io/client-go v0.19.2
 	sigs.k8s.io/controller-runtime v0.7.0
+	software.sslmate.com/src/go-pkcs12 v0.4.0
 )
@@ -24,15 +24,19 @@ import (
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	plexv1alpha1 "github.com/adambkaplan/plex-operator/api/v1alpha1"
 	"github.com/adambkaplan/plex-operator/controllers"
+	"github.com/adambkaplan/plex-operator/pkg/reconcilers"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -72,16 +76,35 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "189dd63d.my.domain",
+		// Read Secrets from the API server, so that the contents of every Secret in the cluster are
+		// not held in the cache
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create discovery client")
+		os.Exit(1)
+	}
+	platform, err := reconcilers.DetectPlatform(discoveryClient)
+	if err != nil {
+		setupLog.Error(err, "unable to detect platform")
+		os.Exit(1)
+	}
+	// The operator's namespace is set by the downward API when the operator runs on the cluster
+	platform.OperatorNamespace = os.Getenv("POD_NAMESPACE")
+	setupLog.Info("detected platform", "openshift", platform.OpenShift, "namespace", platform.OperatorNamespace)
+
 	if err = (&controllers.PlexMediaServerReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("PlexMediaServer"),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("PlexMediaServer"),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("plex-operator"),
+		Platform: platform,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PlexMediaServer")
 		os.Exit(1)
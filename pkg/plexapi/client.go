@@ -0,0 +1,254 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package plexapi is a client for the Plex Media Server HTTP API. The operator uses it to query
+// and configure the servers it deploys.
+package plexapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// tokenHeader is the header Plex reads the X-Plex-Token from
+	tokenHeader = "X-Plex-Token"
+
+	// product identifies the operator to Plex
+	product = "plex-operator"
+
+	// defaultTimeout is the timeout of requests made with the default HTTP client
+	defaultTimeout = 10 * time.Second
+)
+
+// ErrNoToken is returned if the PlexMediaServer does not reference a token Secret
+var ErrNoToken = errors.New("no X-Plex-Token secret is referenced")
+
+// StatusError is returned when Plex responds with an unsuccessful HTTP status
+type StatusError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsUnauthorized returns true if Plex rejected the request's X-Plex-Token
+func IsUnauthorized(err error) bool {
+	statusErr := &StatusError{}
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusUnauthorized || statusErr.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// Client queries a Plex Media Server's HTTP API
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a new Client for the Plex Media Server at baseURL. Requests are authenticated
+// with the X-Plex-Token if one is provided. If httpClient is nil, a client with a short timeout is
+// used.
+func NewClient(baseURL string, token string, httpClient *http.Client) (*Client, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Plex URL %q", baseURL)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	return &Client{
+		baseURL:    parsed,
+		token:      token,
+		httpClient: httpClient,
+	}, nil
+}
+
+// ServerURL returns the URL of the PlexMediaServer's pod, reached through its headless Service on
+// the port Plex listens on
+func ServerURL(plex *v1alpha1.PlexMediaServer, port int32) string {
+	return fmt.Sprintf("http://%s-0.%s.%s.svc:%d", plex.Name, plex.Name, plex.Namespace, port)
+}
+
+// TokenFromSecret returns the X-Plex-Token in the Secret referenced by the PlexMediaServer.
+// ErrNoToken is returned if no Secret is referenced.
+func TokenFromSecret(ctx context.Context, reader client.Reader, plex *v1alpha1.PlexMediaServer) (string, error) {
+	ref := plex.Spec.TokenSecretRef
+	if ref == nil {
+		return "", ErrNoToken
+	}
+	secret := &corev1.Secret{}
+	err := reader.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: ref.Name}, secret)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(secret.Data[ref.Key]))
+	if token == "" {
+		return "", fmt.Errorf("secret %s has no X-Plex-Token in key %s", ref.Name, ref.Key)
+	}
+	return token, nil
+}
+
+// Identity returns the server's identity. Plex does not require a token for this endpoint.
+func (c *Client) Identity(ctx context.Context) (*Identity, error) {
+	response := &struct {
+		MediaContainer Identity `json:"MediaContainer"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/identity", nil, response); err != nil {
+		return nil, err
+	}
+	return &response.MediaContainer, nil
+}
+
+// ServerInfo returns information about the server and its plex.tv sign-in state
+func (c *Client) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	response := &struct {
+		MediaContainer ServerInfo `json:"MediaContainer"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/", nil, response); err != nil {
+		return nil, err
+	}
+	return &response.MediaContainer, nil
+}
+
+// Sessions returns the server's active playback sessions
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	response := &struct {
+		MediaContainer struct {
+			Metadata []Session `json:"Metadata"`
+		} `json:"MediaContainer"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/status/sessions", nil, response); err != nil {
+		return nil, err
+	}
+	return response.MediaContainer.Metadata, nil
+}
+
+// LibrarySections returns the server's libraries
+func (c *Client) LibrarySections(ctx context.Context) ([]LibrarySection, error) {
+	response := &struct {
+		MediaContainer struct {
+			Directory []LibrarySection `json:"Directory"`
+		} `json:"MediaContainer"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/library/sections", nil, response); err != nil {
+		return nil, err
+	}
+	return response.MediaContainer.Directory, nil
+}
+
+// Preferences returns the server's preferences
+func (c *Client) Preferences(ctx context.Context) ([]Setting, error) {
+	response := &struct {
+		MediaContainer struct {
+			Setting []Setting `json:"Setting"`
+		} `json:"MediaContainer"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/:/prefs", nil, response); err != nil {
+		return nil, err
+	}
+	return response.MediaContainer.Setting, nil
+}
+
+// SetPreferences sets preferences on the running server. Plex saves the preferences to
+// Preferences.xml.
+func (c *Client) SetPreferences(ctx context.Context, preferences map[string]string) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	query := url.Values{}
+	for key, value := range preferences {
+		query.Set(key, value)
+	}
+	return c.do(ctx, http.MethodPut, "/:/prefs", query, nil)
+}
+
+// ButlerTasks returns the server's scheduled maintenance tasks
+func (c *Client) ButlerTasks(ctx context.Context) ([]ButlerTask, error) {
+	response := &struct {
+		ButlerTasks struct {
+			ButlerTask []ButlerTask `json:"ButlerTask"`
+		} `json:"ButlerTasks"`
+	}{}
+	if err := c.do(ctx, http.MethodGet, "/butler", nil, response); err != nil {
+		return nil, err
+	}
+	tasks := response.ButlerTasks.ButlerTask
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].Name < tasks[j].Name
+	})
+	return tasks, nil
+}
+
+// RunButlerTask starts the named maintenance task
+func (c *Client) RunButlerTask(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/butler/%s", url.PathEscape(name)), nil, nil)
+}
+
+// do sends a request to Plex and decodes the JSON response into out, if provided
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, out interface{}) error {
+	requestURL := *c.baseURL
+	requestURL.Path = strings.TrimSuffix(requestURL.Path, "/") + path
+	requestURL.RawQuery = query.Encode()
+	request, err := http.NewRequestWithContext(ctx, method, requestURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("X-Plex-Product", product)
+	request.Header.Set("X-Plex-Client-Identifier", product)
+	if c.token != "" {
+		request.Header.Set(tokenHeader, c.token)
+	}
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return &StatusError{
+			Method:     method,
+			Path:       path,
+			StatusCode: response.StatusCode,
+			Message:    strings.TrimSpace(string(message)),
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(response.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: failed to decode response: %v", method, path, err)
+	}
+	return nil
+}
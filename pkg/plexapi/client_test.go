@@ -0,0 +1,200 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package plexapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+	fakeplex "github.com/adambkaplan/plex-operator/pkg/plexapi/fake"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.TODO()
+	server := fakeplex.NewServer("token")
+	defer server.Close()
+	server.Sessions = []plexapi.Session{
+		{
+			SessionKey: "1",
+			Title:      "Big Buck Bunny",
+			Type:       "movie",
+			Player: &plexapi.SessionPlayer{
+				Title: "Living Room",
+				State: "playing",
+				Local: true,
+			},
+		},
+	}
+	server.LibrarySections = []plexapi.LibrarySection{
+		{
+			Key:   "1",
+			Title: "Movies",
+			Type:  "movie",
+			Locations: []plexapi.LibraryLocation{
+				{ID: 1, Path: "/data/movies"},
+			},
+		},
+	}
+	server.Settings = []plexapi.Setting{
+		{ID: "FriendlyName", Type: "text", Value: "plex"},
+		{ID: "RelayEnabled", Type: "bool", Value: "1"},
+	}
+	server.ButlerTasks = []plexapi.ButlerTask{
+		{Name: "OptimizeDatabase", Interval: 7, Enabled: true},
+		{Name: "BackupDatabase", Interval: 3, Enabled: true},
+	}
+	c := server.Client("token")
+
+	identity, err := c.Identity(ctx)
+	require.NoError(t, err, "failed to get identity")
+	assert.Equal(t, server.Identity, *identity, "identity should be equal")
+
+	info, err := c.ServerInfo(ctx)
+	require.NoError(t, err, "failed to get server info")
+	assert.Equal(t, server.ServerInfo, *info, "server info should be equal")
+
+	sessions, err := c.Sessions(ctx)
+	require.NoError(t, err, "failed to get sessions")
+	assert.Equal(t, server.Sessions, sessions, "sessions should be equal")
+
+	sections, err := c.LibrarySections(ctx)
+	require.NoError(t, err, "failed to get library sections")
+	assert.Equal(t, server.LibrarySections, sections, "library sections should be equal")
+
+	err = c.SetPreferences(ctx, map[string]string{"FriendlyName": "living-room", "RelayEnabled": "0"})
+	require.NoError(t, err, "failed to set preferences")
+	settings, err := c.Preferences(ctx)
+	require.NoError(t, err, "failed to get preferences")
+	require.Len(t, settings, 2, "unexpected number of preferences")
+	assert.Equal(t, plexapi.SettingValue("living-room"), settings[0].Value, "friendly name should be updated")
+	assert.False(t, settings[1].Value.Bool(), "relay should be disabled")
+
+	tasks, err := c.ButlerTasks(ctx)
+	require.NoError(t, err, "failed to get butler tasks")
+	require.Len(t, tasks, 2, "unexpected number of butler tasks")
+	assert.Equal(t, "BackupDatabase", tasks[0].Name, "butler tasks should be sorted by name")
+	err = c.RunButlerTask(ctx, "BackupDatabase")
+	require.NoError(t, err, "failed to run butler task")
+	assert.Equal(t, []string{"BackupDatabase"}, server.RanButlerTasks, "butler task should be started")
+	err = c.RunButlerTask(ctx, "Unknown")
+	assert.Error(t, err, "expected error running unknown butler task")
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	ctx := context.TODO()
+	server := fakeplex.NewServer("token")
+	defer server.Close()
+	c := server.Client("wrong")
+
+	_, err := c.Identity(ctx)
+	assert.NoError(t, err, "identity should not require a token")
+	_, err = c.ServerInfo(ctx)
+	assert.True(t, plexapi.IsUnauthorized(err), "expected unauthorized error, got %v", err)
+}
+
+func TestDownloads(t *testing.T) {
+	ctx := context.TODO()
+	server := fakeplex.NewServer("token")
+	defer server.Close()
+
+	downloads, err := server.Client("").Downloads(ctx, false)
+	require.NoError(t, err, "public channel should not require a token")
+	version, err := downloads.Version("Linux", "debian")
+	require.NoError(t, err, "failed to resolve public version")
+	assert.Equal(t, "1.32.5.7349-8f4248874", version, "public version should be equal")
+	_, err = downloads.Version("Linux", "arch")
+	assert.Error(t, err, "expected error for a distro without a build")
+	_, err = downloads.Version("MacOSX", "debian")
+	assert.Error(t, err, "expected error for a platform without a release")
+
+	_, err = server.Client("").Downloads(ctx, true)
+	assert.Equal(t, plexapi.ErrNoToken, err, "beta channel should require a token")
+	_, err = server.Client("wrong").Downloads(ctx, true)
+	assert.True(t, plexapi.IsUnauthorized(err), "expected unauthorized error, got %v", err)
+	downloads, err = server.Client("token").Downloads(ctx, true)
+	require.NoError(t, err, "failed to get beta downloads")
+	version, err = downloads.Version("Linux", "debian")
+	require.NoError(t, err, "failed to resolve beta version")
+	assert.Equal(t, "1.32.6.7371-b7d1a3e9c", version, "beta version should be equal")
+}
+
+func TestSettingValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		json     string
+		expected plexapi.SettingValue
+	}{
+		{name: "string", json: `"plex"`, expected: "plex"},
+		{name: "true", json: `true`, expected: "1"},
+		{name: "false", json: `false`, expected: "0"},
+		{name: "number", json: `20000`, expected: "20000"},
+		{name: "null", json: `null`, expected: ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var value plexapi.SettingValue
+			err := json.Unmarshal([]byte(tc.json), &value)
+			require.NoError(t, err, "failed to decode value")
+			assert.Equal(t, tc.expected, value, "values should be equal")
+		})
+	}
+}
+
+func TestTokenFromSecret(t *testing.T) {
+	ctx := context.TODO()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "plex",
+			Name:      "plex-token",
+		},
+		Data: map[string][]byte{
+			"token": []byte("abc123\n"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(secret).Build()
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "plex",
+			Name:      "plex",
+		},
+	}
+
+	_, err := plexapi.TokenFromSecret(ctx, c, plex)
+	assert.Equal(t, plexapi.ErrNoToken, err, "expected no token error")
+
+	plex.Spec.TokenSecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "plex-token"},
+		Key:                  "token",
+	}
+	token, err := plexapi.TokenFromSecret(ctx, c, plex)
+	require.NoError(t, err, "failed to get token")
+	assert.Equal(t, "abc123", token, "token should be equal")
+
+	plex.Spec.TokenSecretRef.Key = "missing"
+	_, err = plexapi.TokenFromSecret(ctx, c, plex)
+	assert.Error(t, err, "expected error for missing key")
+}
+
+func TestServerURL(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "media",
+			Name:      "plex",
+		},
+	}
+	assert.Equal(t, "http://plex-0.plex.media.svc:32400", plexapi.ServerURL(plex, 32400), "server URL should be equal")
+}
@@ -0,0 +1,56 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package plexapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// PlexTVURL is the URL of plex.tv, which lists the Plex Media Server releases
+	PlexTVURL = "https://plex.tv"
+
+	// downloadsPath is plex.tv's endpoint for the newest Plex Media Server release of each platform
+	downloadsPath = "/api/downloads/5.json"
+
+	// betaChannel is the query value of plex.tv's Plex Pass beta channel
+	betaChannel = "plexpass"
+)
+
+// Downloads returns the newest Plex Media Server release of each platform from plex.tv. The beta
+// channel requires the X-Plex-Token of a Plex Pass account.
+func (c *Client) Downloads(ctx context.Context, beta bool) (*Downloads, error) {
+	query := url.Values{}
+	if beta {
+		if c.token == "" {
+			return nil, ErrNoToken
+		}
+		query.Set("channel", betaChannel)
+	}
+	downloads := &Downloads{}
+	if err := c.do(ctx, http.MethodGet, downloadsPath, query, downloads); err != nil {
+		return nil, err
+	}
+	return downloads, nil
+}
+
+// Version returns the version of the platform's newest release that is built for the distro. An
+// error is returned if plex.tv does not list a build for the platform and distro.
+func (d *Downloads) Version(platform string, distro string) (string, error) {
+	downloads, ok := d.Computer[platform]
+	if !ok || downloads.Version == "" {
+		return "", fmt.Errorf("plex.tv does not list a release for %s", platform)
+	}
+	for _, release := range downloads.Releases {
+		if release.Distro == distro {
+			return downloads.Version, nil
+		}
+	}
+	return "", fmt.Errorf("plex.tv does not list a %s build of Plex Media Server %s for %s", distro, downloads.Version, platform)
+}
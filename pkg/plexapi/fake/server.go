@@ -0,0 +1,205 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package fake provides an in-memory Plex Media Server HTTP API for tests
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+)
+
+// Server is a fake Plex Media Server backed by an httptest.Server. It also stands in for plex.tv's
+// downloads endpoint. Requests other than /identity and the public downloads channel must provide
+// the server's Token. The exported fields are the server's state, and must not be
+// changed while requests are in flight.
+type Server struct {
+	*httptest.Server
+
+	// Token is the X-Plex-Token the server accepts
+	Token string
+
+	Identity        plexapi.Identity
+	ServerInfo      plexapi.ServerInfo
+	Sessions        []plexapi.Session
+	LibrarySections []plexapi.LibrarySection
+	Settings        []plexapi.Setting
+	ButlerTasks     []plexapi.ButlerTask
+
+	// Downloads and BetaDownloads are the releases listed on plex.tv's public and Plex Pass beta
+	// channels
+	Downloads     plexapi.Downloads
+	BetaDownloads plexapi.Downloads
+
+	// RanButlerTasks records the names of the butler tasks that were started
+	RanButlerTasks []string
+
+	lock sync.Mutex
+}
+
+// NewServer starts a fake Plex Media Server that accepts the given X-Plex-Token. The server must
+// be closed when the test is done.
+func NewServer(token string) *Server {
+	server := &Server{
+		Token: token,
+		Identity: plexapi.Identity{
+			MachineIdentifier: "0123456789abcdef0123456789abcdef01234567",
+			Version:           "1.32.5.7349-8f4248874",
+			Claimed:           true,
+		},
+		ServerInfo: plexapi.ServerInfo{
+			FriendlyName:      "plex",
+			MachineIdentifier: "0123456789abcdef0123456789abcdef01234567",
+			Version:           "1.32.5.7349-8f4248874",
+			Platform:          "Linux",
+			MyPlex:            true,
+			MyPlexSigninState: "ok",
+		},
+		Downloads:     LinuxDownloads("1.32.5.7349-8f4248874"),
+		BetaDownloads: LinuxDownloads("1.32.6.7371-b7d1a3e9c"),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// LinuxDownloads returns a plex.tv downloads listing with Linux builds of the given version
+func LinuxDownloads(version string) plexapi.Downloads {
+	return plexapi.Downloads{
+		Computer: map[string]plexapi.PlatformDownloads{
+			"Linux": {
+				ID:      "linux",
+				Name:    "Linux",
+				Version: version,
+				Releases: []plexapi.Release{
+					{
+						Label:  "Ubuntu (16.04+) / Debian (8+) - Intel/AMD 64-bit",
+						Build:  "linux-x86_64",
+						Distro: "debian",
+						URL:    "https://downloads.plex.tv/plex-media-server-new/" + version + "/debian/plexmediaserver_" + version + "_amd64.deb",
+					},
+					{
+						Label:  "Fedora (27+) / CentOS (7+) / SUSE (15+) - Intel/AMD 64-bit",
+						Build:  "linux-x86_64",
+						Distro: "redhat",
+						URL:    "https://downloads.plex.tv/plex-media-server-new/" + version + "/redhat/plexmediaserver-" + version + ".x86_64.rpm",
+					},
+				},
+			},
+		},
+	}
+}
+
+// Client returns a plexapi Client for the server that uses the given token
+func (s *Server) Client(token string) *plexapi.Client {
+	client, err := plexapi.NewClient(s.URL, token, s.Server.Client())
+	if err != nil {
+		// The httptest server's URL is always valid
+		panic(err)
+	}
+	return client
+}
+
+// Preference returns the value of the server's preference
+func (s *Server) Preference(id string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, setting := range s.Settings {
+		if setting.ID == id {
+			return string(setting.Value), true
+		}
+	}
+	return "", false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	beta := r.URL.Query().Get("channel") == "plexpass"
+	public := r.URL.Path == "/identity" || (r.URL.Path == "/api/downloads/5.json" && !beta)
+	if !public && r.Header.Get("X-Plex-Token") != s.Token {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/identity":
+		writeJSON(w, map[string]interface{}{"MediaContainer": s.Identity})
+	case r.Method == http.MethodGet && r.URL.Path == "/api/downloads/5.json":
+		if beta {
+			writeJSON(w, s.BetaDownloads)
+			return
+		}
+		writeJSON(w, s.Downloads)
+	case r.Method == http.MethodGet && r.URL.Path == "/":
+		writeJSON(w, map[string]interface{}{"MediaContainer": s.ServerInfo})
+	case r.Method == http.MethodGet && r.URL.Path == "/status/sessions":
+		writeJSON(w, map[string]interface{}{"MediaContainer": map[string]interface{}{
+			"size":     len(s.Sessions),
+			"Metadata": s.Sessions,
+		}})
+	case r.Method == http.MethodGet && r.URL.Path == "/library/sections":
+		writeJSON(w, map[string]interface{}{"MediaContainer": map[string]interface{}{
+			"size":      len(s.LibrarySections),
+			"Directory": s.LibrarySections,
+		}})
+	case r.Method == http.MethodGet && r.URL.Path == "/:/prefs":
+		writeJSON(w, map[string]interface{}{"MediaContainer": map[string]interface{}{
+			"size":    len(s.Settings),
+			"Setting": s.Settings,
+		}})
+	case r.Method == http.MethodPut && r.URL.Path == "/:/prefs":
+		s.setPreferences(r)
+	case r.Method == http.MethodGet && r.URL.Path == "/butler":
+		writeJSON(w, map[string]interface{}{"ButlerTasks": map[string]interface{}{
+			"ButlerTask": s.ButlerTasks,
+		}})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/butler/"):
+		name := strings.TrimPrefix(r.URL.Path, "/butler/")
+		for _, task := range s.ButlerTasks {
+			if task.Name == name {
+				s.RanButlerTasks = append(s.RanButlerTasks, name)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// setPreferences updates the server's preferences from the request's query parameters. Unknown
+// preferences are added as text settings.
+func (s *Server) setPreferences(r *http.Request) {
+	for id, values := range r.URL.Query() {
+		value := plexapi.SettingValue(values[len(values)-1])
+		found := false
+		for i := range s.Settings {
+			if s.Settings[i].ID == id {
+				s.Settings[i].Value = value
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.Settings = append(s.Settings, plexapi.Setting{
+				ID:    id,
+				Type:  "text",
+				Value: value,
+			})
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
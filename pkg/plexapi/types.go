@@ -0,0 +1,177 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package plexapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// Identity is the response of Plex's /identity endpoint, which does not require a token
+type Identity struct {
+	MachineIdentifier string `json:"machineIdentifier"`
+	Version           string `json:"version"`
+	Claimed           bool   `json:"claimed"`
+}
+
+// ServerInfo is the response of Plex's root endpoint
+type ServerInfo struct {
+	FriendlyName                  string `json:"friendlyName"`
+	MachineIdentifier             string `json:"machineIdentifier"`
+	Version                       string `json:"version"`
+	Platform                      string `json:"platform"`
+	PlatformVersion               string `json:"platformVersion,omitempty"`
+	MyPlex                        bool   `json:"myPlex"`
+	MyPlexSigninState             string `json:"myPlexSigninState,omitempty"`
+	MyPlexUsername                string `json:"myPlexUsername,omitempty"`
+	MyPlexSubscription            bool   `json:"myPlexSubscription"`
+	TranscoderActiveVideoSessions int    `json:"transcoderActiveVideoSessions"`
+	UpdatedAt                     int64  `json:"updatedAt,omitempty"`
+}
+
+// Session is an active playback session reported by Plex's /status/sessions endpoint
+type Session struct {
+	SessionKey       string            `json:"sessionKey"`
+	Title            string            `json:"title"`
+	GrandparentTitle string            `json:"grandparentTitle,omitempty"`
+	Type             string            `json:"type"`
+	User             *SessionUser      `json:"User,omitempty"`
+	Player           *SessionPlayer    `json:"Player,omitempty"`
+	Session          *SessionDetails   `json:"Session,omitempty"`
+	TranscodeSession *TranscodeSession `json:"TranscodeSession,omitempty"`
+}
+
+// SessionUser is the Plex user watching a session
+type SessionUser struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// SessionPlayer is the Plex client playing a session
+type SessionPlayer struct {
+	Title   string `json:"title"`
+	Product string `json:"product,omitempty"`
+	State   string `json:"state"`
+	Address string `json:"address,omitempty"`
+	Local   bool   `json:"local"`
+}
+
+// SessionDetails reports the bandwidth and network location of a session
+type SessionDetails struct {
+	ID        string `json:"id"`
+	Bandwidth int64  `json:"bandwidth"`
+	Location  string `json:"location"`
+}
+
+// TranscodeSession is set if Plex is transcoding the media of a session
+type TranscodeSession struct {
+	Key           string `json:"key"`
+	VideoDecision string `json:"videoDecision,omitempty"`
+	AudioDecision string `json:"audioDecision,omitempty"`
+	Throttled     bool   `json:"throttled"`
+}
+
+// LibrarySection is a library reported by Plex's /library/sections endpoint
+type LibrarySection struct {
+	Key        string            `json:"key"`
+	Title      string            `json:"title"`
+	Type       string            `json:"type"`
+	Agent      string            `json:"agent,omitempty"`
+	Scanner    string            `json:"scanner,omitempty"`
+	Language   string            `json:"language,omitempty"`
+	Refreshing bool              `json:"refreshing"`
+	Locations  []LibraryLocation `json:"Location,omitempty"`
+}
+
+// LibraryLocation is a directory scanned by a library
+type LibraryLocation struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// Setting is a preference reported by Plex's /:/prefs endpoint
+type Setting struct {
+	ID       string       `json:"id"`
+	Label    string       `json:"label,omitempty"`
+	Summary  string       `json:"summary,omitempty"`
+	Type     string       `json:"type"`
+	Default  SettingValue `json:"default"`
+	Value    SettingValue `json:"value"`
+	Hidden   bool         `json:"hidden"`
+	Advanced bool         `json:"advanced"`
+	Group    string       `json:"group,omitempty"`
+}
+
+// SettingValue is the value of a preference, in the format it is stored in Preferences.xml.
+// Plex reports values as JSON strings, numbers, or booleans. Booleans are stored as "1" or "0".
+type SettingValue string
+
+// UnmarshalJSON decodes a JSON string, number, or boolean preference value
+func (v *SettingValue) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*v = ""
+	case bytes.Equal(data, []byte("true")):
+		*v = "1"
+	case bytes.Equal(data, []byte("false")):
+		*v = "0"
+	case len(data) > 0 && data[0] == '"':
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*v = SettingValue(value)
+	default:
+		var value json.Number
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*v = SettingValue(value.String())
+	}
+	return nil
+}
+
+// Bool returns true if the value is a true boolean preference
+func (v SettingValue) Bool() bool {
+	value, err := strconv.ParseBool(string(v))
+	return err == nil && value
+}
+
+// ButlerTask is a scheduled maintenance task reported by Plex's /butler endpoint
+type ButlerTask struct {
+	Name               string `json:"name"`
+	Title              string `json:"title,omitempty"`
+	Description        string `json:"description,omitempty"`
+	Interval           int    `json:"interval"`
+	ScheduleRandomized bool   `json:"scheduleRandomized"`
+	Enabled            bool   `json:"enabled"`
+}
+
+// Downloads is the response of plex.tv's downloads endpoint, which lists the newest Plex Media
+// Server release of each platform
+type Downloads struct {
+	Computer map[string]PlatformDownloads `json:"computer"`
+}
+
+// PlatformDownloads is the newest Plex Media Server release of a platform, such as Linux
+type PlatformDownloads struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Version     string    `json:"version"`
+	ReleaseDate int64     `json:"release_date,omitempty"`
+	Releases    []Release `json:"releases"`
+}
+
+// Release is a build of a Plex Media Server release for a distro and architecture
+type Release struct {
+	Label    string `json:"label"`
+	Build    string `json:"build"`
+	Distro   string `json:"distro"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum,omitempty"`
+}
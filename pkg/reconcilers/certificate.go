@@ -0,0 +1,173 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// CertificateGVK is the GroupVersionKind of cert-manager's Certificate
+var CertificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// CertificateReconciler reconciles the cert-manager Certificate for Plex Media Server's Ingress.
+// cert-manager is an optional dependency, so Certificates are managed as unstructured objects.
+type CertificateReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewCertificateReconciler returns a new Reconciler that reconciles the Certificate for Plex Media Server
+func NewCertificateReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *CertificateReconciler {
+	return &CertificateReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the Certificate with the desired state of the PlexMediaServer
+func (r *CertificateReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origCertificate := &unstructured.Unstructured{}
+	origCertificate.SetGroupVersionKind(CertificateGVK)
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
+	log := r.Log.WithValues("certificate", namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origCertificate)
+
+	if meta.IsNoMatchError(err) {
+		// cert-manager is not installed on the cluster, which is reported by the CertificateReady
+		// status condition
+		if certificateRequested(plex) {
+			log.Info("cert-manager Certificate API not found, skipping certificate creation")
+		}
+		return false, nil
+	}
+	if errors.IsNotFound(err) {
+		if !certificateRequested(plex) {
+			return false, nil
+		}
+		log.Info("creating")
+		origCertificate, err = r.createCertificate(plex)
+		if err != nil {
+			log.Error(err, "failed to render object")
+			return true, err
+		}
+		err = r.Client.Create(ctx, origCertificate, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "Certificate", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if !certificateRequested(plex) {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origCertificate, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "Certificate", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredCertificate := origCertificate.DeepCopy()
+	renderObjectMeta(plex, desiredCertificate)
+	err = r.renderCertificateSpec(plex, desiredCertificate)
+	if err != nil {
+		log.Error(err, "failed to render object")
+		return true, err
+	}
+	if !equality.Semantic.DeepEqual(origCertificate.Object["spec"], desiredCertificate.Object["spec"]) ||
+		objectMetaChanged(origCertificate, desiredCertificate) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredCertificate, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Certificate", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "Certificate", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+	return false, nil
+}
+
+// certificateRequested returns true if the PlexMediaServer references a cert-manager issuer
+func certificateRequested(plex *v1alpha1.PlexMediaServer) bool {
+	ingress := plex.Spec.Networking.Ingress
+	return ingress != nil && ingress.TLS != nil && ingress.TLS.IssuerRef != nil
+}
+
+func (r *CertificateReconciler) createCertificate(plex *v1alpha1.PlexMediaServer) (*unstructured.Unstructured, error) {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	certificate.SetNamespace(plex.Namespace)
+	certificate.SetName(plex.Name)
+	if err := r.renderCertificateSpec(plex, certificate); err != nil {
+		return nil, err
+	}
+	renderObjectMeta(plex, certificate)
+	if err := ctrl.SetControllerReference(plex, certificate, r.Scheme); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// renderCertificateSpec renders the fields of the Certificate spec managed by the operator on top
+// of the existing Certificate.
+func (r *CertificateReconciler) renderCertificateSpec(plex *v1alpha1.PlexMediaServer, certificate *unstructured.Unstructured) error {
+	ingress := plex.Spec.Networking.Ingress
+	kind := ingress.TLS.IssuerRef.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	if err := unstructured.SetNestedField(certificate.Object, ingressTLSSecretName(plex), "spec", "secretName"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedStringSlice(certificate.Object, []string{ingress.Host}, "spec", "dnsNames"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedStringMap(certificate.Object, map[string]string{
+		"name":  ingress.TLS.IssuerRef.Name,
+		"kind":  kind,
+		"group": CertificateGVK.Group,
+	}, "spec", "issuerRef")
+}
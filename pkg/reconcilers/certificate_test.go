@@ -0,0 +1,217 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/suite"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+type certificateTestCase struct {
+	name                string
+	plex                *v1alpha1.PlexMediaServer
+	existingCertificate *unstructured.Unstructured
+	expectedCertificate *unstructured.Unstructured
+	expectError         bool
+	expectRequeue       bool
+}
+
+type certificateReconcileSuite struct {
+	suite.Suite
+	cases []certificateTestCase
+}
+
+func (test *certificateReconcileSuite) SetupTest() {
+	test.cases = []certificateTestCase{
+		{
+			name: "no issuer",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								SecretName: "plex-cert",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "create with issuer",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedCertificate: certificateDouble("create", "create", "create-tls", "plex.example.com", "letsencrypt", "Issuer"),
+			expectRequeue:       true,
+		},
+		{
+			name: "update issuer",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								SecretName: "plex-cert",
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt-prod",
+									Kind: "ClusterIssuer",
+								},
+							},
+						},
+					},
+				},
+			},
+			existingCertificate: certificateDouble("update", "update", "plex-cert", "plex.example.com", "letsencrypt", "Issuer"),
+			expectedCertificate: certificateDouble("update", "update", "plex-cert", "plex.example.com", "letsencrypt-prod", "ClusterIssuer"),
+			expectRequeue:       true,
+		},
+		{
+			name: "delete when issuer removed",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "delete",
+					Name:      "delete",
+				},
+			},
+			existingCertificate: certificateDouble("delete", "delete", "delete-tls", "plex.example.com", "letsencrypt", "Issuer"),
+			expectRequeue:       true,
+		},
+		{
+			name: "no change",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "no-change",
+					Name:      "no-change",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			existingCertificate: certificateDouble("no-change", "no-change", "no-change-tls", "plex.example.com", "letsencrypt", "Issuer"),
+			expectedCertificate: certificateDouble("no-change", "no-change", "no-change-tls", "plex.example.com", "letsencrypt", "Issuer"),
+		},
+	}
+}
+
+func (test *certificateReconcileSuite) TestCertificateReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			if tc.existingCertificate != nil {
+				builder.WithObjects(tc.existingCertificate)
+			}
+			client := builder.Build()
+			reconciler := &CertificateReconciler{
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			updatedCertificate := &unstructured.Unstructured{}
+			updatedCertificate.SetGroupVersionKind(CertificateGVK)
+			err = client.Get(ctx, types.NamespacedName{Namespace: tc.plex.Namespace, Name: tc.plex.Name}, updatedCertificate)
+			if tc.expectedCertificate == nil {
+				test.True(errors.IsNotFound(err), "expected certificate to not exist")
+				return
+			}
+			test.Require().NoError(err, "failed to get Certificate")
+			test.True(equality.Semantic.DeepEqual(tc.expectedCertificate.Object["spec"], updatedCertificate.Object["spec"]),
+				"expected certificate does not match - diff: %s",
+				cmp.Diff(tc.expectedCertificate.Object["spec"], updatedCertificate.Object["spec"]))
+		})
+	}
+}
+
+func certificateDouble(namespace, plexName, secretName, host, issuer, issuerKind string) *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"secretName": secretName,
+				"dnsNames":   []interface{}{host},
+				"issuerRef": map[string]interface{}{
+					"name":  issuer,
+					"kind":  issuerKind,
+					"group": "cert-manager.io",
+				},
+			},
+		},
+	}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	certificate.SetNamespace(namespace)
+	certificate.SetName(plexName)
+	certificate.SetLabels(plexLabelsDouble(plexName))
+	return certificate
+}
+
+func TestCertificateSuite(t *testing.T) {
+	suite.Run(t, new(certificateReconcileSuite))
+}
@@ -0,0 +1,181 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// resolveCustomConnections returns the custom server access URLs Plex should advertise to clients.
+// User-provided URLs are listed first, followed by the URLs of Plex's external endpoints. Duplicate
+// URLs are skipped.
+func resolveCustomConnections(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) ([]string, error) {
+	connections := []string{}
+	seen := map[string]bool{}
+	addConnections := func(urls []string) {
+		for _, url := range urls {
+			if url == "" || seen[url] {
+				continue
+			}
+			seen[url] = true
+			connections = append(connections, url)
+		}
+	}
+	addConnections(plex.Spec.Networking.CustomConnections)
+	endpoints, err := externalEndpoints(ctx, c, plex)
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoint := range endpoints {
+		addConnections([]string{endpoint.URL})
+	}
+	return connections, nil
+}
+
+// plexEndpoints returns the URLs Plex can be reached at. The headless Service's cluster DNS name is
+// listed first, followed by Plex's external endpoints.
+func plexEndpoints(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) ([]v1alpha1.PlexEndpoint, error) {
+	endpoints := []v1alpha1.PlexEndpoint{
+		{
+			Type: "Internal",
+			URL:  hostURL("http", fmt.Sprintf("%s.%s.svc", plex.Name, plex.Namespace), findPlexPort(plex, "plex").port),
+		},
+	}
+	external, err := externalEndpoints(ctx, c, plex)
+	if err != nil {
+		return nil, err
+	}
+	return append(endpoints, external...), nil
+}
+
+// externalEndpoints returns the URLs Plex can be reached at from outside of the cluster: the
+// Ingress and Route hosts, followed by the external Service's endpoints
+func externalEndpoints(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) ([]v1alpha1.PlexEndpoint, error) {
+	endpoints := advertiseEndpoints(plex)
+	serviceEndpoints, err := externalServiceEndpoints(ctx, c, plex)
+	if err != nil {
+		return nil, err
+	}
+	return append(endpoints, serviceEndpoints...), nil
+}
+
+// advertiseEndpoints returns the URLs for the hosts of Plex's Ingress and Route
+func advertiseEndpoints(plex *v1alpha1.PlexMediaServer) []v1alpha1.PlexEndpoint {
+	endpoints := []v1alpha1.PlexEndpoint{}
+	if ingress := plex.Spec.Networking.Ingress; ingress != nil && ingress.Host != "" {
+		if ingressTLSSecretName(plex) != "" {
+			endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "Ingress", URL: hostURL("https", ingress.Host, 443)})
+		} else {
+			endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "Ingress", URL: hostURL("http", ingress.Host, 80)})
+		}
+	}
+	if route := plex.Spec.Networking.Route; route != nil && route.Host != "" {
+		if route.TLSTermination != "" {
+			endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "Route", URL: hostURL("https", route.Host, 443)})
+		} else {
+			endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "Route", URL: hostURL("http", route.Host, 80)})
+		}
+	}
+	return endpoints
+}
+
+// externalServiceEndpoints returns the URLs for Plex's port on the external Service. The Service's
+// DNS host name is listed first, using HTTPS if it is the domain of Plex's custom certificate. Load
+// balancer Services are reached through their assigned IP addresses and host names. NodePort
+// Services are reached through the external addresses of each Node, or the internal addresses of
+// Nodes without an external address.
+func externalServiceEndpoints(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) ([]v1alpha1.PlexEndpoint, error) {
+	serviceType := plex.Spec.Networking.ExternalServiceType
+	if serviceType == "" {
+		return nil, nil
+	}
+	service := &corev1.Service{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: fmt.Sprintf("%s-ext", plex.Name)}, service)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var plexServicePort *corev1.ServicePort
+	for i := range service.Spec.Ports {
+		if service.Spec.Ports[i].Name == "plex" {
+			plexServicePort = &service.Spec.Ports[i]
+			break
+		}
+	}
+	if plexServicePort == nil {
+		return nil, nil
+	}
+	endpoints := []v1alpha1.PlexEndpoint{}
+	servicePort := plexServicePort.Port
+	if serviceType == corev1.ServiceTypeNodePort {
+		servicePort = plexServicePort.NodePort
+	}
+	if dns := plex.Spec.Networking.DNS; dns != nil && servicePort > 0 {
+		scheme := "http"
+		if tls := plex.Spec.TLS; tls != nil && tls.Domain == dns.Hostname {
+			scheme = "https"
+		}
+		endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "DNS", URL: hostURL(scheme, dns.Hostname, servicePort)})
+	}
+	switch serviceType {
+	case corev1.ServiceTypeLoadBalancer:
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "LoadBalancer", URL: hostURL("http", ingress.IP, plexServicePort.Port)})
+			}
+			if ingress.Hostname != "" {
+				endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "LoadBalancer", URL: hostURL("http", ingress.Hostname, plexServicePort.Port)})
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		if plexServicePort.NodePort == 0 {
+			return endpoints, nil
+		}
+		nodes := &corev1.NodeList{}
+		if err := c.List(ctx, nodes); err != nil {
+			return nil, err
+		}
+		sort.Slice(nodes.Items, func(i, j int) bool {
+			return nodes.Items[i].Name < nodes.Items[j].Name
+		})
+		for _, node := range nodes.Items {
+			for _, address := range nodeAddresses(&node) {
+				endpoints = append(endpoints, v1alpha1.PlexEndpoint{Type: "NodePort", URL: hostURL("http", address, plexServicePort.NodePort)})
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+// nodeAddresses returns the external IP addresses of the Node, or its internal IP addresses if the
+// Node has no external address
+func nodeAddresses(node *corev1.Node) []string {
+	external := []string{}
+	internal := []string{}
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case corev1.NodeExternalIP:
+			external = append(external, address.Address)
+		case corev1.NodeInternalIP:
+			internal = append(internal, address.Address)
+		}
+	}
+	if len(external) > 0 {
+		return external
+	}
+	return internal
+}
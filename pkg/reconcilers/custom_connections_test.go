@@ -0,0 +1,186 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestResolveCustomConnections(t *testing.T) {
+	loadBalancer := externalServiceIPDouble("connections", "plex", "203.0.113.10")
+	loadBalancer.Status.LoadBalancer.Ingress = append(loadBalancer.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{
+		Hostname: "lb.example.com",
+	})
+	nodePort := serviceDouble("connections", "plex", serviceDoubleOptions{
+		ServiceName: "plex-ext",
+		ServiceType: corev1.ServiceTypeNodePort,
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "plex",
+				Port:       32400,
+				TargetPort: intstr.FromInt(32400),
+				NodePort:   30400,
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+	})
+	nodes := []client.Object{
+		nodeAddressDouble("node-b", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}),
+		nodeAddressDouble("node-a",
+			corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+			corev1.NodeAddress{Type: corev1.NodeHostName, Address: "node-a"}),
+	}
+	cases := []struct {
+		name       string
+		networking v1alpha1.PlexNetworkSpec
+		service    *corev1.Service
+		expected   []string
+	}{
+		{
+			name:     "not exposed",
+			expected: []string{},
+		},
+		{
+			name: "user URLs and ingress host",
+			networking: v1alpha1.PlexNetworkSpec{
+				CustomConnections: []string{"https://plex.example.com:443", "http://192.168.1.10:32400"},
+				Ingress: &v1alpha1.PlexIngressSpec{
+					Host: "plex.example.com",
+					TLS: &v1alpha1.PlexIngressTLS{
+						SecretName: "plex-tls",
+					},
+				},
+			},
+			expected: []string{"https://plex.example.com:443", "http://192.168.1.10:32400"},
+		},
+		{
+			name: "load balancer",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+			},
+			service:  loadBalancer,
+			expected: []string{"http://203.0.113.10:32400", "http://lb.example.com:32400"},
+		},
+		{
+			name: "load balancer with DNS host name",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+				},
+			},
+			service:  loadBalancer,
+			expected: []string{"http://plex.example.com:32400", "http://203.0.113.10:32400", "http://lb.example.com:32400"},
+		},
+		{
+			name: "load balancer not assigned",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+			},
+			expected: []string{},
+		},
+		{
+			name: "node port",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeNodePort,
+			},
+			service:  nodePort,
+			expected: []string{"http://[2001:db8::1]:30400", "http://10.0.0.2:30400"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "connections",
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: tc.networking,
+				},
+			}
+			builder := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodes...)
+			if tc.service != nil {
+				builder.WithObjects(tc.service)
+			}
+			connections, err := resolveCustomConnections(context.TODO(), builder.Build(), plex)
+			require.NoError(t, err, "unexpected error resolving custom connections")
+			assert.Equal(t, tc.expected, connections, "custom connections should be equal")
+		})
+	}
+}
+
+func nodeAddressDouble(name string, addresses ...corev1.NodeAddress) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Status: corev1.NodeStatus{
+			Addresses: addresses,
+		},
+	}
+}
+
+func TestPlexEndpoints(t *testing.T) {
+	nodePort := serviceDouble("endpoints", "plex", serviceDoubleOptions{
+		ServiceName: "plex-ext",
+		ServiceType: corev1.ServiceTypeNodePort,
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "plex",
+				Port:       32400,
+				TargetPort: intstr.FromInt(32400),
+				NodePort:   30400,
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+	})
+	node := nodeAddressDouble("node", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"})
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "endpoints",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeNodePort,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+				},
+				Route: &v1alpha1.PlexRouteSpec{
+					Host:           "plex.apps.example.com",
+					TLSTermination: "edge",
+				},
+			},
+			TLS: &v1alpha1.PlexTLSSpec{
+				Domain: "plex.example.com",
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodePort, node).Build()
+	endpoints, err := plexEndpoints(context.TODO(), c, plex)
+	require.NoError(t, err, "unexpected error resolving endpoints")
+	assert.Equal(t, []v1alpha1.PlexEndpoint{
+		{Type: "Internal", URL: "http://plex.endpoints.svc:32400"},
+		{Type: "Route", URL: "https://plex.apps.example.com:443"},
+		{Type: "DNS", URL: "https://plex.example.com:30400"},
+		{Type: "NodePort", URL: "http://10.0.0.1:30400"},
+	}, endpoints, "endpoints should be equal")
+}
@@ -0,0 +1,251 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// externalDNSHostnameAnnotation tells ExternalDNS to publish a record for a Service
+	externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+	// externalDNSTTLAnnotation sets the TTL of the record published by ExternalDNS
+	externalDNSTTLAnnotation = "external-dns.alpha.kubernetes.io/ttl"
+)
+
+// DNSEndpointGVK is the GroupVersionKind of ExternalDNS's DNSEndpoint
+var DNSEndpointGVK = schema.GroupVersionKind{
+	Group:   "externaldns.k8s.io",
+	Version: "v1alpha1",
+	Kind:    "DNSEndpoint",
+}
+
+// lookupHost resolves a host name to its addresses. Tests replace it to avoid network lookups.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// dnsMode returns how Plex's DNS record is published, or empty if no record is published. A
+// record is only published for the external Service.
+func dnsMode(plex *v1alpha1.PlexMediaServer) string {
+	dns := plex.Spec.Networking.DNS
+	if dns == nil || plex.Spec.Networking.ExternalServiceType == "" {
+		return ""
+	}
+	if dns.Mode == "" {
+		return "Annotation"
+	}
+	return dns.Mode
+}
+
+// renderDNSAnnotations renders ExternalDNS's annotations on top of the external Service's
+// existing annotations. The annotations are removed if the record is not published through them.
+func renderDNSAnnotations(plex *v1alpha1.PlexMediaServer, existing map[string]string) map[string]string {
+	if dnsMode(plex) != "Annotation" {
+		delete(existing, externalDNSHostnameAnnotation)
+		delete(existing, externalDNSTTLAnnotation)
+		if len(existing) == 0 {
+			return nil
+		}
+		return existing
+	}
+	if existing == nil {
+		existing = map[string]string{}
+	}
+	dns := plex.Spec.Networking.DNS
+	existing[externalDNSHostnameAnnotation] = dns.Hostname
+	if dns.TTL != nil {
+		existing[externalDNSTTLAnnotation] = strconv.FormatInt(*dns.TTL, 10)
+	} else {
+		delete(existing, externalDNSTTLAnnotation)
+	}
+	return existing
+}
+
+// DNSEndpointReconciler reconciles the ExternalDNS DNSEndpoint for Plex Media Server's external
+// Service. ExternalDNS is an optional dependency, so DNSEndpoints are managed as unstructured
+// objects.
+type DNSEndpointReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewDNSEndpointReconciler returns a new Reconciler that reconciles the DNSEndpoint for Plex Media Server
+func NewDNSEndpointReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *DNSEndpointReconciler {
+	return &DNSEndpointReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the DNSEndpoint with the desired state of the PlexMediaServer
+func (r *DNSEndpointReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origEndpoint := &unstructured.Unstructured{}
+	origEndpoint.SetGroupVersionKind(DNSEndpointGVK)
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
+	log := r.Log.WithValues("dnsEndpoint", namespacedName)
+	requested := dnsMode(plex) == "DNSEndpoint"
+	err := r.Client.Get(ctx, namespacedName, origEndpoint)
+
+	if meta.IsNoMatchError(err) {
+		// ExternalDNS's CRD is not installed on the cluster
+		if requested {
+			log.Info("ExternalDNS DNSEndpoint API not found, skipping DNS endpoint creation")
+		}
+		return false, nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return true, err
+	}
+	exists := err == nil
+
+	if !requested {
+		if !exists {
+			return false, nil
+		}
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origEndpoint, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "DNSEndpoint", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	service := &corev1.Service{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: fmt.Sprintf("%s-ext", plex.Name)}, service)
+	if errors.IsNotFound(err) {
+		// The external Service is owned by Plex, so Plex is reconciled again once it is created
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if !exists {
+		log.Info("creating")
+		origEndpoint, err = r.createDNSEndpoint(plex, service)
+		if err != nil {
+			log.Error(err, "failed to render object")
+			return true, err
+		}
+		err = r.Client.Create(ctx, origEndpoint, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "DNSEndpoint", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+
+	desiredEndpoint := origEndpoint.DeepCopy()
+	renderObjectMeta(plex, desiredEndpoint)
+	err = r.renderDNSEndpointSpec(plex, service, desiredEndpoint)
+	if err != nil {
+		log.Error(err, "failed to render object")
+		return true, err
+	}
+	if !equality.Semantic.DeepEqual(origEndpoint.Object["spec"], desiredEndpoint.Object["spec"]) ||
+		objectMetaChanged(origEndpoint, desiredEndpoint) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredEndpoint, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "DNSEndpoint", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "DNSEndpoint", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *DNSEndpointReconciler) createDNSEndpoint(plex *v1alpha1.PlexMediaServer, service *corev1.Service) (*unstructured.Unstructured, error) {
+	endpoint := &unstructured.Unstructured{}
+	endpoint.SetGroupVersionKind(DNSEndpointGVK)
+	endpoint.SetNamespace(plex.Namespace)
+	endpoint.SetName(plex.Name)
+	if err := r.renderDNSEndpointSpec(plex, service, endpoint); err != nil {
+		return nil, err
+	}
+	renderObjectMeta(plex, endpoint)
+	if err := ctrl.SetControllerReference(plex, endpoint, r.Scheme); err != nil {
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// renderDNSEndpointSpec renders the DNS records for the external Service's load balancer on top
+// of the existing DNSEndpoint. IPv4 and IPv6 addresses are published as A and AAAA records. If
+// the load balancer only has host names, a CNAME record is published for the first host name.
+func (r *DNSEndpointReconciler) renderDNSEndpointSpec(plex *v1alpha1.PlexMediaServer, service *corev1.Service, endpoint *unstructured.Unstructured) error {
+	dns := plex.Spec.Networking.DNS
+	targets := map[string][]interface{}{}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ip := net.ParseIP(ingress.IP); ip != nil {
+			if ip.To4() != nil {
+				targets["A"] = append(targets["A"], ingress.IP)
+			} else {
+				targets["AAAA"] = append(targets["AAAA"], ingress.IP)
+			}
+			continue
+		}
+		if ingress.Hostname != "" && len(targets["CNAME"]) == 0 {
+			targets["CNAME"] = []interface{}{ingress.Hostname}
+		}
+	}
+	if len(targets["A"]) > 0 || len(targets["AAAA"]) > 0 {
+		delete(targets, "CNAME")
+	}
+	endpoints := []interface{}{}
+	for _, recordType := range []string{"A", "AAAA", "CNAME"} {
+		if len(targets[recordType]) == 0 {
+			continue
+		}
+		record := map[string]interface{}{
+			"dnsName":    dns.Hostname,
+			"recordType": recordType,
+			"targets":    targets[recordType],
+		}
+		if dns.TTL != nil {
+			record["recordTTL"] = *dns.TTL
+		}
+		endpoints = append(endpoints, record)
+	}
+	return unstructured.SetNestedSlice(endpoint.Object, endpoints, "spec", "endpoints")
+}
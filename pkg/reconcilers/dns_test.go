@@ -0,0 +1,207 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestRenderDNSAnnotations(t *testing.T) {
+	ttl := int64(60)
+	cases := []struct {
+		name       string
+		networking v1alpha1.PlexNetworkSpec
+		existing   map[string]string
+		expected   map[string]string
+	}{
+		{
+			name: "no DNS",
+		},
+		{
+			name: "no external service",
+			networking: v1alpha1.PlexNetworkSpec{
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+				},
+			},
+		},
+		{
+			name: "hostname and TTL",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+					TTL:      &ttl,
+				},
+			},
+			existing: map[string]string{
+				"example.com/keep": "true",
+			},
+			expected: map[string]string{
+				"example.com/keep":                          "true",
+				"external-dns.alpha.kubernetes.io/hostname": "plex.example.com",
+				"external-dns.alpha.kubernetes.io/ttl":      "60",
+			},
+		},
+		{
+			name: "TTL removed",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeNodePort,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+					Mode:     "Annotation",
+				},
+			},
+			existing: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "old.example.com",
+				"external-dns.alpha.kubernetes.io/ttl":      "60",
+			},
+			expected: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "plex.example.com",
+			},
+		},
+		{
+			name: "DNSEndpoint mode",
+			networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+					Mode:     "DNSEndpoint",
+				},
+			},
+			existing: map[string]string{
+				"external-dns.alpha.kubernetes.io/hostname": "plex.example.com",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: tc.networking,
+				},
+			}
+			assert.Equal(t, tc.expected, renderDNSAnnotations(plex, tc.existing), "annotations should be equal")
+		})
+	}
+}
+
+func TestDNSEndpointReconcile(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	err := v1alpha1.AddToScheme(s)
+	require.NoError(t, err, "failed to add scheme")
+	ttl := int64(120)
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "dns",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+				DNS: &v1alpha1.PlexDNSSpec{
+					Hostname: "plex.example.com",
+					Mode:     "DNSEndpoint",
+					TTL:      &ttl,
+				},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(plex).Build()
+	reconciler := NewDNSEndpointReconciler(c, logr.Discard(), s, record.NewFakeRecorder(100))
+	namespacedName := types.NamespacedName{Namespace: "dns", Name: "plex"}
+	getEndpoint := func() (*unstructured.Unstructured, error) {
+		endpoint := &unstructured.Unstructured{}
+		endpoint.SetGroupVersionKind(DNSEndpointGVK)
+		err := c.Get(ctx, namespacedName, endpoint)
+		return endpoint, err
+	}
+
+	// Wait for the external Service to be created
+	requeue, err := reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.False(t, requeue, "should not requeue while the external service does not exist")
+	_, err = getEndpoint()
+	assert.True(t, errors.IsNotFound(err), "expected DNSEndpoint to not exist")
+
+	// Publish A and AAAA records for the load balancer's IP addresses
+	service := externalServiceIPDouble("dns", "plex", "203.0.113.10")
+	service.Status.LoadBalancer.Ingress = append(service.Status.LoadBalancer.Ingress,
+		corev1.LoadBalancerIngress{IP: "2001:db8::10"},
+		corev1.LoadBalancerIngress{Hostname: "lb.example.com"})
+	err = c.Create(ctx, service)
+	require.NoError(t, err, "failed to create external service")
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after creating the DNSEndpoint")
+	endpoint, err := getEndpoint()
+	require.NoError(t, err, "failed to get DNSEndpoint")
+	endpoints, _, err := unstructured.NestedSlice(endpoint.Object, "spec", "endpoints")
+	require.NoError(t, err, "failed to get DNS endpoints")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"dnsName":    "plex.example.com",
+			"recordType": "A",
+			"targets":    []interface{}{"203.0.113.10"},
+			"recordTTL":  int64(120),
+		},
+		map[string]interface{}{
+			"dnsName":    "plex.example.com",
+			"recordType": "AAAA",
+			"targets":    []interface{}{"2001:db8::10"},
+			"recordTTL":  int64(120),
+		},
+	}, endpoints, "DNS endpoints should be equal")
+
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.False(t, requeue, "should not requeue if the DNSEndpoint did not change")
+
+	// Publish a CNAME record if the load balancer only has a host name
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	err = c.Update(ctx, service)
+	require.NoError(t, err, "failed to update external service")
+	plex.Spec.Networking.DNS.TTL = nil
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after updating the DNSEndpoint")
+	endpoint, err = getEndpoint()
+	require.NoError(t, err, "failed to get DNSEndpoint")
+	endpoints, _, err = unstructured.NestedSlice(endpoint.Object, "spec", "endpoints")
+	require.NoError(t, err, "failed to get DNS endpoints")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"dnsName":    "plex.example.com",
+			"recordType": "CNAME",
+			"targets":    []interface{}{"lb.example.com"},
+		},
+	}, endpoints, "DNS endpoints should be equal")
+
+	// Switching to annotations deletes the DNSEndpoint
+	plex.Spec.Networking.DNS.Mode = "Annotation"
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after deleting the DNSEndpoint")
+	_, err = getEndpoint()
+	assert.True(t, errors.IsNotFound(err), "expected DNSEndpoint to be deleted")
+}
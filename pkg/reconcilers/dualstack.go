@@ -0,0 +1,100 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// The ipFamilyPolicy and ipFamilies Service fields are newer than the Kubernetes API types used by
+// the operator, so they are rendered on Services as unstructured content.
+
+// hasIPFamilies returns true if IP family options are set on the PlexMediaServer
+func hasIPFamilies(plex *v1alpha1.PlexMediaServer) bool {
+	networking := plex.Spec.Networking
+	return networking.IPFamilyPolicy != "" || len(networking.IPFamilies) > 0
+}
+
+// renderServiceIPFamilies renders the IP family fields set on the PlexMediaServer on top of the
+// Service content. Fields that are not set are left to the cluster's defaults.
+func renderServiceIPFamilies(plex *v1alpha1.PlexMediaServer, service *unstructured.Unstructured) error {
+	networking := plex.Spec.Networking
+	if networking.IPFamilyPolicy != "" {
+		if err := unstructured.SetNestedField(service.Object, networking.IPFamilyPolicy, "spec", "ipFamilyPolicy"); err != nil {
+			return err
+		}
+	}
+	if len(networking.IPFamilies) > 0 {
+		families := []string{}
+		for _, family := range networking.IPFamilies {
+			families = append(families, string(family))
+		}
+		if err := unstructured.SetNestedStringSlice(service.Object, families, "spec", "ipFamilies"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createServiceWithIPFamilies creates the Service with the IP family fields set on the
+// PlexMediaServer. The primary IP family of a Service can only be set when it is created.
+func createServiceWithIPFamilies(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer, service *corev1.Service) error {
+	if !hasIPFamilies(plex) {
+		return c.Create(ctx, service, &client.CreateOptions{})
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(service)
+	if err != nil {
+		return err
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+	if err := renderServiceIPFamilies(plex, obj); err != nil {
+		return err
+	}
+	return c.Create(ctx, obj, &client.CreateOptions{})
+}
+
+// reconcileServiceIPFamilies patches the IP family fields of an existing Service. Returns true if
+// the Service was updated.
+func reconcileServiceIPFamilies(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer, namespacedName types.NamespacedName) (bool, error) {
+	if !hasIPFamilies(plex) {
+		return false, nil
+	}
+	origService := &unstructured.Unstructured{}
+	origService.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+	if err := c.Get(ctx, namespacedName, origService); err != nil {
+		return true, err
+	}
+	desiredService := origService.DeepCopy()
+	if err := renderServiceIPFamilies(plex, desiredService); err != nil {
+		return true, err
+	}
+	if equality.Semantic.DeepEqual(origService.Object["spec"], desiredService.Object["spec"]) {
+		return false, nil
+	}
+	if err := c.Patch(ctx, desiredService, client.MergeFrom(origService)); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// hostURL returns the URL for the scheme, host, and port. IPv6 addresses are enclosed in brackets.
+func hostURL(scheme string, host string, port int32) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
@@ -0,0 +1,164 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestServiceIPFamilies(t *testing.T) {
+	cases := []struct {
+		name             string
+		plex             *v1alpha1.PlexMediaServer
+		expectedPolicy   string
+		expectedFamilies []string
+	}{
+		{
+			name: "cluster defaults",
+			plex: dualStackPlexDouble("", nil),
+		},
+		{
+			name:             "create dual-stack service",
+			plex:             dualStackPlexDouble("RequireDualStack", []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}),
+			expectedPolicy:   "RequireDualStack",
+			expectedFamilies: []string{"IPv6", "IPv4"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s := scheme.Scheme
+			err := v1alpha1.AddToScheme(s)
+			require.NoError(t, err, "failed to add scheme")
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(tc.plex).Build()
+			reconciler := NewServiceReconciler(c, logr.Discard(), s, record.NewFakeRecorder(100))
+			// Reconcile until the Service settles
+			for i := 0; i < 3; i++ {
+				_, err = reconciler.Reconcile(ctx, tc.plex)
+				require.NoError(t, err, "unexpected error from reconcile")
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			require.NoError(t, err, "unexpected error from reconcile")
+			assert.False(t, requeue, "service should not change once reconciled")
+
+			service := &unstructured.Unstructured{}
+			service.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service"))
+			err = c.Get(ctx, types.NamespacedName{Namespace: tc.plex.Namespace, Name: tc.plex.Name}, service)
+			require.NoError(t, err, "failed to get Service")
+			policy, _, err := unstructured.NestedString(service.Object, "spec", "ipFamilyPolicy")
+			require.NoError(t, err, "failed to read ipFamilyPolicy")
+			assert.Equal(t, tc.expectedPolicy, policy, "ipFamilyPolicy should be equal")
+			families, _, err := unstructured.NestedStringSlice(service.Object, "spec", "ipFamilies")
+			require.NoError(t, err, "failed to read ipFamilies")
+			assert.Equal(t, tc.expectedFamilies, families, "ipFamilies should be equal")
+		})
+	}
+}
+
+// patchRecordingClient records the merge patches sent to the API server. The fake client stores
+// Services with the operator's API types, which drops the IP family fields from patches.
+type patchRecordingClient struct {
+	client.Client
+	patches []string
+}
+
+func (p *patchRecordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	p.patches = append(p.patches, string(data))
+	return p.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestPatchServiceIPFamilies(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	err := v1alpha1.AddToScheme(s)
+	require.NoError(t, err, "failed to add scheme")
+	plex := dualStackPlexDouble("PreferDualStack", []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol})
+	service := serviceDouble("dual-stack", "plex", serviceDoubleOptions{
+		ServiceType: corev1.ServiceTypeClusterIP,
+	})
+	c := &patchRecordingClient{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(plex, service).Build(),
+	}
+	updated, err := reconcileServiceIPFamilies(ctx, c, plex, types.NamespacedName{Namespace: "dual-stack", Name: "plex"})
+	require.NoError(t, err, "unexpected error reconciling IP families")
+	assert.True(t, updated, "service should be updated")
+	assert.Equal(t, []string{`{"spec":{"ipFamilies":["IPv4","IPv6"],"ipFamilyPolicy":"PreferDualStack"}}`}, c.patches,
+		"patches should be equal")
+}
+
+func TestHostURL(t *testing.T) {
+	cases := []struct {
+		scheme   string
+		host     string
+		port     int32
+		expected string
+	}{
+		{
+			scheme:   "https",
+			host:     "plex.example.com",
+			port:     443,
+			expected: "https://plex.example.com:443",
+		},
+		{
+			scheme:   "http",
+			host:     "192.168.1.10",
+			port:     32400,
+			expected: "http://192.168.1.10:32400",
+		},
+		{
+			scheme:   "http",
+			host:     "2001:db8::10",
+			port:     32400,
+			expected: "http://[2001:db8::10]:32400",
+		},
+		{
+			scheme:   "https",
+			host:     "[2001:db8::10]",
+			port:     443,
+			expected: "https://[2001:db8::10]:443",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hostURL(tc.scheme, tc.host, tc.port), "URLs should be equal")
+		})
+	}
+}
+
+func dualStackPlexDouble(policy string, families []corev1.IPFamily) *v1alpha1.PlexMediaServer {
+	return &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "dual-stack",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				IPFamilyPolicy: policy,
+				IPFamilies:     families,
+			},
+		},
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// Reasons for the events recorded on the PlexMediaServer
+const (
+	eventReasonCreated      = "Created"
+	eventReasonUpdated      = "Updated"
+	eventReasonDeleted      = "Deleted"
+	eventReasonRecreating   = "Recreating"
+	eventReasonConflict     = "Conflict"
+	eventReasonCreateFailed = "CreateFailed"
+	eventReasonUpdateFailed = "UpdateFailed"
+	eventReasonDeleteFailed = "DeleteFailed"
+)
+
+// recordCreated records an event on the PlexMediaServer for an object the operator created, or
+// failed to create
+func recordCreated(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, kind string, name string, err error) {
+	if err != nil {
+		recorder.Eventf(plex, corev1.EventTypeWarning, eventReasonCreateFailed, "Failed to create %s %s: %v", kind, name, err)
+		return
+	}
+	recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonCreated, "Created %s %s", kind, name)
+}
+
+// recordUpdated records an event on the PlexMediaServer for an object the operator updated, or
+// failed to update. Conflicts are retried, and are not reported as failures.
+func recordUpdated(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, kind string, name string, err error) {
+	if err != nil {
+		recorder.Eventf(plex, corev1.EventTypeWarning, eventReasonUpdateFailed, "Failed to update %s %s: %v", kind, name, err)
+		return
+	}
+	recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonUpdated, "Updated %s %s", kind, name)
+}
+
+// recordDeleted records an event on the PlexMediaServer for an object the operator deleted, or
+// failed to delete
+func recordDeleted(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, kind string, name string, err error) {
+	if err != nil {
+		recorder.Eventf(plex, corev1.EventTypeWarning, eventReasonDeleteFailed, "Failed to delete %s %s: %v", kind, name, err)
+		return
+	}
+	recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonDeleted, "Deleted %s %s", kind, name)
+}
+
+// recordConflict records an event on the PlexMediaServer when an object changed while the
+// operator was writing it
+func recordConflict(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, kind string, name string) {
+	recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonConflict, "%s %s was modified concurrently, retrying", kind, name)
+}
+
+// recordConditionTransitions records an event on the PlexMediaServer for each status condition
+// that was added or changed status. Conditions that are added in their expected state are not
+// recorded, so that a new PlexMediaServer does not record an event per condition.
+// The Reconciling and Stalled conditions mirror Progressing and Degraded, and are not recorded.
+func recordConditionTransitions(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, previous []metav1.Condition) {
+	for _, condition := range plex.Status.Conditions {
+		if condition.Type == "Reconciling" || condition.Type == "Stalled" {
+			continue
+		}
+		eventType := corev1.EventTypeNormal
+		if conditionIsAbnormal(condition) {
+			eventType = corev1.EventTypeWarning
+		}
+		previousCondition := meta.FindStatusCondition(previous, condition.Type)
+		if previousCondition == nil && eventType == corev1.EventTypeNormal {
+			continue
+		}
+		if previousCondition != nil && previousCondition.Status == condition.Status {
+			continue
+		}
+		recorder.Eventf(plex, eventType, condition.Reason, "%s is %s: %s", condition.Type, condition.Status, condition.Message)
+	}
+}
+
+// conditionIsAbnormal returns true if the condition reports a problem with the Plex media server.
+// Degraded is abnormal when it is true; Progressing and UpToDate are never abnormal; all other
+// conditions are abnormal when they are false.
+func conditionIsAbnormal(condition metav1.Condition) bool {
+	switch condition.Type {
+	case "Degraded":
+		return condition.Status == metav1.ConditionTrue
+	case "Progressing", "UpToDate":
+		return false
+	}
+	return condition.Status == metav1.ConditionFalse
+}
+
+// summarizeClaimTemplateChanges describes how the desired volume claim templates differ from the
+// StatefulSet's current ones, by template name and changed field
+func summarizeClaimTemplateChanges(current []corev1.PersistentVolumeClaim, desired []corev1.PersistentVolumeClaim) string {
+	currentClaims := map[string]corev1.PersistentVolumeClaim{}
+	for _, claim := range current {
+		currentClaims[claim.Name] = claim
+	}
+	desiredClaims := map[string]corev1.PersistentVolumeClaim{}
+	for _, claim := range desired {
+		desiredClaims[claim.Name] = claim
+	}
+	changes := []string{}
+	for name, desiredClaim := range desiredClaims {
+		currentClaim, exists := currentClaims[name]
+		if !exists {
+			changes = append(changes, fmt.Sprintf("added %s", name))
+			continue
+		}
+		changes = append(changes, summarizeClaimChanges(name, currentClaim.Spec, desiredClaim.Spec)...)
+	}
+	for name := range currentClaims {
+		if _, exists := desiredClaims[name]; !exists {
+			changes = append(changes, fmt.Sprintf("removed %s", name))
+		}
+	}
+	sort.Strings(changes)
+	return strings.Join(changes, "; ")
+}
+
+// summarizeClaimChanges describes the fields that differ between two volume claim specs
+func summarizeClaimChanges(name string, current corev1.PersistentVolumeClaimSpec, desired corev1.PersistentVolumeClaimSpec) []string {
+	if equality.Semantic.DeepEqual(current, desired) {
+		return nil
+	}
+	changes := []string{}
+	currentStorage := current.Resources.Requests[corev1.ResourceStorage]
+	desiredStorage := desired.Resources.Requests[corev1.ResourceStorage]
+	if currentStorage.Cmp(desiredStorage) != 0 {
+		changes = append(changes, fmt.Sprintf("%s storage %s -> %s", name, currentStorage.String(), desiredStorage.String()))
+	}
+	if !equality.Semantic.DeepEqual(current.StorageClassName, desired.StorageClassName) {
+		changes = append(changes, fmt.Sprintf("%s storage class %s -> %s", name,
+			storageClassName(current.StorageClassName), storageClassName(desired.StorageClassName)))
+	}
+	if !equality.Semantic.DeepEqual(current.AccessModes, desired.AccessModes) {
+		changes = append(changes, fmt.Sprintf("%s access modes %v -> %v", name, current.AccessModes, desired.AccessModes))
+	}
+	if len(changes) == 0 {
+		changes = append(changes, fmt.Sprintf("changed %s", name))
+	}
+	return changes
+}
+
+func storageClassName(name *string) string {
+	if name == nil {
+		return "<default>"
+	}
+	return *name
+}
@@ -0,0 +1,110 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// recordedEvents drains the events recorded by the fake recorder
+func recordedEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestSummarizeClaimTemplateChanges(t *testing.T) {
+	storageClass := "fast"
+	claim := func(name string, storage string, storageClass *string) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: storageClass,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(storage),
+					},
+				},
+			},
+		}
+	}
+	cases := []struct {
+		name     string
+		current  []corev1.PersistentVolumeClaim
+		desired  []corev1.PersistentVolumeClaim
+		expected string
+	}{
+		{
+			name:     "added",
+			current:  []corev1.PersistentVolumeClaim{claim("config", "10Gi", nil)},
+			desired:  []corev1.PersistentVolumeClaim{claim("config", "10Gi", nil), claim("data", "100Gi", nil)},
+			expected: "added data",
+		},
+		{
+			name:     "removed",
+			current:  []corev1.PersistentVolumeClaim{claim("config", "10Gi", nil), claim("transcode", "20Gi", nil)},
+			desired:  []corev1.PersistentVolumeClaim{claim("config", "10Gi", nil)},
+			expected: "removed transcode",
+		},
+		{
+			name:     "resized and moved",
+			current:  []corev1.PersistentVolumeClaim{claim("config", "10Gi", nil)},
+			desired:  []corev1.PersistentVolumeClaim{claim("config", "20Gi", &storageClass)},
+			expected: "config storage 10Gi -> 20Gi; config storage class <default> -> fast",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, summarizeClaimTemplateChanges(tc.current, tc.desired), "summary should be equal")
+		})
+	}
+}
+
+func TestRecordConditionTransitions(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Status: v1alpha1.PlexMediaServerStatus{
+			Conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionFalse, Reason: "CrashLoopBackOff", Message: "Plex media server pod plex-0 is failing"},
+				{Type: "Degraded", Status: metav1.ConditionTrue, Reason: "CrashLoopBackOff", Message: "Plex media server pod plex-0 is failing"},
+				{Type: "Stalled", Status: metav1.ConditionTrue, Reason: "CrashLoopBackOff", Message: "Plex media server pod plex-0 is failing"},
+				{Type: "Progressing", Status: metav1.ConditionFalse, Reason: "Degraded", Message: "Plex media server is degraded"},
+				{Type: "ServiceAvailable", Status: metav1.ConditionTrue, Reason: "AsExpected", Message: "Plex media server service exists"},
+			},
+		},
+	}
+	previous := []metav1.Condition{
+		{Type: "Ready", Status: metav1.ConditionTrue, Reason: "AsExpected", Message: "Plex media server has at least 1 ready replica"},
+		{Type: "Progressing", Status: metav1.ConditionFalse, Reason: "AsExpected", Message: "Plex media server is up to date"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	recordConditionTransitions(recorder, plex, previous)
+	assert.Equal(t, []string{
+		"Warning CrashLoopBackOff Ready is False: Plex media server pod plex-0 is failing",
+		"Warning CrashLoopBackOff Degraded is True: Plex media server pod plex-0 is failing",
+	}, recordedEvents(recorder), "recorded events should be equal")
+}
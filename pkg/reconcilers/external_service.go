@@ -17,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -26,16 +27,18 @@ import (
 // ExternalServiceReconciler reconciles the external Service deployment for Plex Media Server
 type ExternalServiceReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // NewServiceReconciler returns a new Reconciler that reconciles the Service for Plex Media Server
-func NewExternalServiceReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme) *ExternalServiceReconciler {
+func NewExternalServiceReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *ExternalServiceReconciler {
 	return &ExternalServiceReconciler{
-		Client: client,
-		Log:    log,
-		Scheme: scheme,
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
 	}
 }
 
@@ -53,7 +56,8 @@ func (r *ExternalServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha
 		}
 		log.Info("creating")
 		origService = r.createService(plex)
-		err = r.Client.Create(ctx, origService, &client.CreateOptions{})
+		err = createServiceWithIPFamilies(ctx, r.Client, plex, origService)
+		recordCreated(r.Recorder, plex, "Service", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to create object")
 			return true, err
@@ -72,6 +76,7 @@ func (r *ExternalServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha
 		err = r.Client.Delete(ctx, origService, &client.DeleteOptions{
 			PropagationPolicy: &background,
 		})
+		recordDeleted(r.Recorder, plex, "Service", namespacedName.Name, err)
 		if err != nil {
 			return true, err
 		}
@@ -80,14 +85,19 @@ func (r *ExternalServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha
 
 	// Reconcile the existing service based on the specification
 	desiredService := origService.DeepCopy()
+	renderObjectMeta(plex, desiredService)
+	desiredService.Annotations = renderDNSAnnotations(plex, desiredService.Annotations)
 	desiredService.Spec = r.renderServiceSpec(plex, desiredService.Spec)
-	if !equality.Semantic.DeepEqual(origService.Spec, desiredService.Spec) {
+	if !equality.Semantic.DeepEqual(origService.Spec, desiredService.Spec) ||
+		objectMetaChanged(origService, desiredService) {
 		log.Info("updating")
 		err = r.Update(ctx, desiredService, &client.UpdateOptions{})
 		if errors.IsConflict(err) {
 			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Service", namespacedName.Name)
 			return true, nil
 		}
+		recordUpdated(r.Recorder, plex, "Service", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to update object")
 			return true, err
@@ -96,6 +106,17 @@ func (r *ExternalServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha
 		return true, nil
 	}
 
+	updated, err := reconcileServiceIPFamilies(ctx, r.Client, plex, namespacedName)
+	if err != nil {
+		recordUpdated(r.Recorder, plex, "Service", namespacedName.Name, err)
+		log.Error(err, "failed to update IP families")
+		return true, err
+	}
+	if updated {
+		log.Info("updated IP families")
+		r.Recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonUpdated, "Updated IP families of Service %s", namespacedName.Name)
+		return true, nil
+	}
 	return false, nil
 }
 
@@ -106,6 +127,8 @@ func (r *ExternalServiceReconciler) createService(plex *v1alpha1.PlexMediaServer
 			Name:      fmt.Sprintf("%s-ext", plex.Name),
 		},
 	}
+	renderObjectMeta(plex, service)
+	service.Annotations = renderDNSAnnotations(plex, service.Annotations)
 	service.Spec = r.renderServiceSpec(plex, service.Spec)
 	ctrl.SetControllerReference(plex, service, r.Scheme)
 	return service
@@ -123,29 +146,15 @@ func (r *ExternalServiceReconciler) renderServiceSpec(plex *v1alpha1.PlexMediaSe
 
 func (r *ExternalServiceReconciler) renderServicePorts(plex *v1alpha1.PlexMediaServer, existing []corev1.ServicePort) []corev1.ServicePort {
 	servicePorts := []corev1.ServicePort{}
-	rokuPort := corev1.ServicePort{
-		Port: 8324,
-	}
-	plexPort := corev1.ServicePort{
-		Port: 32400,
-	}
+	existingPlexPorts := map[string]corev1.ServicePort{}
 	for _, port := range existing {
-		if port.Port == 8324 {
-			rokuPort = port
-		}
-		if port.Port == 32400 {
-			plexPort = port
-		}
+		existingPlexPorts[port.Name] = port
 	}
-
-	if plex.Spec.Networking.EnableRoku {
-		rokuPort.Name = "roku"
-		rokuPort.Protocol = corev1.ProtocolTCP
-		servicePorts = append(servicePorts, rokuPort)
+	for _, port := range plexPorts(plex) {
+		if !port.enabled || !port.external {
+			continue
+		}
+		servicePorts = append(servicePorts, renderPlexServicePort(port, existingPlexPorts[port.name], true))
 	}
-
-	plexPort.Protocol = corev1.ProtocolTCP
-	plexPort.Name = "plex"
-	servicePorts = append(servicePorts, plexPort)
 	return servicePorts
 }
@@ -19,7 +19,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/adambkaplan/plex-operator/api/v1alpha1"
@@ -31,6 +33,7 @@ type externalServiceReconcileSuite struct {
 }
 
 func (test *externalServiceReconcileSuite) SetupTest() {
+	dnsTTL := int64(300)
 	test.cases = []serviceTestCase{
 		{
 			name: "none with no existing service",
@@ -108,6 +111,52 @@ func (test *externalServiceReconcileSuite) SetupTest() {
 			}),
 			expectRequeue: true,
 		},
+		{
+			name: "create LoadBalancer with port overrides",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-ports",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						EnableRoku:          true,
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						Ports: []v1alpha1.PlexPortSpec{
+							{
+								Name: "plex",
+								Port: 443,
+							},
+							{
+								Name:     "roku",
+								Port:     18324,
+								NodePort: 30324,
+							},
+						},
+					},
+				},
+			},
+			expectedService: serviceDouble("create", "create-ports", serviceDoubleOptions{
+				ServiceName: "create-ports-ext",
+				ServiceType: corev1.ServiceTypeLoadBalancer,
+				Ports: []corev1.ServicePort{
+					{
+						Name:       "roku",
+						Port:       18324,
+						TargetPort: intstr.FromInt(8324),
+						NodePort:   30324,
+						Protocol:   corev1.ProtocolTCP,
+					},
+					{
+						Name:       "plex",
+						Port:       443,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
+					},
+				},
+			}),
+			expectRequeue: true,
+		},
 		{
 			name: "create LoadBalancer with roku",
 			plex: &v1alpha1.PlexMediaServer{
@@ -127,14 +176,16 @@ func (test *externalServiceReconcileSuite) SetupTest() {
 				ServiceType: corev1.ServiceTypeLoadBalancer,
 				Ports: []corev1.ServicePort{
 					{
-						Port:     8324,
-						Name:     "roku",
-						Protocol: corev1.ProtocolTCP,
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Name:       "roku",
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Port:     32400,
-						Name:     "plex",
-						Protocol: corev1.ProtocolTCP,
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Name:       "plex",
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -208,14 +259,16 @@ func (test *externalServiceReconcileSuite) SetupTest() {
 				ServiceType: corev1.ServiceTypeLoadBalancer,
 				Ports: []corev1.ServicePort{
 					{
-						Port:     8324,
-						Name:     "roku",
-						Protocol: corev1.ProtocolTCP,
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Name:       "roku",
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Port:     32400,
-						Name:     "plex",
-						Protocol: corev1.ProtocolTCP,
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Name:       "plex",
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -243,14 +296,16 @@ func (test *externalServiceReconcileSuite) SetupTest() {
 				ServiceType: corev1.ServiceTypeLoadBalancer,
 				Ports: []corev1.ServicePort{
 					{
-						Port:     8324,
-						Name:     "roku",
-						Protocol: corev1.ProtocolTCP,
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Name:       "roku",
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Port:     32400,
-						Name:     "plex",
-						Protocol: corev1.ProtocolTCP,
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Name:       "plex",
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -300,6 +355,67 @@ func (test *externalServiceReconcileSuite) SetupTest() {
 				ServiceType: corev1.ServiceTypeNodePort,
 			}),
 		},
+		{
+			name: "create with DNS annotations",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "dns",
+					Name:      "dns-create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						DNS: &v1alpha1.PlexDNSSpec{
+							Hostname: "plex.example.com",
+							TTL:      &dnsTTL,
+						},
+					},
+				},
+			},
+			expectedService: serviceDouble("dns", "dns-create", serviceDoubleOptions{
+				ServiceName: "dns-create-ext",
+				ServiceType: corev1.ServiceTypeLoadBalancer,
+				Annotations: map[string]string{
+					"external-dns.alpha.kubernetes.io/hostname": "plex.example.com",
+					"external-dns.alpha.kubernetes.io/ttl":      "300",
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "remove DNS annotations for DNSEndpoint",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "dns",
+					Name:      "dns-endpoint",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						DNS: &v1alpha1.PlexDNSSpec{
+							Hostname: "plex.example.com",
+							Mode:     "DNSEndpoint",
+						},
+					},
+				},
+			},
+			existingService: serviceDouble("dns", "dns-endpoint", serviceDoubleOptions{
+				ServiceName: "dns-endpoint-ext",
+				ServiceType: corev1.ServiceTypeLoadBalancer,
+				Annotations: map[string]string{
+					"example.com/keep":                          "true",
+					"external-dns.alpha.kubernetes.io/hostname": "plex.example.com",
+				},
+			}),
+			expectedService: serviceDouble("dns", "dns-endpoint", serviceDoubleOptions{
+				ServiceName: "dns-endpoint-ext",
+				ServiceType: corev1.ServiceTypeLoadBalancer,
+				Annotations: map[string]string{
+					"example.com/keep": "true",
+				},
+			}),
+			expectRequeue: true,
+		},
 	}
 }
 
@@ -321,9 +437,10 @@ func (test *externalServiceReconcileSuite) TestExternalService() {
 			}
 			client := builder.Build()
 			reconciler := &ExternalServiceReconciler{
-				Client: client,
-				Scheme: client.Scheme(),
-				Log:    log,
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
 			}
 			requeue, err := reconciler.Reconcile(ctx, tc.plex)
 			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
@@ -342,6 +459,7 @@ func (test *externalServiceReconcileSuite) TestExternalService() {
 				test.True(equality.Semantic.DeepEqual(tc.expectedService.Spec, updatedService.Spec),
 					"expected service does not match - diff: %s",
 					cmp.Diff(tc.expectedService.Spec, updatedService.Spec))
+				test.Equal(tc.expectedService.Annotations, updatedService.Annotations, "service annotations should be equal")
 			}
 		})
 	}
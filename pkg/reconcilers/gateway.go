@@ -0,0 +1,268 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+var (
+	// HTTPRouteGVK is the GroupVersionKind of the Gateway API HTTPRoute
+	HTTPRouteGVK = schema.GroupVersionKind{
+		Group:   "gateway.networking.k8s.io",
+		Version: "v1",
+		Kind:    "HTTPRoute",
+	}
+
+	// TCPRouteGVK is the GroupVersionKind of the Gateway API TCPRoute
+	TCPRouteGVK = schema.GroupVersionKind{
+		Group:   "gateway.networking.k8s.io",
+		Version: "v1alpha2",
+		Kind:    "TCPRoute",
+	}
+
+	// UDPRouteGVK is the GroupVersionKind of the Gateway API UDPRoute
+	UDPRouteGVK = schema.GroupVersionKind{
+		Group:   "gateway.networking.k8s.io",
+		Version: "v1alpha2",
+		Kind:    "UDPRoute",
+	}
+)
+
+// gatewayRoute describes a Gateway API route that can be managed for the Plex Media Server
+type gatewayRoute struct {
+	gvk       schema.GroupVersionKind
+	name      string
+	parentRef *v1alpha1.PlexGatewayParentRef
+	// port is the Service port the route sends traffic to
+	port int64
+	// listenerPort is the Gateway listener port the route binds to. Zero if the route binds
+	// to any listener referenced by the parentRef.
+	listenerPort int64
+	// enabled is true if the route should exist
+	enabled bool
+}
+
+// gatewayRoutes returns all Gateway API routes that can be managed for the Plex Media Server
+func gatewayRoutes(plex *v1alpha1.PlexMediaServer) []gatewayRoute {
+	gateway := plex.Spec.Networking.Gateway
+	routes := []gatewayRoute{
+		{
+			gvk:     HTTPRouteGVK,
+			name:    plex.Name,
+			port:    int64(findPlexPort(plex, "plex").port),
+			enabled: gateway != nil,
+		},
+		{
+			gvk:     TCPRouteGVK,
+			name:    fmt.Sprintf("%s-roku", plex.Name),
+			port:    int64(findPlexPort(plex, "roku").port),
+			enabled: gateway != nil && gateway.RokuParentRef != nil && plex.Spec.Networking.EnableRoku,
+		},
+	}
+	if gateway != nil {
+		routes[0].parentRef = &gateway.ParentRef
+		routes[1].parentRef = gateway.RokuParentRef
+	}
+	for i := 0; i < 4; i++ {
+		port := int64(findPlexPort(plex, fmt.Sprintf("discovery-%d", i)).port)
+		route := gatewayRoute{
+			gvk:          UDPRouteGVK,
+			name:         fmt.Sprintf("%s-discovery-%d", plex.Name, i),
+			port:         port,
+			listenerPort: port,
+			enabled:      gateway != nil && gateway.DiscoveryParentRef != nil && plex.Spec.Networking.EnableDiscovery,
+		}
+		if gateway != nil {
+			route.parentRef = gateway.DiscoveryParentRef
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// GatewayReconciler reconciles the Gateway API routes for Plex Media Server.
+// The Gateway API is an optional dependency, so routes are managed as unstructured objects.
+type GatewayReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewGatewayReconciler returns a new Reconciler that reconciles Gateway API routes for Plex Media Server
+func NewGatewayReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *GatewayReconciler {
+	return &GatewayReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the Gateway API routes with the desired state of the PlexMediaServer
+func (r *GatewayReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	requeue := false
+	for _, route := range gatewayRoutes(plex) {
+		routeRequeue, err := r.reconcileRoute(ctx, plex, route)
+		if err != nil {
+			return true, err
+		}
+		requeue = requeue || routeRequeue
+	}
+	return requeue, nil
+}
+
+func (r *GatewayReconciler) reconcileRoute(ctx context.Context, plex *v1alpha1.PlexMediaServer, route gatewayRoute) (bool, error) {
+	origRoute := &unstructured.Unstructured{}
+	origRoute.SetGroupVersionKind(route.gvk)
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: route.name}
+	log := r.Log.WithValues(route.gvk.Kind, namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origRoute)
+
+	if meta.IsNoMatchError(err) {
+		// The Gateway API route is not installed on the cluster
+		if route.enabled {
+			log.Info("route API not found, skipping route creation")
+		}
+		return false, nil
+	}
+	if errors.IsNotFound(err) {
+		if !route.enabled {
+			return false, nil
+		}
+		log.Info("creating")
+		origRoute, err = r.createRoute(plex, route)
+		if err != nil {
+			log.Error(err, "failed to render object")
+			return true, err
+		}
+		err = r.Client.Create(ctx, origRoute, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, route.gvk.Kind, namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if !route.enabled {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origRoute, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, route.gvk.Kind, namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredRoute := origRoute.DeepCopy()
+	renderObjectMeta(plex, desiredRoute)
+	err = r.renderRouteSpec(plex, route, desiredRoute)
+	if err != nil {
+		log.Error(err, "failed to render object")
+		return true, err
+	}
+	if !equality.Semantic.DeepEqual(origRoute.Object["spec"], desiredRoute.Object["spec"]) ||
+		objectMetaChanged(origRoute, desiredRoute) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredRoute, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, route.gvk.Kind, namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, route.gvk.Kind, namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *GatewayReconciler) createRoute(plex *v1alpha1.PlexMediaServer, route gatewayRoute) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(route.gvk)
+	obj.SetNamespace(plex.Namespace)
+	obj.SetName(route.name)
+	if err := r.renderRouteSpec(plex, route, obj); err != nil {
+		return nil, err
+	}
+	renderObjectMeta(plex, obj)
+	if err := ctrl.SetControllerReference(plex, obj, r.Scheme); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// renderRouteSpec renders the fields of the route spec managed by the operator on top of the
+// existing route.
+func (r *GatewayReconciler) renderRouteSpec(plex *v1alpha1.PlexMediaServer, route gatewayRoute, obj *unstructured.Unstructured) error {
+	parentRef := map[string]interface{}{
+		"name": route.parentRef.Name,
+	}
+	if route.parentRef.Namespace != "" {
+		parentRef["namespace"] = route.parentRef.Namespace
+	}
+	if route.parentRef.SectionName != "" {
+		parentRef["sectionName"] = route.parentRef.SectionName
+	}
+	if route.listenerPort > 0 {
+		parentRef["port"] = route.listenerPort
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, []interface{}{parentRef}, "spec", "parentRefs"); err != nil {
+		return err
+	}
+	rules := []interface{}{
+		map[string]interface{}{
+			"backendRefs": []interface{}{
+				map[string]interface{}{
+					"name": plex.Name,
+					"port": route.port,
+				},
+			},
+		},
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, rules, "spec", "rules"); err != nil {
+		return err
+	}
+	if route.gvk != HTTPRouteGVK {
+		return nil
+	}
+	hostnames := plex.Spec.Networking.Gateway.Hostnames
+	if len(hostnames) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "spec", "hostnames")
+		return nil
+	}
+	return unstructured.SetNestedStringSlice(obj.Object, hostnames, "spec", "hostnames")
+}
@@ -0,0 +1,315 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/suite"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+type gatewayTestCase struct {
+	name           string
+	plex           *v1alpha1.PlexMediaServer
+	existingRoutes []*unstructured.Unstructured
+	expectedRoutes []*unstructured.Unstructured
+	absentRoutes   []*unstructured.Unstructured
+	expectError    bool
+	expectRequeue  bool
+}
+
+type gatewayReconcileSuite struct {
+	suite.Suite
+	cases []gatewayTestCase
+}
+
+func (test *gatewayReconcileSuite) SetupTest() {
+	test.cases = []gatewayTestCase{
+		{
+			name: "no gateway",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+			},
+			absentRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "none", "none", routeDoubleOptions{}),
+			},
+		},
+		{
+			name: "create HTTPRoute",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name:      "gateway",
+								Namespace: "infra",
+							},
+							Hostnames: []string{"plex.example.com"},
+						},
+					},
+				},
+			},
+			expectedRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "create", "create", routeDoubleOptions{
+					PlexName:  "create",
+					ParentRef: map[string]interface{}{"name": "gateway", "namespace": "infra"},
+					Port:      32400,
+					Hostnames: []interface{}{"plex.example.com"},
+				}),
+			},
+			absentRoutes: []*unstructured.Unstructured{
+				routeDouble(TCPRouteGVK, "create", "create-roku", routeDoubleOptions{}),
+				routeDouble(UDPRouteGVK, "create", "create-discovery-0", routeDoubleOptions{}),
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "create Roku and discovery routes",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-all",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						EnableRoku:      true,
+						EnableDiscovery: true,
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+							RokuParentRef: &v1alpha1.PlexGatewayParentRef{
+								Name:        "gateway",
+								SectionName: "roku",
+							},
+							DiscoveryParentRef: &v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+						},
+					},
+				},
+			},
+			expectedRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "create", "create-all", routeDoubleOptions{
+					PlexName:  "create-all",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+				}),
+				routeDouble(TCPRouteGVK, "create", "create-all-roku", routeDoubleOptions{
+					PlexName:  "create-all",
+					ParentRef: map[string]interface{}{"name": "gateway", "sectionName": "roku"},
+					Port:      8324,
+				}),
+				routeDouble(UDPRouteGVK, "create", "create-all-discovery-0", routeDoubleOptions{
+					PlexName:  "create-all",
+					ParentRef: map[string]interface{}{"name": "gateway", "port": int64(32410)},
+					Port:      32410,
+				}),
+				routeDouble(UDPRouteGVK, "create", "create-all-discovery-3", routeDoubleOptions{
+					PlexName:  "create-all",
+					ParentRef: map[string]interface{}{"name": "gateway", "port": int64(32414)},
+					Port:      32414,
+				}),
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "delete Roku route when Roku is disabled",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "delete",
+					Name:      "delete",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+							RokuParentRef: &v1alpha1.PlexGatewayParentRef{
+								Name:        "gateway",
+								SectionName: "roku",
+							},
+						},
+					},
+				},
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "delete", "delete", routeDoubleOptions{
+					PlexName:  "delete",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+				}),
+				routeDouble(TCPRouteGVK, "delete", "delete-roku", routeDoubleOptions{
+					PlexName:  "delete",
+					ParentRef: map[string]interface{}{"name": "gateway", "sectionName": "roku"},
+					Port:      8324,
+				}),
+			},
+			expectedRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "delete", "delete", routeDoubleOptions{
+					PlexName:  "delete",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+				}),
+			},
+			absentRoutes: []*unstructured.Unstructured{
+				routeDouble(TCPRouteGVK, "delete", "delete-roku", routeDoubleOptions{}),
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "no change",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "no-change",
+					Name:      "no-change",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+						},
+					},
+				},
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "no-change", "no-change", routeDoubleOptions{
+					PlexName:  "no-change",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+				}),
+			},
+			expectedRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "no-change", "no-change", routeDoubleOptions{
+					PlexName:  "no-change",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+				}),
+			},
+		},
+	}
+}
+
+func (test *gatewayReconcileSuite) TestGatewayReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			for _, route := range tc.existingRoutes {
+				builder.WithObjects(route)
+			}
+			client := builder.Build()
+			reconciler := &GatewayReconciler{
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			for _, expected := range tc.expectedRoutes {
+				updated := &unstructured.Unstructured{}
+				updated.SetGroupVersionKind(expected.GroupVersionKind())
+				err = client.Get(ctx, types.NamespacedName{Namespace: expected.GetNamespace(), Name: expected.GetName()}, updated)
+				test.Require().NoError(err, "failed to get %s %s", expected.GetKind(), expected.GetName())
+				test.True(equality.Semantic.DeepEqual(expected.Object["spec"], updated.Object["spec"]),
+					"expected %s %s does not match - diff: %s",
+					expected.GetKind(), expected.GetName(),
+					cmp.Diff(expected.Object["spec"], updated.Object["spec"]))
+			}
+			for _, absent := range tc.absentRoutes {
+				updated := &unstructured.Unstructured{}
+				updated.SetGroupVersionKind(absent.GroupVersionKind())
+				err = client.Get(ctx, types.NamespacedName{Namespace: absent.GetNamespace(), Name: absent.GetName()}, updated)
+				test.True(errors.IsNotFound(err), "expected %s %s to not exist", absent.GetKind(), absent.GetName())
+			}
+		})
+	}
+}
+
+type routeDoubleOptions struct {
+	PlexName  string
+	ParentRef map[string]interface{}
+	Port      int64
+	Hostnames []interface{}
+	Parents   []interface{}
+}
+
+func routeDouble(gvk schema.GroupVersionKind, namespace, name string, options routeDoubleOptions) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"parentRefs": []interface{}{options.ParentRef},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": options.PlexName,
+						"port": options.Port,
+					},
+				},
+			},
+		},
+	}
+	if len(options.Hostnames) > 0 {
+		spec["hostnames"] = options.Hostnames
+	}
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	if len(options.Parents) > 0 {
+		route.Object["status"] = map[string]interface{}{
+			"parents": options.Parents,
+		}
+	}
+	route.SetGroupVersionKind(gvk)
+	route.SetNamespace(namespace)
+	route.SetName(name)
+	route.SetLabels(plexLabelsDouble(options.PlexName))
+	return route
+}
+
+func TestGatewaySuite(t *testing.T) {
+	suite.Run(t, new(gatewayReconcileSuite))
+}
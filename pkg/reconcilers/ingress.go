@@ -0,0 +1,183 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// IngressReconciler reconciles the Ingress for Plex Media Server
+type IngressReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewIngressReconciler returns a new Reconciler that reconciles the Ingress for Plex Media Server
+func NewIngressReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *IngressReconciler {
+	return &IngressReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the Ingress with the desired state of the PlexMediaServer
+func (r *IngressReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origIngress := &networkingv1.Ingress{}
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
+	log := r.Log.WithValues("ingress", namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origIngress)
+
+	if errors.IsNotFound(err) {
+		// Only create if the ingress is not found and ingress options were specified
+		if plex.Spec.Networking.Ingress == nil {
+			return false, nil
+		}
+		log.Info("creating")
+		origIngress = r.createIngress(plex)
+		err = r.Client.Create(ctx, origIngress, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "Ingress", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	// If the ingress options are removed, we no longer need the ingress
+	if plex.Spec.Networking.Ingress == nil {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origIngress, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "Ingress", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredIngress := origIngress.DeepCopy()
+	r.renderObjectMeta(plex, desiredIngress)
+	desiredIngress.Spec = r.renderIngressSpec(plex, desiredIngress.Spec)
+	if !equality.Semantic.DeepEqual(origIngress.Spec, desiredIngress.Spec) ||
+		objectMetaChanged(origIngress, desiredIngress) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredIngress, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Ingress", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "Ingress", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *IngressReconciler) createIngress(plex *v1alpha1.PlexMediaServer) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: plex.Namespace,
+			Name:      plex.Name,
+		},
+	}
+	r.renderObjectMeta(plex, ingress)
+	ingress.Spec = r.renderIngressSpec(plex, ingress.Spec)
+	ctrl.SetControllerReference(plex, ingress, r.Scheme)
+	return ingress
+}
+
+// renderObjectMeta renders the labels and annotations of the ingress, adding the user-provided
+// ingress annotations on top of the common annotations
+func (r *IngressReconciler) renderObjectMeta(plex *v1alpha1.PlexMediaServer, ingress *networkingv1.Ingress) {
+	ingress.Labels, ingress.Annotations = renderMetadata(plex, ingress.Labels, ingress.Annotations, nil,
+		plex.Spec.Networking.Ingress.Annotations)
+}
+
+func (r *IngressReconciler) renderIngressSpec(plex *v1alpha1.PlexMediaServer, existing networkingv1.IngressSpec) networkingv1.IngressSpec {
+	ingressSpec := plex.Spec.Networking.Ingress
+	pathType := networkingv1.PathTypePrefix
+	existing.IngressClassName = ingressSpec.IngressClassName
+	existing.Rules = []networkingv1.IngressRule{
+		{
+			Host: ingressSpec.Host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: plex.Name,
+									Port: networkingv1.ServiceBackendPort{
+										Name: "plex",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	existing.TLS = nil
+	if secretName := ingressTLSSecretName(plex); secretName != "" {
+		existing.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{ingressSpec.Host},
+				SecretName: secretName,
+			},
+		}
+	}
+	return existing
+}
+
+// ingressTLSSecretName returns the name of the Secret used to terminate TLS on Plex's Ingress.
+// Returns an empty string if TLS is not enabled for the Ingress.
+func ingressTLSSecretName(plex *v1alpha1.PlexMediaServer) string {
+	ingressSpec := plex.Spec.Networking.Ingress
+	if ingressSpec == nil || ingressSpec.TLS == nil {
+		return ""
+	}
+	if ingressSpec.TLS.SecretName != "" {
+		return ingressSpec.TLS.SecretName
+	}
+	if ingressSpec.TLS.IssuerRef != nil {
+		return fmt.Sprintf("%s-tls", plex.Name)
+	}
+	return ""
+}
@@ -0,0 +1,332 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/suite"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+type ingressTestCase struct {
+	name            string
+	plex            *v1alpha1.PlexMediaServer
+	existingIngress *networkingv1.Ingress
+	expectedIngress *networkingv1.Ingress
+	expectError     bool
+	expectRequeue   bool
+}
+
+type ingressReconcileSuite struct {
+	suite.Suite
+	cases []ingressTestCase
+}
+
+func (test *ingressReconcileSuite) SetupTest() {
+	ingressClass := "nginx"
+	test.cases = []ingressTestCase{
+		{
+			name: "none with no existing ingress",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+			},
+		},
+		{
+			name: "none with existing ingress",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none-existing",
+				},
+			},
+			existingIngress: ingressDouble("none", "none-existing", ingressDoubleOptions{
+				Host: "plex.example.com",
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with host",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+						},
+					},
+				},
+			},
+			expectedIngress: ingressDouble("create", "create", ingressDoubleOptions{
+				Host: "plex.example.com",
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with class, annotations, and TLS secret",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-tls",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host:             "plex.example.com",
+							IngressClassName: &ingressClass,
+							Annotations: map[string]string{
+								"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+							},
+							TLS: &v1alpha1.PlexIngressTLS{
+								SecretName: "plex-cert",
+							},
+						},
+					},
+				},
+			},
+			expectedIngress: ingressDouble("create", "create-tls", ingressDoubleOptions{
+				Host:             "plex.example.com",
+				IngressClassName: &ingressClass,
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+					appliedAnnotationsAnnotation:                  "nginx.ingress.kubernetes.io/proxy-body-size",
+				},
+				TLSSecret: "plex-cert",
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with issuer",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-issuer",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedIngress: ingressDouble("create", "create-issuer", ingressDoubleOptions{
+				Host:      "plex.example.com",
+				TLSSecret: "create-issuer-tls",
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update host",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.org",
+						},
+					},
+				},
+			},
+			existingIngress: ingressDouble("update", "update", ingressDoubleOptions{
+				Host: "plex.example.com",
+			}),
+			expectedIngress: ingressDouble("update", "update", ingressDoubleOptions{
+				Host: "plex.example.org",
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update removed annotation",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-annotations",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							Annotations: map[string]string{
+								"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+							},
+						},
+					},
+				},
+			},
+			existingIngress: ingressDouble("update", "update-annotations", ingressDoubleOptions{
+				Host: "plex.example.com",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+					"nginx.ingress.kubernetes.io/ssl-redirect":    "false",
+					"kubernetes.io/change-cause":                  "manual",
+					appliedAnnotationsAnnotation:                  "nginx.ingress.kubernetes.io/proxy-body-size,nginx.ingress.kubernetes.io/ssl-redirect",
+				},
+			}),
+			expectedIngress: ingressDouble("update", "update-annotations", ingressDoubleOptions{
+				Host: "plex.example.com",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/proxy-body-size": "0",
+					"kubernetes.io/change-cause":                  "manual",
+					appliedAnnotationsAnnotation:                  "nginx.ingress.kubernetes.io/proxy-body-size",
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "no change",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "no-change",
+					Name:      "no-change",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+						},
+					},
+				},
+			},
+			existingIngress: ingressDouble("no-change", "no-change", ingressDoubleOptions{
+				Host: "plex.example.com",
+			}),
+			expectedIngress: ingressDouble("no-change", "no-change", ingressDoubleOptions{
+				Host: "plex.example.com",
+			}),
+		},
+	}
+}
+
+func (test *ingressReconcileSuite) TestIngressReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			if tc.existingIngress != nil {
+				builder.WithObjects(tc.existingIngress)
+			}
+			client := builder.Build()
+			reconciler := &IngressReconciler{
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			updatedIngress := &networkingv1.Ingress{}
+			err = client.Get(ctx, types.NamespacedName{Namespace: tc.plex.Namespace, Name: tc.plex.Name}, updatedIngress)
+			if tc.expectedIngress == nil {
+				test.True(errors.IsNotFound(err), "expected ingress to not exist")
+				return
+			}
+			test.Require().NoError(err, "failed to get Ingress")
+			test.True(equality.Semantic.DeepEqual(tc.expectedIngress.Spec, updatedIngress.Spec),
+				"expected ingress does not match - diff: %s",
+				cmp.Diff(tc.expectedIngress.Spec, updatedIngress.Spec))
+			test.Equal(tc.expectedIngress.Annotations, updatedIngress.Annotations, "ingress annotations should be equal")
+		})
+	}
+}
+
+type ingressDoubleOptions struct {
+	Host             string
+	IngressClassName *string
+	Annotations      map[string]string
+	TLSSecret        string
+}
+
+func ingressDouble(namespace, plexName string, options ingressDoubleOptions) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        plexName,
+			Labels:      plexLabelsDouble(plexName),
+			Annotations: options.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: options.IngressClassName,
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: options.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{
+								{
+									Path:     "/",
+									PathType: &pathType,
+									Backend: networkingv1.IngressBackend{
+										Service: &networkingv1.IngressServiceBackend{
+											Name: plexName,
+											Port: networkingv1.ServiceBackendPort{
+												Name: "plex",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if options.TLSSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      []string{options.Host},
+				SecretName: options.TLSSecret,
+			},
+		}
+	}
+	return ingress
+}
+
+func TestIngressSuite(t *testing.T) {
+	suite.Run(t, new(ingressReconcileSuite))
+}
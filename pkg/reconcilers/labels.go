@@ -0,0 +1,131 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// appliedLabelsAnnotation records the keys of the common and additional labels the operator
+	// applied, so that labels removed from the PlexMediaServer are removed from the object
+	appliedLabelsAnnotation = "plex.adambkaplan.com/applied-labels"
+
+	// appliedAnnotationsAnnotation records the keys of the common and additional annotations the
+	// operator applied, so that annotations removed from the PlexMediaServer are removed from the
+	// object
+	appliedAnnotationsAnnotation = "plex.adambkaplan.com/applied-annotations"
+)
+
+// plexLabels returns the standard labels of every object the operator manages for Plex. The
+// plex.adambkaplan.com/instance label selects Plex's pod, and must not change.
+func plexLabels(plex *v1alpha1.PlexMediaServer) map[string]string {
+	labels := map[string]string{
+		"app.kubernetes.io/name":        "plex-media-server",
+		"app.kubernetes.io/instance":    plex.Name,
+		"app.kubernetes.io/managed-by":  "plex-operator",
+		"plex.adambkaplan.com/instance": plex.Name,
+	}
+	version := plexVersion(plex)
+	if len(validation.IsValidLabelValue(version)) == 0 {
+		labels["app.kubernetes.io/version"] = version
+	}
+	return labels
+}
+
+// renderMetadata merges the PlexMediaServer's common labels and annotations, the given labels and
+// annotations, and the standard labels on top of the existing labels and annotations. The standard
+// labels take precedence. Labels and annotations added by other tools are kept, while those the
+// operator applied before and are no longer set are removed. The keys the operator applied are
+// recorded in annotations on the object.
+func renderMetadata(plex *v1alpha1.PlexMediaServer, existingLabels map[string]string, existingAnnotations map[string]string,
+	additionalLabels map[string]string, additionalAnnotations map[string]string) (map[string]string, map[string]string) {
+	desiredLabels := mergeMaps(plex.Spec.CommonLabels, additionalLabels)
+	desiredAnnotations := mergeMaps(plex.Spec.CommonAnnotations, additionalAnnotations)
+
+	labels := applyMetadata(existingLabels, appliedKeys(existingAnnotations, appliedLabelsAnnotation), desiredLabels)
+	for k, v := range plexLabels(plex) {
+		labels[k] = v
+	}
+	annotations := applyMetadata(existingAnnotations, appliedKeys(existingAnnotations, appliedAnnotationsAnnotation), desiredAnnotations)
+	recordAppliedKeys(annotations, appliedLabelsAnnotation, desiredLabels)
+	recordAppliedKeys(annotations, appliedAnnotationsAnnotation, desiredAnnotations)
+	if len(annotations) == 0 {
+		return labels, nil
+	}
+	return labels, annotations
+}
+
+// mergeMaps merges the maps in order, so that later maps take precedence
+func mergeMaps(maps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// applyMetadata returns a copy of the existing labels or annotations, with the keys applied before
+// removed and the desired keys set
+func applyMetadata(existing map[string]string, lastApplied []string, desired map[string]string) map[string]string {
+	applied := map[string]string{}
+	for k, v := range existing {
+		applied[k] = v
+	}
+	for _, k := range lastApplied {
+		delete(applied, k)
+	}
+	for k, v := range desired {
+		applied[k] = v
+	}
+	return applied
+}
+
+// appliedKeys returns the keys recorded in the given annotation
+func appliedKeys(annotations map[string]string, annotation string) []string {
+	if annotations[annotation] == "" {
+		return nil
+	}
+	return strings.Split(annotations[annotation], ",")
+}
+
+// recordAppliedKeys records the sorted keys of the applied labels or annotations in the given
+// annotation. The annotation is removed if nothing was applied.
+func recordAppliedKeys(annotations map[string]string, annotation string, applied map[string]string) {
+	if len(applied) == 0 {
+		delete(annotations, annotation)
+		return
+	}
+	keys := []string{}
+	for k := range applied {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	annotations[annotation] = strings.Join(keys, ",")
+}
+
+// renderObjectMeta renders the labels and annotations of an object the operator manages for Plex
+func renderObjectMeta(plex *v1alpha1.PlexMediaServer, obj metav1.Object) {
+	labels, annotations := renderMetadata(plex, obj.GetLabels(), obj.GetAnnotations(), nil, nil)
+	obj.SetLabels(labels)
+	obj.SetAnnotations(annotations)
+}
+
+// objectMetaChanged returns true if the labels or annotations of the desired object differ from
+// the original object
+func objectMetaChanged(orig metav1.Object, desired metav1.Object) bool {
+	return !equality.Semantic.DeepEqual(orig.GetLabels(), desired.GetLabels()) ||
+		!equality.Semantic.DeepEqual(orig.GetAnnotations(), desired.GetAnnotations())
+}
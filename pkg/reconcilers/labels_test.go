@@ -0,0 +1,228 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// plexLabelsDouble returns the standard labels of objects managed for a PlexMediaServer without a
+// version
+func plexLabelsDouble(plexName string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":        "plex-media-server",
+		"app.kubernetes.io/instance":    plexName,
+		"app.kubernetes.io/version":     "latest",
+		"app.kubernetes.io/managed-by":  "plex-operator",
+		"plex.adambkaplan.com/instance": plexName,
+	}
+}
+
+func TestRenderLabels(t *testing.T) {
+	cases := []struct {
+		name     string
+		spec     v1alpha1.PlexMediaServerSpec
+		existing map[string]string
+		pod      map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "standard labels",
+			expected: plexLabelsDouble("plex"),
+		},
+		{
+			name: "version",
+			spec: v1alpha1.PlexMediaServerSpec{
+				Version: "1.32.5.7349-8f4248874",
+			},
+			expected: map[string]string{
+				"app.kubernetes.io/name":        "plex-media-server",
+				"app.kubernetes.io/instance":    "plex",
+				"app.kubernetes.io/version":     "1.32.5.7349-8f4248874",
+				"app.kubernetes.io/managed-by":  "plex-operator",
+				"plex.adambkaplan.com/instance": "plex",
+			},
+		},
+		{
+			name: "merge common and pod labels",
+			spec: v1alpha1.PlexMediaServerSpec{
+				CommonLabels: map[string]string{
+					"team":                          "media",
+					"tier":                          "common",
+					"plex.adambkaplan.com/instance": "other",
+				},
+			},
+			existing: map[string]string{
+				"tier":     "existing",
+				"injected": "true",
+			},
+			pod: map[string]string{
+				"tier": "pod",
+			},
+			expected: map[string]string{
+				"app.kubernetes.io/name":        "plex-media-server",
+				"app.kubernetes.io/instance":    "plex",
+				"app.kubernetes.io/version":     "latest",
+				"app.kubernetes.io/managed-by":  "plex-operator",
+				"plex.adambkaplan.com/instance": "plex",
+				"team":                          "media",
+				"tier":                          "pod",
+				"injected":                      "true",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+				Spec: tc.spec,
+			}
+			labels, _ := renderMetadata(plex, tc.existing, nil, tc.pod, nil)
+			assert.Equal(t, tc.expected, labels, "labels should be equal")
+		})
+	}
+}
+
+func TestRenderAnnotations(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{}
+	_, annotations := renderMetadata(plex, nil, nil, nil, nil)
+	assert.Nil(t, annotations, "annotations should not be added")
+	plex.Spec.CommonAnnotations = map[string]string{
+		"owner": "media-team",
+		"note":  "common",
+	}
+	existing := map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"note":                             "existing",
+	}
+	expected := map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"owner":                            "media-team",
+		"note":                             "pod",
+		appliedAnnotationsAnnotation:       "note,owner",
+	}
+	_, annotations = renderMetadata(plex, nil, existing, nil, map[string]string{"note": "pod"})
+	assert.Equal(t, expected, annotations, "annotations should be equal")
+}
+
+func TestRenderMetadataRemovedKeys(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			CommonLabels: map[string]string{
+				"team": "media",
+				"tier": "common",
+			},
+			CommonAnnotations: map[string]string{
+				"owner": "media-team",
+				"note":  "common",
+			},
+		},
+	}
+	existingLabels := map[string]string{
+		"injected": "true",
+	}
+	existingAnnotations := map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+	}
+	labels, annotations := renderMetadata(plex, existingLabels, existingAnnotations, nil, nil)
+	assert.Equal(t, "common", labels["tier"], "common label should be applied")
+	assert.Equal(t, "common", annotations["note"], "common annotation should be applied")
+
+	// Removing a key removes it from the object, but keeps the keys added by other tools
+	delete(plex.Spec.CommonLabels, "tier")
+	delete(plex.Spec.CommonAnnotations, "note")
+	labels, annotations = renderMetadata(plex, labels, annotations, nil, nil)
+	expectedLabels := plexLabelsDouble("plex")
+	expectedLabels["team"] = "media"
+	expectedLabels["injected"] = "true"
+	assert.Equal(t, expectedLabels, labels, "removed label should be removed")
+	assert.Equal(t, map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+		"owner":                            "media-team",
+		appliedLabelsAnnotation:            "team",
+		appliedAnnotationsAnnotation:       "owner",
+	}, annotations, "removed annotation should be removed")
+
+	// Removing all keys removes the record of the applied keys
+	plex.Spec.CommonLabels = nil
+	plex.Spec.CommonAnnotations = nil
+	labels, annotations = renderMetadata(plex, labels, annotations, nil, nil)
+	expectedLabels = plexLabelsDouble("plex")
+	expectedLabels["injected"] = "true"
+	assert.Equal(t, expectedLabels, labels, "removed labels should be removed")
+	assert.Equal(t, map[string]string{
+		"vault.hashicorp.com/agent-inject": "true",
+	}, annotations, "removed annotations should be removed")
+}
+
+func TestRenderPodTemplateMetadata(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			CommonLabels: map[string]string{
+				"team": "media",
+			},
+			PodLabels: map[string]string{
+				"sidecar.istio.io/inject": "false",
+			},
+			PodAnnotations: map[string]string{
+				"linkerd.io/inject": "enabled",
+			},
+		},
+	}
+	existing := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"plex.adambkaplan.com/instance": "plex",
+			},
+			Annotations: map[string]string{
+				"kubectl.kubernetes.io/restartedAt": "2021-01-01T00:00:00Z",
+				preferencesHashAnnotation:           "stale",
+			},
+		},
+	}
+	reconciler := &StatefulSetReconciler{}
+	template := reconciler.renderPodTemplate(plex, existing)
+
+	expectedLabels := plexLabelsDouble("plex")
+	expectedLabels["team"] = "media"
+	expectedLabels["sidecar.istio.io/inject"] = "false"
+	assert.Equal(t, expectedLabels, template.Labels, "pod labels should be equal")
+	assert.Equal(t, map[string]string{
+		"kubectl.kubernetes.io/restartedAt": "2021-01-01T00:00:00Z",
+		"linkerd.io/inject":                 "enabled",
+		appliedLabelsAnnotation:             "sidecar.istio.io/inject,team",
+		appliedAnnotationsAnnotation:        "linkerd.io/inject",
+	}, template.Annotations, "pod annotations should be equal")
+
+	// Removing a pod label and annotation removes them from the pod template
+	plex.Spec.PodLabels = nil
+	plex.Spec.PodAnnotations = nil
+	template = reconciler.renderPodTemplate(plex, template)
+	delete(expectedLabels, "sidecar.istio.io/inject")
+	assert.Equal(t, expectedLabels, template.Labels, "pod labels should be equal")
+	assert.Equal(t, map[string]string{
+		"kubectl.kubernetes.io/restartedAt": "2021-01-01T00:00:00Z",
+		appliedLabelsAnnotation:             "team",
+	}, template.Annotations, "pod annotations should be equal")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// resolveLANNetworks returns the networks Plex should treat as local. User-provided networks are
+// listed first, followed by the pod networks of each Node and the cluster's service networks if
+// cluster networks are included. Invalid and duplicate networks are skipped.
+func resolveLANNetworks(ctx context.Context, c client.Client, log logr.Logger, plex *v1alpha1.PlexMediaServer) ([]string, error) {
+	lanSpec := plex.Spec.Networking.LANNetworks
+	if lanSpec == nil {
+		return nil, nil
+	}
+	networks := []string{}
+	seen := map[string]bool{}
+	addNetworks := func(cidrs []string) {
+		for _, cidr := range cidrs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				log.Info("skipping invalid LAN network", "cidr", cidr)
+				continue
+			}
+			network := ipNet.String()
+			if seen[network] {
+				continue
+			}
+			seen[network] = true
+			networks = append(networks, network)
+		}
+	}
+	addNetworks(lanSpec.CIDRs)
+	if !lanSpec.IncludeClusterNetworks {
+		return networks, nil
+	}
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+	podCIDRs := []string{}
+	for _, node := range nodes.Items {
+		podCIDRs = append(podCIDRs, nodePodCIDRs(&node)...)
+	}
+	sort.Strings(podCIDRs)
+	addNetworks(podCIDRs)
+	addNetworks(lanSpec.ServiceCIDRs)
+	return networks, nil
+}
+
+// nodePodCIDRs returns the pod networks assigned to the Node
+func nodePodCIDRs(node *corev1.Node) []string {
+	if len(node.Spec.PodCIDRs) > 0 {
+		return node.Spec.PodCIDRs
+	}
+	if node.Spec.PodCIDR != "" {
+		return []string{node.Spec.PodCIDR}
+	}
+	return nil
+}
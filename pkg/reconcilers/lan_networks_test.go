@@ -0,0 +1,97 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestResolveLANNetworks(t *testing.T) {
+	nodes := []client.Object{
+		nodeDouble("node-b", "10.244.1.0/24", "fd00:10:244:1::/64"),
+		nodeDouble("node-a", "10.244.0.0/24"),
+		nodeDouble("node-c"),
+	}
+	cases := []struct {
+		name     string
+		lanSpec  *v1alpha1.PlexLANNetworksSpec
+		expected []string
+	}{
+		{
+			name: "no LAN networks",
+		},
+		{
+			name: "user networks",
+			lanSpec: &v1alpha1.PlexLANNetworksSpec{
+				CIDRs:        []string{"192.168.1.0/24", "not-a-cidr", "192.168.1.10/24"},
+				ServiceCIDRs: []string{"10.96.0.0/12"},
+			},
+			expected: []string{"192.168.1.0/24"},
+		},
+		{
+			name: "cluster networks",
+			lanSpec: &v1alpha1.PlexLANNetworksSpec{
+				CIDRs:                  []string{"192.168.1.0/24"},
+				IncludeClusterNetworks: true,
+				ServiceCIDRs:           []string{"10.96.0.0/12"},
+			},
+			expected: []string{
+				"192.168.1.0/24",
+				"10.244.0.0/24",
+				"10.244.1.0/24",
+				"fd00:10:244:1::/64",
+				"10.96.0.0/12",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "lan",
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						LANNetworks: tc.lanSpec,
+					},
+				},
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(nodes...).Build()
+			networks, err := resolveLANNetworks(context.TODO(), c, logr.Discard(), plex)
+			require.NoError(t, err, "unexpected error resolving LAN networks")
+			assert.Equal(t, tc.expected, networks, "LAN networks should be equal")
+		})
+	}
+}
+
+func nodeDouble(name string, podCIDRs ...string) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.NodeSpec{
+			PodCIDRs: podCIDRs,
+		},
+	}
+	if len(podCIDRs) > 0 {
+		node.Spec.PodCIDR = podCIDRs[0]
+	}
+	return node
+}
@@ -0,0 +1,226 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// defaultMaintenanceWindowDuration is how long a maintenance window stays open by default
+	defaultMaintenanceWindowDuration = time.Hour
+
+	// scheduleSearchLimit bounds the search for the next time a schedule matches, so that
+	// schedules that never match (such as February 30th) do not search forever
+	scheduleSearchLimit = 5 * 366 * 24 * time.Hour
+)
+
+// schedule is a parsed five-field cron expression. Each field is a bit set of the values it
+// matches.
+type schedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+
+	// dayOfMonthAny and dayOfWeekAny are true if the field starts with `*`, such as `*/2`. As in
+	// cron, if both day fields are restricted, a day matches if either field matches.
+	dayOfMonthAny bool
+	dayOfWeekAny  bool
+}
+
+// scheduleField is the range of values of a cron field
+type scheduleField struct {
+	name string
+	min  int
+	max  int
+}
+
+var scheduleFields = []scheduleField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// parseSchedule parses a cron expression with five fields: minute, hour, day of month, month, and
+// day of week. Fields accept `*`, lists, ranges, and steps. Sunday is 0 or 7.
+func parseSchedule(expression string) (*schedule, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("schedule %q must have %d fields, found %d", expression, len(scheduleFields), len(fields))
+	}
+	values := make([]uint64, len(fields))
+	for i, field := range fields {
+		parsed, err := parseScheduleField(field, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %v", expression, err)
+		}
+		values[i] = parsed
+	}
+	s := &schedule{
+		minute:        values[0],
+		hour:          values[1],
+		dayOfMonth:    values[2],
+		month:         values[3],
+		dayOfWeek:     values[4],
+		dayOfMonthAny: strings.HasPrefix(fields[2], "*"),
+		dayOfWeekAny:  strings.HasPrefix(fields[4], "*"),
+	}
+	// Sunday can be written as 0 or 7
+	if s.dayOfWeek&(1<<7) != 0 {
+		s.dayOfWeek |= 1
+	}
+	return s, nil
+}
+
+// parseScheduleField parses a comma-separated list of `*`, values, or ranges, each with an
+// optional step, into a bit set
+func parseScheduleField(field string, bounds scheduleField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart := part
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			parsed, err := strconv.Atoi(part[i+1:])
+			if err != nil || parsed < 1 {
+				return 0, fmt.Errorf("invalid step in %s field %q", bounds.name, part)
+			}
+			rangePart = part[:i]
+			step = parsed
+		}
+		start, end := bounds.min, bounds.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			values := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = parseScheduleValue(values[0], bounds); err != nil {
+				return 0, err
+			}
+			if end, err = parseScheduleValue(values[1], bounds); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range in %s field %q", bounds.name, part)
+			}
+		default:
+			value, err := parseScheduleValue(rangePart, bounds)
+			if err != nil {
+				return 0, err
+			}
+			start = value
+			// A single value with a step, such as 5/15, repeats until the end of the range
+			if step == 1 {
+				end = value
+			}
+		}
+		for value := start; value <= end; value += step {
+			set |= 1 << uint(value)
+		}
+	}
+	return set, nil
+}
+
+// parseScheduleValue parses a number in a cron field, which must be within the field's range
+func parseScheduleValue(value string, bounds scheduleField) (int, error) {
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value in %s field %q", bounds.name, value)
+	}
+	if parsed < bounds.min || parsed > bounds.max {
+		return 0, fmt.Errorf("%s %d is not between %d and %d", bounds.name, parsed, bounds.min, bounds.max)
+	}
+	return parsed, nil
+}
+
+// matchesDay returns true if the schedule runs on the day of t
+func (s *schedule) matchesDay(t time.Time) bool {
+	dayOfMonth := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dayOfWeek := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.dayOfMonthAny || s.dayOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+// next returns the first time after t at which the schedule runs, or the zero time if the schedule
+// does not run within the search limit. Days that do not match are skipped one at a time, and
+// within a matching day the search skips directly to the next matching hour and minute.
+func (s *schedule) next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(scheduleSearchLimit)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		hour, ok := nextScheduleValue(s.hour, t.Hour())
+		if !ok {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if hour != t.Hour() {
+			t = time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, time.UTC)
+		}
+		minute, ok := nextScheduleValue(s.minute, t.Minute())
+		if !ok {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), minute, 0, 0, time.UTC)
+	}
+	return time.Time{}
+}
+
+// nextScheduleValue returns the smallest value in the bit set that is at least from. False is
+// returned if there is no such value.
+func nextScheduleValue(set uint64, from int) (int, bool) {
+	remaining := set >> uint(from)
+	if remaining == 0 {
+		return 0, false
+	}
+	return from + bits.TrailingZeros64(remaining), true
+}
+
+// maintenanceWindowDuration returns how long the maintenance window stays open
+func maintenanceWindowDuration(window *v1alpha1.PlexMaintenanceWindow) time.Duration {
+	if window.Duration == nil || window.Duration.Duration <= 0 {
+		return defaultMaintenanceWindowDuration
+	}
+	return window.Duration.Duration
+}
+
+// inMaintenanceWindow returns true if Plex can be updated at the given time. Plex can always be
+// updated if there is no maintenance window. If the window is closed, the time it next opens is
+// returned.
+func inMaintenanceWindow(updates *v1alpha1.PlexUpdatesSpec, now time.Time) (bool, time.Time, error) {
+	if updates == nil || updates.MaintenanceWindow == nil {
+		return true, time.Time{}, nil
+	}
+	window, err := parseSchedule(updates.MaintenanceWindow.Schedule)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	// The window is open if it opened less than its duration ago
+	opened := window.next(now.Add(-maintenanceWindowDuration(updates.MaintenanceWindow)))
+	if !opened.IsZero() && !opened.After(now) {
+		return true, time.Time{}, nil
+	}
+	return false, window.next(now), nil
+}
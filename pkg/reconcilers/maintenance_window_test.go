@@ -0,0 +1,202 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestParseSchedule(t *testing.T) {
+	cases := []struct {
+		name          string
+		schedule      string
+		expectedError string
+	}{
+		{name: "every minute", schedule: "* * * * *"},
+		{name: "lists, ranges, and steps", schedule: "0,30 1-5/2 */10 1-12 1-5"},
+		{name: "day names", schedule: "0 3 * * mon-fri", expectedError: `schedule "0 3 * * mon-fri": invalid value in day of week field "mon"`},
+		{name: "sunday as 7", schedule: "0 3 * * 7"},
+		{name: "too few fields", schedule: "0 3 * *", expectedError: `schedule "0 3 * *" must have 5 fields, found 4`},
+		{name: "out of range", schedule: "0 24 * * *", expectedError: `schedule "0 24 * * *": hour 24 is not between 0 and 23`},
+		{name: "reversed range", schedule: "0 5-1 * * *", expectedError: `schedule "0 5-1 * * *": invalid range in hour field "5-1"`},
+		{name: "invalid step", schedule: "*/0 * * * *", expectedError: `schedule "*/0 * * * *": invalid step in minute field "*/0"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseSchedule(tc.schedule)
+			if tc.expectedError == "" {
+				assert.NoError(t, err, "schedule should be valid")
+				return
+			}
+			if assert.Error(t, err, "schedule should not be valid") {
+				assert.Equal(t, tc.expectedError, err.Error(), "error should be equal")
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2023-06-14 is a Wednesday
+	from := time.Date(2023, time.June, 14, 12, 34, 56, 0, time.UTC)
+	cases := []struct {
+		name     string
+		schedule string
+		expected time.Time
+	}{
+		{
+			name:     "every minute",
+			schedule: "* * * * *",
+			expected: time.Date(2023, time.June, 14, 12, 35, 0, 0, time.UTC),
+		},
+		{
+			name:     "daily",
+			schedule: "0 3 * * *",
+			expected: time.Date(2023, time.June, 15, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "step",
+			schedule: "*/15 * * * *",
+			expected: time.Date(2023, time.June, 14, 12, 45, 0, 0, time.UTC),
+		},
+		{
+			name:     "sunday as 7",
+			schedule: "30 2 * * 7",
+			expected: time.Date(2023, time.June, 18, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			schedule: "0 0 1 * 5",
+			expected: time.Date(2023, time.June, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// A day field starting with * is not restricted, so both day fields must match
+			name:     "day of month step and day of week",
+			schedule: "0 3 */2 * 1",
+			expected: time.Date(2023, time.June, 19, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "next year",
+			schedule: "0 4 1 1 *",
+			expected: time.Date(2024, time.January, 1, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "later hour",
+			schedule: "15 20-22 * * *",
+			expected: time.Date(2023, time.June, 14, 20, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "next hour",
+			schedule: "10,20 * * * *",
+			expected: time.Date(2023, time.June, 14, 13, 10, 0, 0, time.UTC),
+		},
+		{
+			name:     "leap day",
+			schedule: "0 0 29 2 *",
+			expected: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "never",
+			schedule: "0 0 30 2 *",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := parseSchedule(tc.schedule)
+			require.NoError(t, err, "failed to parse schedule")
+			assert.Equal(t, tc.expected, s.next(from), "next time should be equal")
+		})
+	}
+}
+
+func TestNextScheduleValue(t *testing.T) {
+	set := uint64(1<<3 | 1<<10 | 1<<59)
+	cases := []struct {
+		from     int
+		expected int
+		found    bool
+	}{
+		{from: 0, expected: 3, found: true},
+		{from: 3, expected: 3, found: true},
+		{from: 4, expected: 10, found: true},
+		{from: 11, expected: 59, found: true},
+	}
+	for _, tc := range cases {
+		value, found := nextScheduleValue(set, tc.from)
+		assert.Equal(t, tc.found, found, "found should be equal from %d", tc.from)
+		assert.Equal(t, tc.expected, value, "value should be equal from %d", tc.from)
+	}
+	_, found := nextScheduleValue(1<<3, 4)
+	assert.False(t, found, "no value should be found after the last value")
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	cases := []struct {
+		name         string
+		updates      *v1alpha1.PlexUpdatesSpec
+		now          time.Time
+		expectedOpen bool
+		expectedNext time.Time
+	}{
+		{
+			name:         "no window",
+			updates:      &v1alpha1.PlexUpdatesSpec{},
+			now:          time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC),
+			expectedOpen: true,
+		},
+		{
+			name: "window opens",
+			updates: &v1alpha1.PlexUpdatesSpec{
+				MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * * *"},
+			},
+			now:          time.Date(2023, time.June, 14, 3, 0, 0, 0, time.UTC),
+			expectedOpen: true,
+		},
+		{
+			name: "window is open",
+			updates: &v1alpha1.PlexUpdatesSpec{
+				MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * * *"},
+			},
+			now:          time.Date(2023, time.June, 14, 3, 59, 59, 0, time.UTC),
+			expectedOpen: true,
+		},
+		{
+			name: "window closed",
+			updates: &v1alpha1.PlexUpdatesSpec{
+				MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * * *"},
+			},
+			now:          time.Date(2023, time.June, 14, 4, 0, 0, 0, time.UTC),
+			expectedNext: time.Date(2023, time.June, 15, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "custom duration",
+			updates: &v1alpha1.PlexUpdatesSpec{
+				MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{
+					Schedule: "0 22 * * 6",
+					Duration: &metav1.Duration{Duration: 8 * time.Hour},
+				},
+			},
+			// Sunday morning, after the window opened on Saturday night
+			now:          time.Date(2023, time.June, 18, 5, 0, 0, 0, time.UTC),
+			expectedOpen: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			open, next, err := inMaintenanceWindow(tc.updates, tc.now)
+			require.NoError(t, err, "failed to check maintenance window")
+			assert.Equal(t, tc.expectedOpen, open, "open should be equal")
+			assert.Equal(t, tc.expectedNext, next, "next window should be equal")
+		})
+	}
+}
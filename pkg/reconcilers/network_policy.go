@@ -0,0 +1,243 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// namespaceNameLabel is set by Kubernetes on every namespace to the namespace's name
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// NetworkPolicyReconciler reconciles the NetworkPolicy for Plex Media Server
+type NetworkPolicyReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Platform Platform
+}
+
+// NewNetworkPolicyReconciler returns a new Reconciler that reconciles the NetworkPolicy for Plex Media Server
+func NewNetworkPolicyReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder, platform Platform) *NetworkPolicyReconciler {
+	return &NetworkPolicyReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+		Platform: platform,
+	}
+}
+
+// Reconcile reconciles the NetworkPolicy with the desired state of the PlexMediaServer
+func (r *NetworkPolicyReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origPolicy := &networkingv1.NetworkPolicy{}
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
+	log := r.Log.WithValues("networkPolicy", namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origPolicy)
+
+	if errors.IsNotFound(err) {
+		// Only create if the policy is not found and network policy options were specified
+		if plex.Spec.Networking.NetworkPolicy == nil {
+			return false, nil
+		}
+		log.Info("creating")
+		origPolicy = r.createNetworkPolicy(plex)
+		err = r.Client.Create(ctx, origPolicy, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "NetworkPolicy", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	// If the network policy options are removed, we no longer need the policy
+	if plex.Spec.Networking.NetworkPolicy == nil {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origPolicy, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "NetworkPolicy", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredPolicy := origPolicy.DeepCopy()
+	renderObjectMeta(plex, desiredPolicy)
+	desiredPolicy.Spec = r.renderNetworkPolicySpec(plex, desiredPolicy.Spec)
+	if !equality.Semantic.DeepEqual(origPolicy.Spec, desiredPolicy.Spec) ||
+		objectMetaChanged(origPolicy, desiredPolicy) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredPolicy, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "NetworkPolicy", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "NetworkPolicy", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *NetworkPolicyReconciler) createNetworkPolicy(plex *v1alpha1.PlexMediaServer) *networkingv1.NetworkPolicy {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: plex.Namespace,
+			Name:      plex.Name,
+		},
+	}
+	policy.Spec = r.renderNetworkPolicySpec(plex, policy.Spec)
+	renderObjectMeta(plex, policy)
+	ctrl.SetControllerReference(plex, policy, r.Scheme)
+	return policy
+}
+
+func (r *NetworkPolicyReconciler) renderNetworkPolicySpec(plex *v1alpha1.PlexMediaServer, existing networkingv1.NetworkPolicySpec) networkingv1.NetworkPolicySpec {
+	policySpec := plex.Spec.Networking.NetworkPolicy
+	existing.PodSelector = metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"plex.adambkaplan.com/instance": plex.Name,
+		},
+	}
+	existing.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+	existing.Ingress = []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: r.renderIngressPorts(plex),
+			From:  r.renderIngressPeers(policySpec),
+		},
+	}
+	existing.Egress = nil
+	if policySpec.Egress != nil {
+		existing.PolicyTypes = append(existing.PolicyTypes, networkingv1.PolicyTypeEgress)
+		existing.Egress = r.renderEgressRules(policySpec.Egress)
+	}
+	return existing
+}
+
+// renderIngressPorts allows traffic to each enabled port in Plex's port table, so that enabling a
+// feature on the Plex Service also opens it on the NetworkPolicy. NetworkPolicies apply to the
+// pod, so the container's target ports are allowed.
+func (r *NetworkPolicyReconciler) renderIngressPorts(plex *v1alpha1.PlexMediaServer) []networkingv1.NetworkPolicyPort {
+	policyPorts := []networkingv1.NetworkPolicyPort{}
+	for _, port := range plexPorts(plex) {
+		if !port.enabled {
+			continue
+		}
+		policyPorts = append(policyPorts, networkPolicyPort(port.protocol, port.targetPort))
+	}
+	return policyPorts
+}
+
+// renderIngressPeers allows traffic from the allowed CIDRs and namespaces. If traffic is restricted,
+// the operator's namespace is also allowed so that the operator can reach Plex's HTTP API.
+func (r *NetworkPolicyReconciler) renderIngressPeers(policySpec *v1alpha1.PlexNetworkPolicySpec) []networkingv1.NetworkPolicyPeer {
+	peers := ipBlockPeers(policySpec.AllowedCIDRs)
+	if policySpec.AllowedNamespaces != nil {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: policySpec.AllowedNamespaces.DeepCopy(),
+		})
+	}
+	if len(peers) > 0 && r.Platform.OperatorNamespace != "" {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					namespaceNameLabel: r.Platform.OperatorNamespace,
+				},
+			},
+		})
+	}
+	return peers
+}
+
+// renderEgressRules allows DNS lookups, HTTPS traffic to plex.tv, and traffic to NFS servers.
+// HTTPS traffic is only allowed if plex.tv's CIDRs are set, so that an empty list does not allow
+// HTTPS traffic to all destinations.
+func (r *NetworkPolicyReconciler) renderEgressRules(egress *v1alpha1.PlexNetworkPolicyEgress) []networkingv1.NetworkPolicyEgressRule {
+	rules := []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{
+				networkPolicyPort(corev1.ProtocolUDP, 53),
+				networkPolicyPort(corev1.ProtocolTCP, 53),
+			},
+		},
+	}
+	if len(egress.PlexTVCIDRs) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				networkPolicyPort(corev1.ProtocolTCP, 443),
+			},
+			To: ipBlockPeers(egress.PlexTVCIDRs),
+		})
+	}
+	if len(egress.NFSCIDRs) > 0 {
+		rules = append(rules, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				networkPolicyPort(corev1.ProtocolTCP, 111),
+				networkPolicyPort(corev1.ProtocolUDP, 111),
+				networkPolicyPort(corev1.ProtocolTCP, 2049),
+				networkPolicyPort(corev1.ProtocolUDP, 2049),
+			},
+			To: ipBlockPeers(egress.NFSCIDRs),
+		})
+	}
+	return rules
+}
+
+func networkPolicyPort(protocol corev1.Protocol, port int32) networkingv1.NetworkPolicyPort {
+	policyPort := intstr.FromInt(int(port))
+	return networkingv1.NetworkPolicyPort{
+		Protocol: &protocol,
+		Port:     &policyPort,
+	}
+}
+
+// ipBlockPeers returns a NetworkPolicy peer for each CIDR. Returns nil if no CIDRs are provided,
+// which allows traffic from or to all peers.
+func ipBlockPeers(cidrs []string) []networkingv1.NetworkPolicyPeer {
+	if len(cidrs) == 0 {
+		return nil
+	}
+	peers := []networkingv1.NetworkPolicyPeer{}
+	for _, cidr := range cidrs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{
+				CIDR: cidr,
+			},
+		})
+	}
+	return peers
+}
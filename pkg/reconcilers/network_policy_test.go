@@ -0,0 +1,404 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/suite"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+type networkPolicyTestCase struct {
+	name           string
+	plex           *v1alpha1.PlexMediaServer
+	existingPolicy *networkingv1.NetworkPolicy
+	// operatorNamespace is the namespace the operator runs in
+	operatorNamespace string
+	expectedPolicy    *networkingv1.NetworkPolicy
+	expectError       bool
+	expectRequeue     bool
+}
+
+type networkPolicyReconcileSuite struct {
+	suite.Suite
+	cases []networkPolicyTestCase
+}
+
+func (test *networkPolicyReconcileSuite) SetupTest() {
+	test.cases = []networkPolicyTestCase{
+		{
+			name: "none with no existing policy",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+			},
+		},
+		{
+			name: "none with existing policy",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none-existing",
+				},
+			},
+			existingPolicy: networkPolicyDouble("none", "none-existing", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with allowed CIDRs and namespaces",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{
+							AllowedCIDRs: []string{"192.168.1.0/24"},
+							AllowedNamespaces: &metav1.LabelSelector{
+								MatchLabels: map[string]string{
+									"kubernetes.io/metadata.name": "ingress-nginx",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicy: networkPolicyDouble("create", "create", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						IPBlock: &networkingv1.IPBlock{
+							CIDR: "192.168.1.0/24",
+						},
+					},
+					{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "ingress-nginx",
+							},
+						},
+					},
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with egress",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-egress",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{
+							Egress: &v1alpha1.PlexNetworkPolicyEgress{
+								PlexTVCIDRs: []string{"203.0.113.0/24"},
+								NFSCIDRs:    []string{"10.0.0.10/32"},
+							},
+						},
+					},
+				},
+			},
+			expectedPolicy: networkPolicyDouble("create", "create-egress", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{
+						Ports: []networkingv1.NetworkPolicyPort{
+							networkPolicyPort(corev1.ProtocolUDP, 53),
+							networkPolicyPort(corev1.ProtocolTCP, 53),
+						},
+					},
+					{
+						Ports: []networkingv1.NetworkPolicyPort{
+							networkPolicyPort(corev1.ProtocolTCP, 443),
+						},
+						To: []networkingv1.NetworkPolicyPeer{
+							{
+								IPBlock: &networkingv1.IPBlock{
+									CIDR: "203.0.113.0/24",
+								},
+							},
+						},
+					},
+					{
+						Ports: []networkingv1.NetworkPolicyPort{
+							networkPolicyPort(corev1.ProtocolTCP, 111),
+							networkPolicyPort(corev1.ProtocolUDP, 111),
+							networkPolicyPort(corev1.ProtocolTCP, 2049),
+							networkPolicyPort(corev1.ProtocolUDP, 2049),
+						},
+						To: []networkingv1.NetworkPolicyPeer{
+							{
+								IPBlock: &networkingv1.IPBlock{
+									CIDR: "10.0.0.10/32",
+								},
+							},
+						},
+					},
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create with egress and no plex.tv CIDRs",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-egress-no-plex-tv",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{
+							Egress: &v1alpha1.PlexNetworkPolicyEgress{},
+						},
+					},
+				},
+			},
+			expectedPolicy: networkPolicyDouble("create", "create-egress-no-plex-tv", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{
+						Ports: []networkingv1.NetworkPolicyPort{
+							networkPolicyPort(corev1.ProtocolUDP, 53),
+							networkPolicyPort(corev1.ProtocolTCP, 53),
+						},
+					},
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create allows the operator namespace",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-operator",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{
+							AllowedCIDRs: []string{"192.168.1.0/24"},
+						},
+					},
+				},
+			},
+			operatorNamespace: "plex-operator",
+			expectedPolicy: networkPolicyDouble("create", "create-operator", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+				From: []networkingv1.NetworkPolicyPeer{
+					{
+						IPBlock: &networkingv1.IPBlock{
+							CIDR: "192.168.1.0/24",
+						},
+					},
+					{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{
+								"kubernetes.io/metadata.name": "plex-operator",
+							},
+						},
+					},
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "create does not restrict all sources to the operator namespace",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create-unrestricted",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{},
+					},
+				},
+			},
+			operatorNamespace: "plex-operator",
+			expectedPolicy: networkPolicyDouble("create", "create-unrestricted", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update opens enabled ports",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						EnableDiscovery: true,
+						EnableDLNA:      true,
+						EnableRoku:      true,
+						NetworkPolicy:   &v1alpha1.PlexNetworkPolicySpec{},
+					},
+				},
+			},
+			existingPolicy: networkPolicyDouble("update", "update", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+			}),
+			expectedPolicy: networkPolicyDouble("update", "update", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolUDP, 1900),
+					networkPolicyPort(corev1.ProtocolTCP, 8324),
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+					networkPolicyPort(corev1.ProtocolUDP, 32410),
+					networkPolicyPort(corev1.ProtocolUDP, 32412),
+					networkPolicyPort(corev1.ProtocolUDP, 32413),
+					networkPolicyPort(corev1.ProtocolUDP, 32414),
+					networkPolicyPort(corev1.ProtocolTCP, 32469),
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "no change",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "no-change",
+					Name:      "no-change",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						NetworkPolicy: &v1alpha1.PlexNetworkPolicySpec{},
+					},
+				},
+			},
+			existingPolicy: networkPolicyDouble("no-change", "no-change", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+			}),
+			expectedPolicy: networkPolicyDouble("no-change", "no-change", networkPolicyDoubleOptions{
+				Ports: []networkingv1.NetworkPolicyPort{
+					networkPolicyPort(corev1.ProtocolTCP, 32400),
+				},
+			}),
+		},
+	}
+}
+
+func (test *networkPolicyReconcileSuite) TestNetworkPolicyReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			if tc.existingPolicy != nil {
+				builder.WithObjects(tc.existingPolicy)
+			}
+			client := builder.Build()
+			reconciler := &NetworkPolicyReconciler{
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
+				Platform: Platform{OperatorNamespace: tc.operatorNamespace},
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			updatedPolicy := &networkingv1.NetworkPolicy{}
+			err = client.Get(ctx, types.NamespacedName{Namespace: tc.plex.Namespace, Name: tc.plex.Name}, updatedPolicy)
+			if tc.expectedPolicy == nil {
+				test.True(errors.IsNotFound(err), "expected network policy to not exist")
+				return
+			}
+			test.Require().NoError(err, "failed to get NetworkPolicy")
+			test.True(equality.Semantic.DeepEqual(tc.expectedPolicy.Spec, updatedPolicy.Spec),
+				"expected network policy does not match - diff: %s",
+				cmp.Diff(tc.expectedPolicy.Spec, updatedPolicy.Spec))
+		})
+	}
+}
+
+type networkPolicyDoubleOptions struct {
+	Ports  []networkingv1.NetworkPolicyPort
+	From   []networkingv1.NetworkPolicyPeer
+	Egress []networkingv1.NetworkPolicyEgressRule
+}
+
+func networkPolicyDouble(namespace, name string, options networkPolicyDoubleOptions) *networkingv1.NetworkPolicy {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    plexLabelsDouble(name),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"plex.adambkaplan.com/instance": name,
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					Ports: options.Ports,
+					From:  options.From,
+				},
+			},
+		},
+	}
+	if len(options.Egress) > 0 {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		policy.Spec.Egress = options.Egress
+	}
+	return policy
+}
+
+func TestNetworkPolicySuite(t *testing.T) {
+	suite.Run(t, new(networkPolicyReconcileSuite))
+}
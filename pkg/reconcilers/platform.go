@@ -0,0 +1,35 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/discovery"
+)
+
+// Platform describes the optional platform capabilities detected on the cluster
+type Platform struct {
+	// OpenShift is true if the route.openshift.io API is installed on the cluster
+	OpenShift bool
+
+	// OperatorNamespace is the namespace the operator runs in, if known. Plex's NetworkPolicy
+	// allows traffic from this namespace so that the operator can reach Plex's HTTP API.
+	OperatorNamespace string
+}
+
+// DetectPlatform detects the platform capabilities of the cluster through API discovery
+func DetectPlatform(client discovery.DiscoveryInterface) (Platform, error) {
+	platform := Platform{}
+	_, err := client.ServerResourcesForGroupVersion(RouteGVK.GroupVersion().String())
+	if errors.IsNotFound(err) {
+		return platform, nil
+	}
+	if err != nil {
+		return platform, err
+	}
+	platform.OpenShift = true
+	return platform, nil
+}
@@ -0,0 +1,82 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// notFoundDiscovery returns a NotFound error for missing group versions, like the API server does
+type notFoundDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d *notFoundDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	for _, resourceList := range d.Resources {
+		if resourceList.GroupVersion == groupVersion {
+			return resourceList, nil
+		}
+	}
+	return nil, errors.NewNotFound(schema.GroupResource{}, "")
+}
+
+func TestDetectPlatform(t *testing.T) {
+	cases := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		expected  Platform
+	}{
+		{
+			name: "kubernetes",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+				},
+			},
+			expected: Platform{},
+		},
+		{
+			name: "openshift",
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "apps/v1",
+				},
+				{
+					GroupVersion: "route.openshift.io/v1",
+					APIResources: []metav1.APIResource{
+						{
+							Name: "routes",
+							Kind: "Route",
+						},
+					},
+				},
+			},
+			expected: Platform{OpenShift: true},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &notFoundDiscovery{
+				FakeDiscovery: &fakediscovery.FakeDiscovery{
+					Fake: &k8stesting.Fake{
+						Resources: tc.resources,
+					},
+				},
+			}
+			platform, err := DetectPlatform(client)
+			assert.NoError(t, err, "unexpected error detecting platform")
+			assert.Equal(t, tc.expected, platform, "detected platform should be equal")
+		})
+	}
+}
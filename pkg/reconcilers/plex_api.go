@@ -0,0 +1,33 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+)
+
+// newPlexAPIClient returns a client for the PlexMediaServer's HTTP API, reached through the
+// headless Service. Requests are authenticated with the referenced X-Plex-Token, if any. Tests
+// replace it to use a fake Plex server.
+var newPlexAPIClient = func(ctx context.Context, c client.Reader, plex *v1alpha1.PlexMediaServer) (*plexapi.Client, error) {
+	token, err := plexapi.TokenFromSecret(ctx, c, plex)
+	if err != nil && err != plexapi.ErrNoToken {
+		return nil, err
+	}
+	port := findPlexPort(plex, "plex")
+	return plexapi.NewClient(plexapi.ServerURL(plex, port.targetPort), token, nil)
+}
+
+// plexAppURL returns the link that opens the server in the Plex web app
+func plexAppURL(machineIdentifier string) string {
+	return fmt.Sprintf("https://app.plex.tv/desktop/#!/media/%s/com.plexapp.plugins.library", machineIdentifier)
+}
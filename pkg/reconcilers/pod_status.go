@@ -0,0 +1,106 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// degradedPodReasons are the pod reasons that Plex does not recover from without intervention
+var degradedPodReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+	"Unschedulable":              true,
+}
+
+// plexPodStatus summarizes the state of Plex's pod. The reason explains why the pod is not
+// running: the scheduler's reason if the pod cannot be scheduled, otherwise the first waiting
+// reason or failed termination of an init container or container. The scheduler reports the
+// same reason and message on the pod's PodScheduled condition as on its FailedScheduling events.
+// The last termination is the most recent termination of any container, including a container
+// that terminated while the pod is being deleted.
+func plexPodStatus(pod *corev1.Pod) *v1alpha1.PlexPodStatus {
+	status := &v1alpha1.PlexPodStatus{
+		Name:  pod.Name,
+		Phase: string(pod.Status.Phase),
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			status.Reason = condition.Reason
+			status.Message = condition.Message
+		}
+	}
+	containers := []corev1.ContainerStatus{}
+	containers = append(containers, pod.Status.InitContainerStatuses...)
+	containers = append(containers, pod.Status.ContainerStatuses...)
+	for i, container := range containers {
+		status.RestartCount += container.RestartCount
+		terminations := []*corev1.ContainerStateTerminated{container.LastTerminationState.Terminated}
+		if i >= len(pod.Status.InitContainerStatuses) {
+			// Init containers always terminate, but a container that is terminated has stopped,
+			// for example while the pod is deleted
+			terminations = append(terminations, container.State.Terminated)
+		}
+		for _, terminated := range terminations {
+			if terminated == nil {
+				continue
+			}
+			if status.LastTermination == nil || terminated.FinishedAt.After(status.LastTermination.FinishedAt.Time) {
+				status.LastTermination = &v1alpha1.PlexContainerTermination{
+					Container:  container.Name,
+					ExitCode:   terminated.ExitCode,
+					Reason:     terminated.Reason,
+					Message:    terminated.Message,
+					FinishedAt: terminated.FinishedAt,
+				}
+			}
+		}
+		if status.Reason != "" {
+			continue
+		}
+		if waiting := container.State.Waiting; waiting != nil && waiting.Reason != "" &&
+			waiting.Reason != "PodInitializing" && waiting.Reason != "ContainerCreating" {
+			status.Reason = waiting.Reason
+			status.Message = fmt.Sprintf("container %s is waiting: %s", container.Name, waiting.Message)
+			if waiting.Message == "" {
+				status.Message = fmt.Sprintf("container %s is waiting", container.Name)
+			}
+			continue
+		}
+		if terminated := container.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			status.Reason = terminated.Reason
+			status.Message = fmt.Sprintf("container %s exited with code %d", container.Name, terminated.ExitCode)
+		}
+	}
+	return status
+}
+
+// podFailureMessage describes why the pod failed, including the most recent container
+// termination if the pod is crashing
+func podFailureMessage(status *v1alpha1.PlexPodStatus) string {
+	message := fmt.Sprintf("Plex media server pod %s is failing with %s: %s", status.Name, status.Reason, status.Message)
+	if status.Reason != "CrashLoopBackOff" || status.LastTermination == nil {
+		return message
+	}
+	termination := status.LastTermination
+	message = fmt.Sprintf("%s; container %s last exited with code %d", message, termination.Container, termination.ExitCode)
+	if termination.Reason != "" {
+		message = fmt.Sprintf("%s (%s)", message, termination.Reason)
+	}
+	if termination.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, termination.Message)
+	}
+	return message
+}
@@ -0,0 +1,220 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestPlexPodStatus(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	later := metav1.NewTime(time.Date(2021, 1, 1, 0, 5, 0, 0, time.UTC))
+	cases := []struct {
+		name     string
+		status   corev1.PodStatus
+		expected *v1alpha1.PlexPodStatus
+	}{
+		{
+			name: "running",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:  "plex",
+						State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:  "plex-0",
+				Phase: "Running",
+			},
+		},
+		{
+			name: "creating",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:  "plex",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:  "plex-0",
+				Phase: "Pending",
+			},
+		},
+		{
+			name: "unschedulable",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				Conditions: []corev1.PodCondition{
+					{
+						Type:    corev1.PodScheduled,
+						Status:  corev1.ConditionFalse,
+						Reason:  "Unschedulable",
+						Message: "0/3 nodes are available: 3 Insufficient memory.",
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:    "plex-0",
+				Phase:   "Pending",
+				Reason:  "Unschedulable",
+				Message: "0/3 nodes are available: 3 Insufficient memory.",
+			},
+		},
+		{
+			name: "crash loop",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "preferences",
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 0,
+							Reason:   "Completed",
+						}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   1,
+							Reason:     "Error",
+							FinishedAt: earlier,
+						}},
+						RestartCount: 1,
+					},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "plex",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "back-off 5m0s restarting failed container",
+						}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   137,
+							Reason:     "OOMKilled",
+							Message:    "out of memory",
+							FinishedAt: later,
+						}},
+						RestartCount: 5,
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:         "plex-0",
+				Phase:        "Running",
+				Reason:       "CrashLoopBackOff",
+				Message:      "container plex is waiting: back-off 5m0s restarting failed container",
+				RestartCount: 6,
+				LastTermination: &v1alpha1.PlexContainerTermination{
+					Container:  "plex",
+					ExitCode:   137,
+					Reason:     "OOMKilled",
+					Message:    "out of memory",
+					FinishedAt: later,
+				},
+			},
+		},
+		{
+			name: "terminated while deleted",
+			status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "plex",
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode:   137,
+							Reason:     "Error",
+							FinishedAt: later,
+						}},
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:    "plex-0",
+				Phase:   "Running",
+				Reason:  "Error",
+				Message: "container plex exited with code 137",
+				LastTermination: &v1alpha1.PlexContainerTermination{
+					Container:  "plex",
+					ExitCode:   137,
+					Reason:     "Error",
+					FinishedAt: later,
+				},
+			},
+		},
+		{
+			name: "init container failed",
+			status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "preferences",
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							ExitCode: 2,
+							Reason:   "Error",
+						}},
+					},
+				},
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:  "plex",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}},
+					},
+				},
+			},
+			expected: &v1alpha1.PlexPodStatus{
+				Name:    "plex-0",
+				Phase:   "Pending",
+				Reason:  "Error",
+				Message: "container preferences exited with code 2",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex-0",
+				},
+				Status: tc.status,
+			}
+			assert.Equal(t, tc.expected, plexPodStatus(pod), "pod status should be equal")
+		})
+	}
+}
+
+func TestPodFailureMessage(t *testing.T) {
+	status := &v1alpha1.PlexPodStatus{
+		Name:    "plex-0",
+		Reason:  "CrashLoopBackOff",
+		Message: "container plex is waiting: back-off 5m0s restarting failed container",
+		LastTermination: &v1alpha1.PlexContainerTermination{
+			Container: "plex",
+			ExitCode:  137,
+			Reason:    "OOMKilled",
+		},
+	}
+	assert.Equal(t, "Plex media server pod plex-0 is failing with CrashLoopBackOff: container plex is waiting: "+
+		"back-off 5m0s restarting failed container; container plex last exited with code 137 (OOMKilled)",
+		podFailureMessage(status), "failure message should be equal")
+
+	status.Reason = "ImagePullBackOff"
+	status.Message = "container plex is waiting: Back-off pulling image"
+	assert.Equal(t, "Plex media server pod plex-0 is failing with ImagePullBackOff: container plex is waiting: Back-off pulling image",
+		podFailureMessage(status), "failure message should be equal")
+}
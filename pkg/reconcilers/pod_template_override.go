@@ -0,0 +1,226 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// podTemplateOverrideAnnotation records the pod template override that was last merged into
+	// Plex's pod template, so that fields removed from the override are removed from the template
+	podTemplateOverrideAnnotation = "plex.adambkaplan.com/pod-template-override"
+)
+
+// podTemplateOverride returns the PlexMediaServer's pod template override as JSON, or an empty
+// string if there is no override
+func podTemplateOverride(plex *v1alpha1.PlexMediaServer) string {
+	if plex.Spec.PodTemplateOverride == nil || len(plex.Spec.PodTemplateOverride.Raw) == 0 {
+		return ""
+	}
+	override := &bytes.Buffer{}
+	if err := json.Compact(override, plex.Spec.PodTemplateOverride.Raw); err != nil {
+		return string(plex.Spec.PodTemplateOverride.Raw)
+	}
+	if override.String() == "{}" || override.String() == "null" {
+		return ""
+	}
+	return override.String()
+}
+
+// renderPodTemplateOverride merges the PlexMediaServer's pod template override on top of the
+// rendered pod template. lastApplied is the override that was merged into the existing template.
+// If the merged template is not valid, the last applied override is merged instead.
+func (r *StatefulSetReconciler) renderPodTemplateOverride(plex *v1alpha1.PlexMediaServer, template corev1.PodTemplateSpec, lastApplied string, claims []corev1.PersistentVolumeClaim) corev1.PodTemplateSpec {
+	override := podTemplateOverride(plex)
+	if override == "" && lastApplied == "" {
+		return template
+	}
+	base := r.renderPodTemplate(plex, corev1.PodTemplateSpec{})
+	merged, err := applyPodTemplateOverride(plex, base, template, lastApplied, override, claims)
+	if err == nil {
+		return merged
+	}
+	r.Log.Info(fmt.Sprintf("pod template override is not valid, keeping the last applied override: %v", err))
+	if lastApplied == "" {
+		return template
+	}
+	merged, err = applyPodTemplateOverride(plex, base, template, lastApplied, lastApplied, claims)
+	if err != nil {
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		template.Annotations[podTemplateOverrideAnnotation] = lastApplied
+		return template
+	}
+	return merged
+}
+
+// applyPodTemplateOverride merges the override on top of the current pod template with a three-way
+// strategic merge, like kubectl apply: fields set in the override replace those in the template,
+// and fields that were in the last applied override but are no longer in the override are removed.
+// Both overrides are merged on top of the base template the operator renders, so that removing a
+// field from the override only removes what the override added. The merged template is then
+// validated.
+func applyPodTemplateOverride(plex *v1alpha1.PlexMediaServer, base corev1.PodTemplateSpec, current corev1.PodTemplateSpec, lastApplied string, override string, claims []corev1.PersistentVolumeClaim) (corev1.PodTemplateSpec, error) {
+	merged := corev1.PodTemplateSpec{}
+	schema, err := strategicpatch.NewPatchMetaFromStruct(current)
+	if err != nil {
+		return merged, err
+	}
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return merged, err
+	}
+	original, err := mergePodTemplatePatch(baseJSON, lastApplied, schema)
+	if err != nil {
+		return merged, err
+	}
+	modified, err := mergePodTemplatePatch(baseJSON, override, schema)
+	if err != nil {
+		return merged, err
+	}
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return merged, err
+	}
+	patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, currentJSON, schema, true)
+	if err != nil {
+		return merged, err
+	}
+	merged, err = decodePodTemplate(currentJSON, string(patch), schema)
+	if err != nil {
+		return merged, err
+	}
+	if err := validatePodTemplate(plex, merged, claims); err != nil {
+		return merged, err
+	}
+	if override == "" {
+		delete(merged.Annotations, podTemplateOverrideAnnotation)
+		if len(merged.Annotations) == 0 {
+			merged.Annotations = nil
+		}
+		return merged, nil
+	}
+	if merged.Annotations == nil {
+		merged.Annotations = map[string]string{}
+	}
+	merged.Annotations[podTemplateOverrideAnnotation] = override
+	return merged, nil
+}
+
+// podTemplateOverrideError returns why the PlexMediaServer's pod template override cannot be merged
+// into the StatefulSet's pod template, or nil if the override is valid
+func podTemplateOverrideError(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) error {
+	schema, err := strategicpatch.NewPatchMetaFromStruct(statefulSet.Spec.Template)
+	if err != nil {
+		return err
+	}
+	current, err := json.Marshal(statefulSet.Spec.Template)
+	if err != nil {
+		return err
+	}
+	merged, err := decodePodTemplate(current, podTemplateOverride(plex), schema)
+	if err != nil {
+		return err
+	}
+	return validatePodTemplate(plex, merged, statefulSet.Spec.VolumeClaimTemplates)
+}
+
+// mergePodTemplatePatch strategically merges a patch on top of a pod template
+func mergePodTemplatePatch(template []byte, patch string, schema strategicpatch.LookupPatchMeta) ([]byte, error) {
+	if patch == "" {
+		return template, nil
+	}
+	return strategicpatch.StrategicMergePatchUsingLookupPatchMeta(template, []byte(patch), schema)
+}
+
+// decodePodTemplate strategically merges a patch on top of a pod template, and decodes the result.
+// Fields that are not part of a pod template are rejected.
+func decodePodTemplate(template []byte, patch string, schema strategicpatch.LookupPatchMeta) (corev1.PodTemplateSpec, error) {
+	decoded := corev1.PodTemplateSpec{}
+	merged, err := mergePodTemplatePatch(template, patch, schema)
+	if err != nil {
+		return decoded, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&decoded)
+	return decoded, err
+}
+
+// validatePodTemplate checks that a pod template with an override keeps the fields the operator
+// owns, and catches mistakes the API server would reject
+func validatePodTemplate(plex *v1alpha1.PlexMediaServer, template corev1.PodTemplateSpec, claims []corev1.PersistentVolumeClaim) error {
+	problems := []string{}
+	if template.Labels["plex.adambkaplan.com/instance"] != plex.Name {
+		problems = append(problems, "the plex.adambkaplan.com/instance label selects Plex's pod and cannot be changed")
+	}
+
+	volumes := map[string]bool{}
+	for _, volume := range template.Spec.Volumes {
+		if volume.Name == "" {
+			problems = append(problems, "volumes must have a name")
+			continue
+		}
+		if volumes[volume.Name] {
+			problems = append(problems, fmt.Sprintf("volume %s is defined more than once", volume.Name))
+		}
+		volumes[volume.Name] = true
+	}
+	for _, claim := range claims {
+		volumes[claim.Name] = true
+	}
+
+	containers := map[string]bool{}
+	foundPlex := false
+	allContainers := append([]corev1.Container{}, template.Spec.InitContainers...)
+	allContainers = append(allContainers, template.Spec.Containers...)
+	for _, container := range allContainers {
+		if container.Name == "" {
+			problems = append(problems, "containers must have a name")
+			continue
+		}
+		if containers[container.Name] {
+			problems = append(problems, fmt.Sprintf("container %s is defined more than once", container.Name))
+		}
+		containers[container.Name] = true
+		if container.Image == "" {
+			problems = append(problems, fmt.Sprintf("container %s must have an image", container.Name))
+		}
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				problems = append(problems, fmt.Sprintf("container %s mounts volume %s, which does not exist", container.Name, mount.Name))
+			}
+		}
+	}
+	for _, container := range template.Spec.Containers {
+		if container.Name != "plex" {
+			continue
+		}
+		foundPlex = true
+		if container.Image != plexImage(plex) {
+			problems = append(problems, "the image of the plex container cannot be changed, set spec.version instead")
+		}
+	}
+	if !foundPlex {
+		problems = append(problems, "the plex container cannot be removed")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
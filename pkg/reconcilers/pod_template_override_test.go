@@ -0,0 +1,151 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+func TestRenderPodTemplateOverride(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+	}
+	reconciler := &StatefulSetReconciler{Log: logr.Discard()}
+
+	plex.Spec.PodTemplateOverride = &runtime.RawExtension{Raw: []byte(`{
+		"metadata": {"labels": {"team": "media"}},
+		"spec": {
+			"runtimeClassName": "gvisor",
+			"hostAliases": [{"ip": "10.0.0.5", "hostnames": ["nas.local"]}],
+			"containers": [
+				{"name": "plex", "resources": {"limits": {"memory": "4Gi"}}},
+				{"name": "exporter", "image": "exporter:latest", "volumeMounts": [{"name": "config", "mountPath": "/config"}]}
+			]
+		}
+	}`)}
+	spec := reconciler.renderStatefulSetSpec(plex, appsv1.StatefulSetSpec{})
+	template := spec.Template
+	assert.Equal(t, "media", template.Labels["team"], "override label should be merged")
+	assert.Equal(t, plex.Name, template.Labels["plex.adambkaplan.com/instance"], "selector label should be kept")
+	require.NotNil(t, template.Spec.RuntimeClassName, "runtime class should be set")
+	assert.Equal(t, "gvisor", *template.Spec.RuntimeClassName, "runtime class should be equal")
+	assert.Len(t, template.Spec.HostAliases, 1, "host aliases should be merged")
+	plexContainer := findContainer(template.Spec.Containers, "plex")
+	require.NotNil(t, plexContainer, "plex container should be kept")
+	assert.Equal(t, plexImage(plex), plexContainer.Image, "plex image should be kept")
+	assert.NotEmpty(t, plexContainer.Ports, "rendered plex ports should be kept")
+	assert.Equal(t, "4Gi", plexContainer.Resources.Limits.Memory().String(), "plex resources should be merged")
+	assert.NotNil(t, findContainer(template.Spec.Containers, "exporter"), "sidecar should be added")
+	assert.Contains(t, template.Annotations, podTemplateOverrideAnnotation, "override should be recorded")
+
+	// Render again on top of the existing StatefulSet, which should be stable
+	again := reconciler.renderStatefulSetSpec(plex, *spec.DeepCopy())
+	assert.Equal(t, spec, again, "rendering the override twice should not change the StatefulSet")
+
+	// An invalid override keeps the last applied override
+	plex.Spec.PodTemplateOverride = &runtime.RawExtension{Raw: []byte(`{
+		"spec": {"containers": [{"name": "plex", "image": "plex:custom"}]}
+	}`)}
+	invalid := reconciler.renderStatefulSetSpec(plex, *spec.DeepCopy())
+	assert.Equal(t, spec.Template, invalid.Template, "invalid override should keep the last applied override")
+
+	// Removing the override removes the fields it added
+	plex.Spec.PodTemplateOverride = nil
+	removed := reconciler.renderStatefulSetSpec(plex, *spec.DeepCopy())
+	template = removed.Template
+	assert.NotContains(t, template.Labels, "team", "override label should be removed")
+	assert.Nil(t, template.Spec.RuntimeClassName, "runtime class should be removed")
+	assert.Empty(t, template.Spec.HostAliases, "host aliases should be removed")
+	assert.Nil(t, findContainer(template.Spec.Containers, "exporter"), "sidecar should be removed")
+	plexContainer = findContainer(template.Spec.Containers, "plex")
+	require.NotNil(t, plexContainer, "plex container should be kept")
+	assert.Empty(t, plexContainer.Resources.Limits, "plex resources should be removed")
+	assert.NotContains(t, template.Annotations, podTemplateOverrideAnnotation, "override should no longer be recorded")
+}
+
+func TestPodTemplateOverrideError(t *testing.T) {
+	cases := []struct {
+		name          string
+		override      string
+		expectedError string
+	}{
+		{
+			name:     "valid",
+			override: `{"spec": {"dnsConfig": {"nameservers": ["10.0.0.1"]}}}`,
+		},
+		{
+			name:          "selector label",
+			override:      `{"metadata": {"labels": {"plex.adambkaplan.com/instance": "other"}}}`,
+			expectedError: "the plex.adambkaplan.com/instance label selects Plex's pod and cannot be changed",
+		},
+		{
+			name:          "plex image",
+			override:      `{"spec": {"containers": [{"name": "plex", "image": "plex:custom"}]}}`,
+			expectedError: "the image of the plex container cannot be changed, set spec.version instead",
+		},
+		{
+			name:          "sidecar without image",
+			override:      `{"spec": {"containers": [{"name": "sidecar"}]}}`,
+			expectedError: "container sidecar must have an image",
+		},
+		{
+			name:          "missing volume",
+			override:      `{"spec": {"containers": [{"name": "sidecar", "image": "sidecar", "volumeMounts": [{"name": "cache", "mountPath": "/cache"}]}]}}`,
+			expectedError: "container sidecar mounts volume cache, which does not exist",
+		},
+		{
+			name:          "unknown field",
+			override:      `{"spec": {"hostAlias": []}}`,
+			expectedError: `json: unknown field "hostAlias"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+			}
+			reconciler := &StatefulSetReconciler{Log: logr.Discard()}
+			statefulSet := &appsv1.StatefulSet{
+				Spec: reconciler.renderStatefulSetSpec(plex, appsv1.StatefulSetSpec{}),
+			}
+			plex.Spec.PodTemplateOverride = &runtime.RawExtension{Raw: []byte(tc.override)}
+			err := podTemplateOverrideError(plex, statefulSet)
+			if tc.expectedError == "" {
+				assert.NoError(t, err, "override should be valid")
+				return
+			}
+			if assert.Error(t, err, "override should not be valid") {
+				assert.Equal(t, tc.expectedError, err.Error(), "error should be equal")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// plexPort describes a port that Plex Media Server listens on
+type plexPort struct {
+	name     string
+	protocol corev1.Protocol
+	// port is the port exposed by Plex's Services
+	port int32
+	// targetPort is the port Plex listens on in its container, which cannot be changed
+	targetPort int32
+	// nodePort is the requested node port for the external Service. Zero if the node port is
+	// allocated by Kubernetes.
+	nodePort int32
+	// external is true if the port is exposed on the external Service
+	external bool
+	// enabled is true if the port should be opened for the PlexMediaServer
+	enabled bool
+}
+
+// plexPorts returns all ports Plex Media Server can listen on, in the order they are exposed.
+// This is the port table for Plex's Services, its container, and any object that must allow
+// traffic to Plex. Port numbers overridden on the PlexMediaServer are applied to the table.
+func plexPorts(plex *v1alpha1.PlexMediaServer) []plexPort {
+	networking := plex.Spec.Networking
+	ports := []plexPort{
+		{
+			name:     "dlna-udp",
+			port:     1900,
+			protocol: corev1.ProtocolUDP,
+			enabled:  networking.EnableDLNA,
+		},
+		{
+			name:     "roku",
+			port:     8324,
+			protocol: corev1.ProtocolTCP,
+			external: true,
+			enabled:  networking.EnableRoku,
+		},
+		{
+			name:     "plex",
+			port:     32400,
+			protocol: corev1.ProtocolTCP,
+			external: true,
+			enabled:  true,
+		},
+		{
+			name:     "discovery-0",
+			port:     32410,
+			protocol: corev1.ProtocolUDP,
+			enabled:  networking.EnableDiscovery,
+		},
+		{
+			name:     "discovery-1",
+			port:     32412,
+			protocol: corev1.ProtocolUDP,
+			enabled:  networking.EnableDiscovery,
+		},
+		{
+			name:     "discovery-2",
+			port:     32413,
+			protocol: corev1.ProtocolUDP,
+			enabled:  networking.EnableDiscovery,
+		},
+		{
+			name:     "discovery-3",
+			port:     32414,
+			protocol: corev1.ProtocolUDP,
+			enabled:  networking.EnableDiscovery,
+		},
+		{
+			name:     "dlna-tcp",
+			port:     32469,
+			protocol: corev1.ProtocolTCP,
+			enabled:  networking.EnableDLNA,
+		},
+	}
+	for i := range ports {
+		port := &ports[i]
+		port.targetPort = port.port
+		for _, override := range networking.Ports {
+			if override.Name != port.name {
+				continue
+			}
+			if override.Port > 0 {
+				port.port = override.Port
+			}
+			port.nodePort = override.NodePort
+		}
+	}
+	return ports
+}
+
+// findPlexPort returns the port in Plex's port table with the given name
+func findPlexPort(plex *v1alpha1.PlexMediaServer, name string) plexPort {
+	for _, port := range plexPorts(plex) {
+		if port.name == name {
+			return port
+		}
+	}
+	return plexPort{}
+}
+
+// isPlexPortName returns true if the port name is in Plex's port table
+func isPlexPortName(plex *v1alpha1.PlexMediaServer, name string) bool {
+	return findPlexPort(plex, name).name != ""
+}
+
+// renderPlexServicePort renders a port from Plex's port table on top of the existing Service
+// port. If setNodePort is true, the requested node port is set if one is provided.
+func renderPlexServicePort(port plexPort, existing corev1.ServicePort, setNodePort bool) corev1.ServicePort {
+	existing.Name = port.name
+	existing.Protocol = port.protocol
+	existing.Port = port.port
+	existing.TargetPort = intstr.FromInt(int(port.targetPort))
+	if setNodePort && port.nodePort > 0 {
+		existing.NodePort = port.nodePort
+	}
+	return existing
+}
+
+// portConflicts finds ports on Plex's external Service that collide with another PlexMediaServer.
+// Service ports collide if two external Services share a load balancer IP address. Node ports are
+// not checked, since Kubernetes rejects a Service that requests a node port already in use.
+func portConflicts(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) ([]string, error) {
+	if plex.Spec.Networking.ExternalServiceType == "" {
+		return nil, nil
+	}
+	plexList := &v1alpha1.PlexMediaServerList{}
+	if err := c.List(ctx, plexList); err != nil {
+		return nil, err
+	}
+	ips, err := externalServiceIPs(ctx, c, plex)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := []string{}
+	for i := range plexList.Items {
+		other := &plexList.Items[i]
+		if other.Namespace == plex.Namespace && other.Name == plex.Name {
+			continue
+		}
+		if other.Spec.Networking.ExternalServiceType == "" {
+			continue
+		}
+		otherIPs, err := externalServiceIPs(ctx, c, other)
+		if err != nil {
+			return nil, err
+		}
+		sharedIP := ""
+		for ip := range ips {
+			if otherIPs[ip] {
+				sharedIP = ip
+				break
+			}
+		}
+		if sharedIP == "" {
+			continue
+		}
+		otherName := fmt.Sprintf("%s/%s", other.Namespace, other.Name)
+		for _, port := range externalPlexPorts(plex) {
+			for _, otherPort := range externalPlexPorts(other) {
+				if port.protocol == otherPort.protocol && port.port == otherPort.port {
+					conflicts = append(conflicts, fmt.Sprintf("port %d/%s on %s is also used by %s",
+						port.port, port.protocol, sharedIP, otherName))
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// externalPlexPorts returns the enabled ports exposed on Plex's external Service
+func externalPlexPorts(plex *v1alpha1.PlexMediaServer) []plexPort {
+	ports := []plexPort{}
+	for _, port := range plexPorts(plex) {
+		if port.enabled && port.external {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// externalServiceIPs returns the requested and assigned load balancer IP addresses of Plex's
+// external Service
+func externalServiceIPs(ctx context.Context, c client.Client, plex *v1alpha1.PlexMediaServer) (map[string]bool, error) {
+	ips := map[string]bool{}
+	service := &corev1.Service{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: fmt.Sprintf("%s-ext", plex.Name)}, service)
+	if errors.IsNotFound(err) {
+		return ips, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if service.Spec.LoadBalancerIP != "" {
+		ips[service.Spec.LoadBalancerIP] = true
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips[ingress.IP] = true
+		}
+	}
+	return ips, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestPlexPortOverrides(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				Ports: []v1alpha1.PlexPortSpec{
+					{
+						Name: "plex",
+						Port: 443,
+					},
+					{
+						Name:     "roku",
+						NodePort: 30324,
+					},
+				},
+			},
+		},
+	}
+	plexPort := findPlexPort(plex, "plex")
+	assert.Equal(t, int32(443), plexPort.port, "plex service port should be overridden")
+	assert.Equal(t, int32(32400), plexPort.targetPort, "plex target port should be the default")
+	rokuPort := findPlexPort(plex, "roku")
+	assert.Equal(t, int32(8324), rokuPort.port, "roku service port should be the default")
+	assert.Equal(t, int32(8324), rokuPort.targetPort, "roku target port should be the default")
+	assert.Equal(t, int32(30324), rokuPort.nodePort, "roku node port should be overridden")
+	dlnaPort := findPlexPort(plex, "dlna-tcp")
+	assert.Equal(t, int32(32469), dlnaPort.port, "dlna-tcp service port should be the default")
+	assert.Equal(t, int32(32469), dlnaPort.targetPort, "dlna-tcp target port should be the default")
+}
+
+func TestPortConflicts(t *testing.T) {
+	cases := []struct {
+		name      string
+		plex      *v1alpha1.PlexMediaServer
+		objects   []client.Object
+		conflicts []string
+	}{
+		{
+			name: "no external service",
+			plex: portConflictPlexDouble("media", "plex", "", nil),
+			objects: []client.Object{
+				portConflictPlexDouble("other", "plex", corev1.ServiceTypeLoadBalancer, nil),
+			},
+		},
+		{
+			name: "different load balancer IPs",
+			plex: portConflictPlexDouble("media", "plex", corev1.ServiceTypeLoadBalancer, nil),
+			objects: []client.Object{
+				externalServiceIPDouble("media", "plex", "192.168.1.10"),
+				portConflictPlexDouble("other", "plex", corev1.ServiceTypeLoadBalancer, nil),
+				externalServiceIPDouble("other", "plex", "192.168.1.11"),
+			},
+		},
+		{
+			name: "shared load balancer IP with different ports",
+			plex: portConflictPlexDouble("media", "plex", corev1.ServiceTypeLoadBalancer, nil),
+			objects: []client.Object{
+				externalServiceIPDouble("media", "plex", "192.168.1.10"),
+				portConflictPlexDouble("other", "plex", corev1.ServiceTypeLoadBalancer, []v1alpha1.PlexPortSpec{
+					{
+						Name: "plex",
+						Port: 32401,
+					},
+				}),
+				externalServiceIPDouble("other", "plex", "192.168.1.10"),
+			},
+		},
+		{
+			name: "shared load balancer IP with same ports",
+			plex: portConflictPlexDouble("media", "plex", corev1.ServiceTypeLoadBalancer, nil),
+			objects: []client.Object{
+				externalServiceIPDouble("media", "plex", "192.168.1.10"),
+				portConflictPlexDouble("other", "plex", corev1.ServiceTypeLoadBalancer, nil),
+				externalServiceIPDouble("other", "plex", "192.168.1.10"),
+			},
+			conflicts: []string{
+				"port 32400/TCP on 192.168.1.10 is also used by other/plex",
+			},
+		},
+		{
+			// Kubernetes rejects the second Service that requests the node port
+			name: "same node port",
+			plex: portConflictPlexDouble("media", "plex", corev1.ServiceTypeNodePort, []v1alpha1.PlexPortSpec{
+				{
+					Name:     "plex",
+					NodePort: 32400,
+				},
+			}),
+			objects: []client.Object{
+				portConflictPlexDouble("other", "plex", corev1.ServiceTypeNodePort, []v1alpha1.PlexPortSpec{
+					{
+						Name:     "plex",
+						NodePort: 32400,
+					},
+				}),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := scheme.Scheme
+			err := v1alpha1.AddToScheme(s)
+			require.NoError(t, err, "failed to add scheme")
+			c := fake.NewClientBuilder().
+				WithScheme(s).
+				WithObjects(tc.plex).
+				WithObjects(tc.objects...).
+				Build()
+			conflicts, err := portConflicts(context.TODO(), c, tc.plex)
+			require.NoError(t, err, "unexpected error finding port conflicts")
+			assert.ElementsMatch(t, tc.conflicts, conflicts, "port conflicts should be equal")
+		})
+	}
+}
+
+func portConflictPlexDouble(namespace, name string, serviceType corev1.ServiceType, ports []v1alpha1.PlexPortSpec) *v1alpha1.PlexMediaServer {
+	return &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: serviceType,
+				Ports:               ports,
+			},
+		},
+	}
+}
+
+func externalServiceIPDouble(namespace, plexName, ip string) *corev1.Service {
+	service := serviceDouble(namespace, plexName, serviceDoubleOptions{
+		ServiceName: plexName + "-ext",
+		ServiceType: corev1.ServiceTypeLoadBalancer,
+	})
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{
+		{
+			IP: ip,
+		},
+	}
+	return service
+}
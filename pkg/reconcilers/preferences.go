@@ -0,0 +1,380 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// preferencesHashAnnotation is set on Plex's pod template so that Plex is restarted when its
+	// preferences change
+	preferencesHashAnnotation = "plex.adambkaplan.com/preferences-hash"
+
+	// customConnectionsPreference is the preference listing the URLs Plex advertises to clients
+	customConnectionsPreference = "customConnections"
+
+	// preferencesMountPath is where the preferences ConfigMap is mounted in the init container
+	preferencesMountPath = "/etc/plex-preferences"
+
+	// mergePreferencesScript merges the preferences listed in each file passed to the script into
+	// Plex's Preferences.xml. Each preference is a line of the form key=value, with the value
+	// already escaped for XML. Existing attributes are replaced and new attributes are added to the
+	// Preferences element. The file is rewritten in place to keep its owner and permissions. The
+	// script fails without changing the file unless the Preferences element is self-closing on a
+	// single line, which is how Plex writes it.
+	mergePreferencesScript = `#!/bin/sh
+set -e
+prefs_dir="/config/Library/Application Support/Plex Media Server"
+prefs_file="${prefs_dir}/Preferences.xml"
+mkdir -p "${prefs_dir}"
+if [ ! -s "${prefs_file}" ]; then
+  printf '<?xml version="1.0" encoding="utf-8"?>\n<Preferences/>\n' > "${prefs_file}"
+fi
+for prefs in "$@"; do
+  awk -v prefs="${prefs}" '
+BEGIN {
+  n = 0
+  while ((getline line < prefs) > 0) {
+    i = index(line, "=")
+    if (i == 0) {
+      continue
+    }
+    n++
+    keys[n] = substr(line, 1, i - 1)
+    values[n] = substr(line, i + 1)
+  }
+}
+/<Preferences/ {
+  open = index($0, "<Preferences")
+  if (found || index(substr($0, open), "/>") == 0) {
+    print "Preferences.xml must have one Preferences element that is self-closing on one line" > "/dev/stderr"
+    failed = 1
+    exit 1
+  }
+  found = 1
+  for (k = 1; k <= n; k++) {
+    attr = " " keys[k] "=\""
+    start = index($0, attr)
+    if (start > 0) {
+      rest = substr($0, start + length(attr))
+      $0 = substr($0, 1, start - 1) substr(rest, index(rest, "\"") + 1)
+    }
+    open = index($0, "<Preferences")
+    end = open + index(substr($0, open), "/>") - 1
+    $0 = substr($0, 1, end - 1) attr values[k] "\"" substr($0, end)
+  }
+}
+{ print }
+END {
+  if (failed) {
+    exit 1
+  }
+  if (!found) {
+    print "Preferences.xml does not have a Preferences element" > "/dev/stderr"
+    exit 1
+  }
+}
+' "${prefs_file}" > "${prefs_file}.tmp"
+  cat "${prefs_file}.tmp" > "${prefs_file}"
+done
+rm -f "${prefs_file}.tmp"
+`
+)
+
+// preferenceKeyPattern matches preference names that can be written as XML attributes
+var preferenceKeyPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// plexPreferences returns the preferences that should be set in Plex's Preferences.xml, keyed by
+// attribute name. Preferences are managed if they are set on the PlexMediaServer, Plex has custom
+// connections to advertise, or Plex uses a custom certificate; nil is returned otherwise.
+// Additional preferences with invalid names are skipped, and preferences set by typed fields take
+// precedence over additional preferences.
+func plexPreferences(plex *v1alpha1.PlexMediaServer, lanNetworks []string, customConnections []string) map[string]string {
+	spec := plex.Spec.Preferences
+	if spec == nil && len(customConnections) == 0 && plex.Spec.TLS == nil {
+		return nil
+	}
+	if spec == nil {
+		spec = &v1alpha1.PlexPreferencesSpec{}
+	}
+	prefs := map[string]string{}
+	for key, value := range spec.Additional {
+		if !preferenceKeyPattern.MatchString(key) {
+			continue
+		}
+		prefs[key] = value
+	}
+	if spec.FriendlyName != "" {
+		prefs["FriendlyName"] = spec.FriendlyName
+	}
+	switch spec.SecureConnections {
+	case "Required":
+		prefs["secureConnections"] = "0"
+	case "Preferred":
+		prefs["secureConnections"] = "1"
+	case "Disabled":
+		prefs["secureConnections"] = "2"
+	}
+	if spec.RemoteAccess != nil {
+		prefs["PublishServerOnPlexOnlineKey"] = preferenceBool(*spec.RemoteAccess)
+	}
+	if spec.RelayEnabled != nil {
+		prefs["RelayEnabled"] = preferenceBool(*spec.RelayEnabled)
+	}
+	switch spec.TranscoderQuality {
+	case "Automatic":
+		prefs["TranscoderQuality"] = "0"
+	case "PreferHigherSpeed":
+		prefs["TranscoderQuality"] = "1"
+	case "PreferHigherQuality":
+		prefs["TranscoderQuality"] = "2"
+	case "MakeMyCPUHurt":
+		prefs["TranscoderQuality"] = "3"
+	}
+	if spec.WANTotalMaxUploadRate != nil {
+		prefs["WanTotalMaxUploadRate"] = strconv.Itoa(int(*spec.WANTotalMaxUploadRate))
+	}
+	if spec.WANPerStreamMaxUploadRate != nil {
+		prefs["WanPerStreamMaxUploadRate"] = strconv.Itoa(int(*spec.WANPerStreamMaxUploadRate))
+	}
+	if len(lanNetworks) > 0 {
+		prefs["LanNetworksBandwidth"] = strings.Join(lanNetworks, ",")
+	}
+	if len(customConnections) > 0 {
+		prefs[customConnectionsPreference] = strings.Join(customConnections, ",")
+	}
+	return prefs
+}
+
+// resolvePreferences returns the preferences that should be set in Plex's Preferences.xml, using
+// the LAN networks and custom connections resolved from the cluster.
+func resolvePreferences(ctx context.Context, c client.Client, log logr.Logger, plex *v1alpha1.PlexMediaServer) (map[string]string, error) {
+	lanNetworks, err := resolveLANNetworks(ctx, c, log, plex)
+	if err != nil {
+		return nil, err
+	}
+	customConnections, err := resolveCustomConnections(ctx, c, plex)
+	if err != nil {
+		return nil, err
+	}
+	return plexPreferences(plex, lanNetworks, customConnections), nil
+}
+
+// preferenceBool returns the Preferences.xml value for a boolean preference
+func preferenceBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// renderPreferences renders Plex's preferences as sorted key=value lines, with each value escaped
+// for use in an XML attribute.
+func renderPreferences(prefs map[string]string) string {
+	keys := []string{}
+	for key := range prefs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := &strings.Builder{}
+	for _, key := range keys {
+		value := &bytes.Buffer{}
+		// Writing to a bytes.Buffer does not fail
+		_ = xml.EscapeText(value, []byte(prefs[key]))
+		fmt.Fprintf(lines, "%s=%s\n", key, value.String())
+	}
+	return lines.String()
+}
+
+// restartPreferences returns the preferences that Plex is restarted for when they change. Custom
+// connections follow the addresses of the cluster's nodes and load balancers, so they are set on the
+// running server instead of restarting Plex.
+func restartPreferences(prefs map[string]string) map[string]string {
+	restart := map[string]string{}
+	for key, value := range prefs {
+		if key == customConnectionsPreference {
+			continue
+		}
+		restart[key] = value
+	}
+	return restart
+}
+
+// renderedPreference returns the value of a preference in the rendered preferences
+func renderedPreference(rendered string, key string) string {
+	for _, line := range strings.Split(rendered, "\n") {
+		if strings.HasPrefix(line, key+"=") {
+			return strings.TrimPrefix(line, key+"=")
+		}
+	}
+	return ""
+}
+
+// preferencesHash returns the hash of the rendered preferences
+func preferencesHash(rendered string) string {
+	sum := sha256.Sum256([]byte(rendered))
+	return hex.EncodeToString(sum[:])
+}
+
+// preferencesConfigMapName returns the name of the ConfigMap holding Plex's preferences
+func preferencesConfigMapName(plex *v1alpha1.PlexMediaServer) string {
+	return fmt.Sprintf("%s-preferences", plex.Name)
+}
+
+// PreferencesReconciler reconciles the ConfigMap holding Plex Media Server's preferences
+type PreferencesReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewPreferencesReconciler returns a new Reconciler that reconciles the ConfigMap holding Plex
+// Media Server's preferences
+func NewPreferencesReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *PreferencesReconciler {
+	return &PreferencesReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the preferences ConfigMap with the desired state of the PlexMediaServer
+func (r *PreferencesReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origConfigMap := &corev1.ConfigMap{}
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: preferencesConfigMapName(plex)}
+	log := r.Log.WithValues("configMap", namespacedName)
+	prefs, err := resolvePreferences(ctx, r.Client, log, plex)
+	if err != nil {
+		log.Error(err, "failed to resolve preferences")
+		return true, err
+	}
+	err = r.Client.Get(ctx, namespacedName, origConfigMap)
+
+	if errors.IsNotFound(err) {
+		// Only create if the ConfigMap is not found and preferences are managed
+		if prefs == nil {
+			return false, nil
+		}
+		log.Info("creating")
+		origConfigMap = r.createConfigMap(plex, prefs)
+		err = r.Client.Create(ctx, origConfigMap, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "ConfigMap", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	// If preferences are no longer managed, we no longer need the ConfigMap
+	if prefs == nil {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origConfigMap, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "ConfigMap", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredConfigMap := origConfigMap.DeepCopy()
+	renderObjectMeta(plex, desiredConfigMap)
+	desiredConfigMap.Data = r.renderConfigMapData(prefs, desiredConfigMap.Data)
+	if !equality.Semantic.DeepEqual(origConfigMap.Data, desiredConfigMap.Data) ||
+		objectMetaChanged(origConfigMap, desiredConfigMap) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredConfigMap, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "ConfigMap", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "ConfigMap", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		if renderedPreference(origConfigMap.Data["preferences"], customConnectionsPreference) !=
+			renderedPreference(desiredConfigMap.Data["preferences"], customConnectionsPreference) {
+			r.setCustomConnections(ctx, plex, prefs[customConnectionsPreference])
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// setCustomConnections sets the custom connections on the running server, since Plex is not
+// restarted when they change. Plex reads the updated ConfigMap when it next starts, so failures are
+// only logged.
+func (r *PreferencesReconciler) setCustomConnections(ctx context.Context, plex *v1alpha1.PlexMediaServer, customConnections string) {
+	log := r.Log.WithValues("preference", customConnectionsPreference)
+	plexAPI, err := newPlexAPIClient(ctx, r.Client, plex)
+	if err == nil {
+		err = plexAPI.SetPreferences(ctx, map[string]string{customConnectionsPreference: customConnections})
+	}
+	if err != nil {
+		log.Info("failed to set preference on Plex media server", "error", err.Error())
+		return
+	}
+	log.Info("set preference on Plex media server")
+}
+
+func (r *PreferencesReconciler) createConfigMap(plex *v1alpha1.PlexMediaServer, prefs map[string]string) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: plex.Namespace,
+			Name:      preferencesConfigMapName(plex),
+		},
+	}
+	configMap.Data = r.renderConfigMapData(prefs, configMap.Data)
+	renderObjectMeta(plex, configMap)
+	ctrl.SetControllerReference(plex, configMap, r.Scheme)
+	return configMap
+}
+
+func (r *PreferencesReconciler) renderConfigMapData(prefs map[string]string, existing map[string]string) map[string]string {
+	if existing == nil {
+		existing = map[string]string{}
+	}
+	existing["preferences"] = renderPreferences(prefs)
+	existing["merge-preferences.sh"] = mergePreferencesScript
+	return existing
+}
@@ -0,0 +1,370 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+	fakeplex "github.com/adambkaplan/plex-operator/pkg/plexapi/fake"
+)
+
+func TestPlexPreferences(t *testing.T) {
+	remoteAccess := true
+	relayEnabled := false
+	totalRate := int32(20000)
+	streamRate := int32(0)
+	cases := []struct {
+		name              string
+		preferences       *v1alpha1.PlexPreferencesSpec
+		lanNetworks       []string
+		customConnections []string
+		expected          map[string]string
+	}{
+		{
+			name: "not managed",
+		},
+		{
+			name:        "empty",
+			preferences: &v1alpha1.PlexPreferencesSpec{},
+			expected:    map[string]string{},
+		},
+		{
+			name: "typed preferences",
+			preferences: &v1alpha1.PlexPreferencesSpec{
+				FriendlyName:              "Living Room",
+				SecureConnections:         "Required",
+				RemoteAccess:              &remoteAccess,
+				RelayEnabled:              &relayEnabled,
+				TranscoderQuality:         "MakeMyCPUHurt",
+				WANTotalMaxUploadRate:     &totalRate,
+				WANPerStreamMaxUploadRate: &streamRate,
+			},
+			lanNetworks: []string{"192.168.1.0/24", "10.0.0.0/8"},
+			expected: map[string]string{
+				"FriendlyName":                 "Living Room",
+				"secureConnections":            "0",
+				"PublishServerOnPlexOnlineKey": "1",
+				"RelayEnabled":                 "0",
+				"TranscoderQuality":            "3",
+				"WanTotalMaxUploadRate":        "20000",
+				"WanPerStreamMaxUploadRate":    "0",
+				"LanNetworksBandwidth":         "192.168.1.0/24,10.0.0.0/8",
+			},
+		},
+		{
+			name:              "custom connections only",
+			customConnections: []string{"https://plex.example.com:443", "http://203.0.113.10:32400"},
+			expected: map[string]string{
+				"customConnections": "https://plex.example.com:443,http://203.0.113.10:32400",
+			},
+		},
+		{
+			name: "additional preferences",
+			preferences: &v1alpha1.PlexPreferencesSpec{
+				FriendlyName: "Living Room",
+				Additional: map[string]string{
+					"FriendlyName":      "Overridden",
+					"DlnaEnabled":       "1",
+					"invalid name":      "1",
+					"1Invalid":          "1",
+					"ScheduledLibrary_": "1",
+				},
+			},
+			expected: map[string]string{
+				"FriendlyName":      "Living Room",
+				"DlnaEnabled":       "1",
+				"ScheduledLibrary_": "1",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: tc.preferences,
+				},
+			}
+			assert.Equal(t, tc.expected, plexPreferences(plex, tc.lanNetworks, tc.customConnections), "preferences should be equal")
+		})
+	}
+}
+
+func TestRenderPreferences(t *testing.T) {
+	rendered := renderPreferences(map[string]string{
+		"FriendlyName": `Tom & Jerry's "Plex" <Server>`,
+		"RelayEnabled": "0",
+	})
+	assert.Equal(t,
+		"FriendlyName=Tom &amp; Jerry&#39;s &#34;Plex&#34; &lt;Server&gt;\nRelayEnabled=0\n",
+		rendered, "rendered preferences should be equal")
+}
+
+type preferencesTestCase struct {
+	name              string
+	plex              *v1alpha1.PlexMediaServer
+	existingConfigMap *corev1.ConfigMap
+	expectedConfigMap *corev1.ConfigMap
+	expectError       bool
+	expectRequeue     bool
+}
+
+type preferencesReconcileSuite struct {
+	suite.Suite
+	cases []preferencesTestCase
+}
+
+func (test *preferencesReconcileSuite) SetupTest() {
+	test.cases = []preferencesTestCase{
+		{
+			name: "none with no existing config map",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+			},
+		},
+		{
+			name: "none with existing config map",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none-existing",
+				},
+			},
+			existingConfigMap: preferencesConfigMapDouble("none", "none-existing", "FriendlyName=Plex\n"),
+			expectRequeue:     true,
+		},
+		{
+			name: "create",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName:      "Living Room",
+						TranscoderQuality: "PreferHigherQuality",
+					},
+				},
+			},
+			expectedConfigMap: preferencesConfigMapDouble("create", "create", "FriendlyName=Living Room\nTranscoderQuality=2\n"),
+			expectRequeue:     true,
+		},
+		{
+			name: "update",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName: "Basement",
+					},
+				},
+			},
+			existingConfigMap: preferencesConfigMapDouble("update", "update", "FriendlyName=Living Room\n"),
+			expectedConfigMap: preferencesConfigMapDouble("update", "update", "FriendlyName=Basement\n"),
+			expectRequeue:     true,
+		},
+		{
+			name: "no change",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "no-change",
+					Name:      "no-change",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName: "Living Room",
+					},
+				},
+			},
+			existingConfigMap: preferencesConfigMapDouble("no-change", "no-change", "FriendlyName=Living Room\n"),
+			expectedConfigMap: preferencesConfigMapDouble("no-change", "no-change", "FriendlyName=Living Room\n"),
+		},
+	}
+}
+
+func (test *preferencesReconcileSuite) TestPreferencesReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			if tc.existingConfigMap != nil {
+				builder.WithObjects(tc.existingConfigMap)
+			}
+			client := builder.Build()
+			reconciler := NewPreferencesReconciler(client, log, client.Scheme(), record.NewFakeRecorder(100))
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			updatedConfigMap := &corev1.ConfigMap{}
+			err = client.Get(ctx, types.NamespacedName{
+				Namespace: tc.plex.Namespace,
+				Name:      fmt.Sprintf("%s-preferences", tc.plex.Name),
+			}, updatedConfigMap)
+			if tc.expectedConfigMap == nil {
+				test.True(errors.IsNotFound(err), "expected config map to not exist")
+				return
+			}
+			test.Require().NoError(err, "failed to get ConfigMap")
+			test.True(equality.Semantic.DeepEqual(tc.expectedConfigMap.Data, updatedConfigMap.Data),
+				"expected config map does not match - diff: %s",
+				cmp.Diff(tc.expectedConfigMap.Data, updatedConfigMap.Data))
+		})
+	}
+}
+
+func TestSetCustomConnections(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(s), "failed to add scheme")
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				CustomConnections: []string{"http://192.168.1.11:32400"},
+			},
+		},
+	}
+	existing := preferencesConfigMapDouble("test", "plex", "customConnections=http://192.168.1.10:32400\n")
+	plexServer := fakeplex.NewServer("token")
+	defer plexServer.Close()
+	origNewPlexAPIClient := newPlexAPIClient
+	defer func() {
+		newPlexAPIClient = origNewPlexAPIClient
+	}()
+	newPlexAPIClient = func(ctx context.Context, c client.Reader, plex *v1alpha1.PlexMediaServer) (*plexapi.Client, error) {
+		return plexServer.Client("token"), nil
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(plex, existing).Build()
+	reconciler := NewPreferencesReconciler(c, logr.Discard(), s, record.NewFakeRecorder(10))
+
+	_, err := reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "failed to reconcile preferences")
+	value, ok := plexServer.Preference("customConnections")
+	assert.True(t, ok, "custom connections should be set on the running server")
+	assert.Equal(t, "http://192.168.1.11:32400", value, "custom connections should be equal")
+}
+
+func TestMergePreferencesScript(t *testing.T) {
+	cases := []struct {
+		name          string
+		existing      string
+		expected      string
+		expectedError bool
+	}{
+		{
+			name:     "new file",
+			expected: "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<Preferences FriendlyName=\"Plex\" RelayEnabled=\"0\"/>\n",
+		},
+		{
+			name:     "existing preferences",
+			existing: "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<Preferences MachineIdentifier=\"abc\" FriendlyName=\"Old\"/>\n",
+			expected: "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<Preferences MachineIdentifier=\"abc\" FriendlyName=\"Plex\" RelayEnabled=\"0\"/>\n",
+		},
+		{
+			name:          "multi-line preferences",
+			existing:      "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<Preferences\n  MachineIdentifier=\"abc\"\n  FriendlyName=\"Old\"/>\n",
+			expectedError: true,
+		},
+		{
+			name:          "no preferences element",
+			existing:      "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n",
+			expectedError: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "plex-preferences")
+			require.NoError(t, err, "failed to create temporary directory")
+			defer os.RemoveAll(dir)
+			script := filepath.Join(dir, "merge-preferences.sh")
+			err = ioutil.WriteFile(script, []byte(strings.Replace(mergePreferencesScript, "/config/", dir+"/", 1)), 0755)
+			require.NoError(t, err, "failed to write script")
+			prefs := filepath.Join(dir, "preferences")
+			err = ioutil.WriteFile(prefs, []byte("FriendlyName=Plex\nRelayEnabled=0\n"), 0644)
+			require.NoError(t, err, "failed to write preferences")
+			prefsFile := filepath.Join(dir, "Library", "Application Support", "Plex Media Server", "Preferences.xml")
+			if tc.existing != "" {
+				require.NoError(t, os.MkdirAll(filepath.Dir(prefsFile), 0755), "failed to create Plex directory")
+				require.NoError(t, ioutil.WriteFile(prefsFile, []byte(tc.existing), 0644), "failed to write Preferences.xml")
+			}
+
+			out, err := exec.Command("sh", script, prefs).CombinedOutput()
+			merged, readErr := ioutil.ReadFile(prefsFile)
+			require.NoError(t, readErr, "failed to read Preferences.xml")
+			if tc.expectedError {
+				assert.Error(t, err, "script should fail")
+				assert.Equal(t, tc.existing, string(merged), "Preferences.xml should not change")
+				return
+			}
+			require.NoError(t, err, "script failed: %s", out)
+			assert.Equal(t, tc.expected, string(merged), "Preferences.xml should be equal")
+		})
+	}
+}
+
+func preferencesConfigMapDouble(namespace, name string, preferences string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      fmt.Sprintf("%s-preferences", name),
+			Labels:    plexLabelsDouble(name),
+		},
+		Data: map[string]string{
+			"preferences":          preferences,
+			"merge-preferences.sh": mergePreferencesScript,
+		},
+	}
+}
+
+func TestPreferencesSuite(t *testing.T) {
+	suite.Run(t, new(preferencesReconcileSuite))
+}
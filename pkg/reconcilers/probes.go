@@ -0,0 +1,96 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// plexProbePath is the endpoint checked by Plex's probes. It responds without an X-Plex-Token once
+// Plex is serving.
+const plexProbePath = "/identity"
+
+// plexProbeDefaults are the default timings of one of Plex's probes
+type plexProbeDefaults struct {
+	periodSeconds    int32
+	timeoutSeconds   int32
+	failureThreshold int32
+}
+
+var (
+	// startupProbeDefaults allow Plex 10 minutes to start, which covers database migrations on
+	// first boot after an upgrade
+	startupProbeDefaults = plexProbeDefaults{
+		periodSeconds:    10,
+		timeoutSeconds:   5,
+		failureThreshold: 60,
+	}
+	readinessProbeDefaults = plexProbeDefaults{
+		periodSeconds:    10,
+		timeoutSeconds:   5,
+		failureThreshold: 3,
+	}
+	// livenessProbeDefaults restart Plex after it has not responded for 2.5 minutes, so that a
+	// long library scan does not get Plex restarted
+	livenessProbeDefaults = plexProbeDefaults{
+		periodSeconds:    30,
+		timeoutSeconds:   10,
+		failureThreshold: 5,
+	}
+)
+
+// renderPlexProbes sets the startup, readiness, and liveness probes of the Plex container
+func renderPlexProbes(plex *v1alpha1.PlexMediaServer, container *corev1.Container) {
+	probes := plex.Spec.Probes
+	port := findPlexPort(plex, "plex").targetPort
+	container.StartupProbe = renderPlexProbe(container.StartupProbe, probes.Startup, startupProbeDefaults, port)
+	container.ReadinessProbe = renderPlexProbe(container.ReadinessProbe, probes.Readiness, readinessProbeDefaults, port)
+	container.LivenessProbe = renderPlexProbe(container.LivenessProbe, probes.Liveness, livenessProbeDefaults, port)
+}
+
+// renderPlexProbe renders a probe of the Plex container on top of the existing probe. The operator
+// owns the probe's handler and timings; other fields, such as the success threshold or HTTP
+// headers, are left as they are. Returns nil if the probe is disabled.
+func renderPlexProbe(existing *corev1.Probe, options *v1alpha1.PlexProbeOptions, defaults plexProbeDefaults, port int32) *corev1.Probe {
+	if options == nil {
+		options = &v1alpha1.PlexProbeOptions{}
+	}
+	if options.Disabled {
+		return nil
+	}
+	probe := &corev1.Probe{}
+	if existing != nil {
+		probe = existing.DeepCopy()
+	}
+	probe.Exec = nil
+	probe.TCPSocket = nil
+	if probe.HTTPGet == nil {
+		probe.HTTPGet = &corev1.HTTPGetAction{}
+	}
+	probe.HTTPGet.Path = plexProbePath
+	probe.HTTPGet.Port = intstr.FromInt(int(port))
+
+	probe.InitialDelaySeconds = 0
+	if options.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *options.InitialDelaySeconds
+	}
+	probe.PeriodSeconds = defaults.periodSeconds
+	if options.PeriodSeconds != nil {
+		probe.PeriodSeconds = *options.PeriodSeconds
+	}
+	probe.TimeoutSeconds = defaults.timeoutSeconds
+	if options.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *options.TimeoutSeconds
+	}
+	probe.FailureThreshold = defaults.failureThreshold
+	if options.FailureThreshold != nil {
+		probe.FailureThreshold = *options.FailureThreshold
+	}
+	return probe
+}
@@ -0,0 +1,129 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestRenderPlexProbe(t *testing.T) {
+	initialDelay := int32(60)
+	failureThreshold := int32(120)
+	cases := []struct {
+		name     string
+		existing *corev1.Probe
+		options  *v1alpha1.PlexProbeOptions
+		expected *corev1.Probe
+	}{
+		{
+			name:     "defaults",
+			expected: plexProbeDouble(10, 5, 60),
+		},
+		{
+			name: "tuned",
+			options: &v1alpha1.PlexProbeOptions{
+				InitialDelaySeconds: &initialDelay,
+				FailureThreshold:    &failureThreshold,
+			},
+			expected: &corev1.Probe{
+				Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path: "/identity",
+						Port: intstr.FromInt(32400),
+					},
+				},
+				InitialDelaySeconds: 60,
+				PeriodSeconds:       10,
+				TimeoutSeconds:      5,
+				FailureThreshold:    120,
+			},
+		},
+		{
+			name:     "disabled",
+			existing: plexProbeDouble(10, 5, 60),
+			options: &v1alpha1.PlexProbeOptions{
+				Disabled: true,
+			},
+		},
+		{
+			name: "preserve unmanaged fields",
+			existing: &corev1.Probe{
+				Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path:   "/web",
+						Port:   intstr.FromString("plex"),
+						Scheme: corev1.URISchemeHTTPS,
+						HTTPHeaders: []corev1.HTTPHeader{
+							{Name: "Accept", Value: "application/json"},
+						},
+					},
+				},
+				PeriodSeconds:    1,
+				SuccessThreshold: 2,
+			},
+			expected: &corev1.Probe{
+				Handler: corev1.Handler{
+					HTTPGet: &corev1.HTTPGetAction{
+						Path:   "/identity",
+						Port:   intstr.FromInt(32400),
+						Scheme: corev1.URISchemeHTTPS,
+						HTTPHeaders: []corev1.HTTPHeader{
+							{Name: "Accept", Value: "application/json"},
+						},
+					},
+				},
+				PeriodSeconds:    10,
+				TimeoutSeconds:   5,
+				SuccessThreshold: 2,
+				FailureThreshold: 60,
+			},
+		},
+		{
+			name: "replace exec handler",
+			existing: &corev1.Probe{
+				Handler: corev1.Handler{
+					Exec: &corev1.ExecAction{
+						Command: []string{"curl", "http://localhost:32400/identity"},
+					},
+				},
+			},
+			expected: plexProbeDouble(10, 5, 60),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			probe := renderPlexProbe(tc.existing, tc.options, startupProbeDefaults, 32400)
+			assert.Equal(t, tc.expected, probe, "probe should be equal")
+		})
+	}
+}
+
+func TestRenderPlexProbes(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Probes: v1alpha1.PlexProbesSpec{
+				Liveness: &v1alpha1.PlexProbeOptions{
+					Disabled: true,
+				},
+			},
+		},
+	}
+	container := &corev1.Container{
+		Name:          "plex",
+		LivenessProbe: plexProbeDouble(30, 10, 5),
+	}
+	renderPlexProbes(plex, container)
+	assert.Equal(t, plexProbeDouble(10, 5, 60), container.StartupProbe, "startup probe should be equal")
+	assert.Equal(t, plexProbeDouble(10, 5, 3), container.ReadinessProbe, "readiness probe should be equal")
+	assert.Nil(t, container.LivenessProbe, "liveness probe should be removed")
+}
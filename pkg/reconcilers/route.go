@@ -0,0 +1,169 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// RouteGVK is the GroupVersionKind of the OpenShift Route
+var RouteGVK = schema.GroupVersionKind{
+	Group:   "route.openshift.io",
+	Version: "v1",
+	Kind:    "Route",
+}
+
+// RouteReconciler reconciles the OpenShift Route for Plex Media Server.
+// Routes are only available on OpenShift, so they are managed as unstructured objects.
+type RouteReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Platform Platform
+}
+
+// NewRouteReconciler returns a new Reconciler that reconciles the Route for Plex Media Server
+func NewRouteReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder, platform Platform) *RouteReconciler {
+	return &RouteReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+		Platform: platform,
+	}
+}
+
+// Reconcile reconciles the Route with the desired state of the PlexMediaServer
+func (r *RouteReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
+	log := r.Log.WithValues("route", namespacedName)
+	if !r.Platform.OpenShift {
+		// The Route API is not installed - the StatusReconciler reports this if a Route was requested
+		return false, nil
+	}
+	origRoute := &unstructured.Unstructured{}
+	origRoute.SetGroupVersionKind(RouteGVK)
+	err := r.Client.Get(ctx, namespacedName, origRoute)
+
+	if errors.IsNotFound(err) {
+		if plex.Spec.Networking.Route == nil {
+			return false, nil
+		}
+		log.Info("creating")
+		origRoute, err = r.createRoute(plex)
+		if err != nil {
+			log.Error(err, "failed to render object")
+			return true, err
+		}
+		err = r.Client.Create(ctx, origRoute, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "Route", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if plex.Spec.Networking.Route == nil {
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origRoute, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "Route", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	desiredRoute := origRoute.DeepCopy()
+	renderObjectMeta(plex, desiredRoute)
+	err = r.renderRouteSpec(plex, desiredRoute)
+	if err != nil {
+		log.Error(err, "failed to render object")
+		return true, err
+	}
+	if !equality.Semantic.DeepEqual(origRoute.Object["spec"], desiredRoute.Object["spec"]) ||
+		objectMetaChanged(origRoute, desiredRoute) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredRoute, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Route", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "Route", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *RouteReconciler) createRoute(plex *v1alpha1.PlexMediaServer) (*unstructured.Unstructured, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(RouteGVK)
+	route.SetNamespace(plex.Namespace)
+	route.SetName(plex.Name)
+	if err := r.renderRouteSpec(plex, route); err != nil {
+		return nil, err
+	}
+	renderObjectMeta(plex, route)
+	if err := ctrl.SetControllerReference(plex, route, r.Scheme); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// renderRouteSpec renders the fields of the Route spec managed by the operator on top of the
+// existing Route. The host is left untouched if it is not set, so that OpenShift can generate it.
+func (r *RouteReconciler) renderRouteSpec(plex *v1alpha1.PlexMediaServer, route *unstructured.Unstructured) error {
+	routeSpec := plex.Spec.Networking.Route
+	if routeSpec.Host != "" {
+		if err := unstructured.SetNestedField(route.Object, routeSpec.Host, "spec", "host"); err != nil {
+			return err
+		}
+	}
+	// OpenShift sets defaults on the route target, such as its weight
+	if err := unstructured.SetNestedField(route.Object, "Service", "spec", "to", "kind"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(route.Object, plex.Name, "spec", "to", "name"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(route.Object, "plex", "spec", "port", "targetPort"); err != nil {
+		return err
+	}
+	if routeSpec.TLSTermination == "" {
+		unstructured.RemoveNestedField(route.Object, "spec", "tls")
+		return nil
+	}
+	return unstructured.SetNestedField(route.Object, routeSpec.TLSTermination, "spec", "tls", "termination")
+}
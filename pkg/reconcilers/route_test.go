@@ -0,0 +1,235 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/suite"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+type routeTestCase struct {
+	name          string
+	plex          *v1alpha1.PlexMediaServer
+	platform      Platform
+	existingRoute *unstructured.Unstructured
+	expectedRoute *unstructured.Unstructured
+	expectError   bool
+	expectRequeue bool
+}
+
+type routeReconcileSuite struct {
+	suite.Suite
+	cases []routeTestCase
+}
+
+func (test *routeReconcileSuite) SetupTest() {
+	openShift := Platform{OpenShift: true}
+	test.cases = []routeTestCase{
+		{
+			name: "no route",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "none",
+					Name:      "none",
+				},
+			},
+			platform: openShift,
+		},
+		{
+			name: "route API not installed",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kubernetes",
+					Name:      "kubernetes",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{},
+					},
+				},
+			},
+		},
+		{
+			name: "create edge route",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "create",
+					Name:      "create",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{
+							Host:           "plex.apps.example.com",
+							TLSTermination: "edge",
+						},
+					},
+				},
+			},
+			platform: openShift,
+			expectedRoute: openShiftRouteDouble("create", "create", map[string]interface{}{
+				"host": "plex.apps.example.com",
+				"to": map[string]interface{}{
+					"kind": "Service",
+					"name": "create",
+				},
+				"port": map[string]interface{}{
+					"targetPort": "plex",
+				},
+				"tls": map[string]interface{}{
+					"termination": "edge",
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update to passthrough keeps generated host and defaults",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{
+							TLSTermination: "passthrough",
+						},
+					},
+				},
+			},
+			platform: openShift,
+			existingRoute: openShiftRouteDouble("update", "update", map[string]interface{}{
+				"host": "update-update.apps.example.com",
+				"to": map[string]interface{}{
+					"kind":   "Service",
+					"name":   "update",
+					"weight": int64(100),
+				},
+				"port": map[string]interface{}{
+					"targetPort": "plex",
+				},
+				"wildcardPolicy": "None",
+			}),
+			expectedRoute: openShiftRouteDouble("update", "update", map[string]interface{}{
+				"host": "update-update.apps.example.com",
+				"to": map[string]interface{}{
+					"kind":   "Service",
+					"name":   "update",
+					"weight": int64(100),
+				},
+				"port": map[string]interface{}{
+					"targetPort": "plex",
+				},
+				"wildcardPolicy": "None",
+				"tls": map[string]interface{}{
+					"termination": "passthrough",
+				},
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "delete when route removed",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "delete",
+					Name:      "delete",
+				},
+			},
+			platform: openShift,
+			existingRoute: openShiftRouteDouble("delete", "delete", map[string]interface{}{
+				"to": map[string]interface{}{
+					"kind": "Service",
+					"name": "delete",
+				},
+			}),
+			expectRequeue: true,
+		},
+	}
+}
+
+func (test *routeReconcileSuite) TestRouteReconcile() {
+	log := logr.Discard()
+
+	for _, tc := range test.cases {
+		test.Run(tc.name, func() {
+			ctx := context.TODO()
+			scheme := scheme.Scheme
+			err := v1alpha1.AddToScheme(scheme)
+			test.Require().Nil(err, "failed to add scheme")
+			builder := fake.NewClientBuilder().WithScheme(scheme)
+			if tc.plex != nil {
+				builder.WithObjects(tc.plex)
+			}
+			if tc.existingRoute != nil {
+				builder.WithObjects(tc.existingRoute)
+			}
+			client := builder.Build()
+			reconciler := &RouteReconciler{
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
+				Platform: tc.platform,
+			}
+			requeue, err := reconciler.Reconcile(ctx, tc.plex)
+			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectError {
+				test.Error(err, "expected error was not returned")
+				return
+			}
+			test.Require().NoError(err, "unexpected error from reconcile")
+			updatedRoute := &unstructured.Unstructured{}
+			updatedRoute.SetGroupVersionKind(RouteGVK)
+			err = client.Get(ctx, types.NamespacedName{Namespace: tc.plex.Namespace, Name: tc.plex.Name}, updatedRoute)
+			if tc.expectedRoute == nil {
+				test.True(errors.IsNotFound(err), "expected route to not exist")
+				return
+			}
+			test.Require().NoError(err, "failed to get Route")
+			test.True(equality.Semantic.DeepEqual(tc.expectedRoute.Object["spec"], updatedRoute.Object["spec"]),
+				"expected route does not match - diff: %s",
+				cmp.Diff(tc.expectedRoute.Object["spec"], updatedRoute.Object["spec"]))
+		})
+	}
+}
+
+func openShiftRouteDouble(namespace, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	route := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	route.SetGroupVersionKind(RouteGVK)
+	route.SetNamespace(namespace)
+	route.SetName(name)
+	return route
+}
+
+// withRouteIngress sets the ingress status reported by OpenShift's router on the route double
+func withRouteIngress(route *unstructured.Unstructured, ingresses ...interface{}) *unstructured.Unstructured {
+	route.Object["status"] = map[string]interface{}{
+		"ingress": ingresses,
+	}
+	return route
+}
+
+func TestRouteSuite(t *testing.T) {
+	suite.Run(t, new(routeReconcileSuite))
+}
@@ -16,6 +16,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -25,16 +26,18 @@ import (
 // ServiceReconciler reconciles the Service deployment for Plex Media Server
 type ServiceReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
 }
 
 // NewServiceReconciler returns a new Reconciler that reconciles the Service for Plex Media Server
-func NewServiceReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme) *ServiceReconciler {
+func NewServiceReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *ServiceReconciler {
 	return &ServiceReconciler{
-		Client: client,
-		Log:    log,
-		Scheme: scheme,
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
 	}
 }
 
@@ -46,7 +49,8 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMe
 	if err != nil && errors.IsNotFound(err) {
 		log.Info("creating")
 		origService = r.createService(plex)
-		err = r.Client.Create(ctx, origService, &client.CreateOptions{})
+		err = createServiceWithIPFamilies(ctx, r.Client, plex, origService)
+		recordCreated(r.Recorder, plex, "Service", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to create object")
 			return true, err
@@ -58,14 +62,18 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMe
 		return true, err
 	}
 	desiredService := origService.DeepCopy()
+	renderObjectMeta(plex, desiredService)
 	desiredService.Spec = r.renderServiceSpec(plex, desiredService.Spec)
-	if !equality.Semantic.DeepEqual(origService.Spec, desiredService.Spec) {
+	if !equality.Semantic.DeepEqual(origService.Spec, desiredService.Spec) ||
+		objectMetaChanged(origService, desiredService) {
 		log.Info("updating")
 		err = r.Update(ctx, desiredService, &client.UpdateOptions{})
 		if errors.IsConflict(err) {
 			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Service", namespacedName.Name)
 			return true, nil
 		}
+		recordUpdated(r.Recorder, plex, "Service", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to update object")
 			return true, err
@@ -74,6 +82,17 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMe
 		return true, nil
 	}
 
+	updated, err := reconcileServiceIPFamilies(ctx, r.Client, plex, namespacedName)
+	if err != nil {
+		recordUpdated(r.Recorder, plex, "Service", namespacedName.Name, err)
+		log.Error(err, "failed to update IP families")
+		return true, err
+	}
+	if updated {
+		log.Info("updated IP families")
+		r.Recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonUpdated, "Updated IP families of Service %s", namespacedName.Name)
+		return true, nil
+	}
 	return false, nil
 }
 
@@ -85,6 +104,7 @@ func (r *ServiceReconciler) createService(plex *v1alpha1.PlexMediaServer) *corev
 		},
 	}
 	service.Spec = r.renderServiceSpec(plex, service.Spec)
+	renderObjectMeta(plex, service)
 	ctrl.SetControllerReference(plex, service, r.Scheme)
 	return service
 }
@@ -100,102 +120,20 @@ func (r *ServiceReconciler) renderServiceSpec(plex *v1alpha1.PlexMediaServer, ex
 
 func (r *ServiceReconciler) renderServicePorts(plex *v1alpha1.PlexMediaServer, existing []corev1.ServicePort) []corev1.ServicePort {
 	servicePorts := []corev1.ServicePort{}
-	dlnaUDP := corev1.ServicePort{
-		Port: 1900,
-	}
-	rokuPort := corev1.ServicePort{
-		Port: 8324,
-	}
-	plexPort := corev1.ServicePort{
-		Port: 32400,
-	}
-	discovery0 := corev1.ServicePort{
-		Port: 32410,
-	}
-	discovery1 := corev1.ServicePort{
-		Port: 32412,
-	}
-	discovery2 := corev1.ServicePort{
-		Port: 32413,
-	}
-	discovery3 := corev1.ServicePort{
-		Port: 32414,
-	}
-	dlnaTCP := corev1.ServicePort{
-		Port: 32469,
-	}
+	existingPlexPorts := map[string]corev1.ServicePort{}
 	for _, port := range existing {
-		if port.Port == 1900 {
-			dlnaUDP = port
-			continue
-		}
-		if port.Port == 8324 {
-			rokuPort = port
-			continue
-		}
-		if port.Port == 32400 {
-			plexPort = port
-			continue
-		}
-		if port.Port == 32410 {
-			discovery0 = port
-			continue
-		}
-		if port.Port == 32412 {
-			discovery1 = port
-			continue
-		}
-		if port.Port == 32413 {
-			discovery2 = port
-			continue
-		}
-		if port.Port == 32414 {
-			discovery3 = port
-			continue
-		}
-		if port.Port == 32469 {
-			dlnaTCP = port
+		if isPlexPortName(plex, port.Name) {
+			existingPlexPorts[port.Name] = port
 			continue
 		}
+		// Append any other ServicePorts to the returned slice
 		servicePorts = append(servicePorts, port)
 	}
-
-	if plex.Spec.Networking.EnableDLNA {
-		dlnaUDP.Name = "dlna-udp"
-		dlnaUDP.Protocol = corev1.ProtocolUDP
-		servicePorts = append(servicePorts, dlnaUDP)
-	}
-
-	if plex.Spec.Networking.EnableRoku {
-		rokuPort.Name = "roku"
-		rokuPort.Protocol = corev1.ProtocolTCP
-		servicePorts = append(servicePorts, rokuPort)
-	}
-
-	plexPort.Name = "plex"
-	plexPort.Protocol = corev1.ProtocolTCP
-
-	servicePorts = append(servicePorts, plexPort)
-	if plex.Spec.Networking.EnableDiscovery {
-		discovery0.Name = "discovery-0"
-		discovery0.Protocol = corev1.ProtocolUDP
-
-		discovery1.Name = "discovery-1"
-		discovery1.Protocol = corev1.ProtocolUDP
-
-		discovery2.Name = "discovery-2"
-		discovery2.Protocol = corev1.ProtocolUDP
-
-		discovery3.Name = "discovery-3"
-		discovery3.Protocol = corev1.ProtocolUDP
-
-		servicePorts = append(servicePorts, discovery0, discovery1, discovery2, discovery3)
-	}
-
-	if plex.Spec.Networking.EnableDLNA {
-		dlnaTCP.Name = "dlna-tcp"
-		dlnaTCP.Protocol = corev1.ProtocolTCP
-		servicePorts = append(servicePorts, dlnaTCP)
+	for _, port := range plexPorts(plex) {
+		if !port.enabled {
+			continue
+		}
+		servicePorts = append(servicePorts, renderPlexServicePort(port, existingPlexPorts[port.name], false))
 	}
 	return servicePorts
 }
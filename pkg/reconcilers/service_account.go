@@ -0,0 +1,115 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+// ServiceAccountReconciler reconciles the ServiceAccount for Plex Media Server
+type ServiceAccountReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewServiceAccountReconciler returns a new Reconciler that reconciles the ServiceAccount for Plex
+// Media Server
+func NewServiceAccountReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *ServiceAccountReconciler {
+	return &ServiceAccountReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the ServiceAccount with the desired state of the PlexMediaServer. Plex does
+// not use the Kubernetes API, so its ServiceAccount does not mount an API token and is not
+// granted any permissions.
+func (r *ServiceAccountReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origServiceAccount := &corev1.ServiceAccount{}
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: serviceAccountName(plex)}
+	log := r.Log.WithValues("serviceAccount", namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origServiceAccount)
+
+	if errors.IsNotFound(err) {
+		log.Info("creating")
+		origServiceAccount = r.createServiceAccount(plex)
+		err = r.Client.Create(ctx, origServiceAccount, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "ServiceAccount", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	desiredServiceAccount := origServiceAccount.DeepCopy()
+	renderObjectMeta(plex, desiredServiceAccount)
+	r.renderServiceAccount(desiredServiceAccount)
+	if !equality.Semantic.DeepEqual(origServiceAccount.AutomountServiceAccountToken, desiredServiceAccount.AutomountServiceAccountToken) ||
+		objectMetaChanged(origServiceAccount, desiredServiceAccount) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredServiceAccount, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "ServiceAccount", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "ServiceAccount", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *ServiceAccountReconciler) createServiceAccount(plex *v1alpha1.PlexMediaServer) *corev1.ServiceAccount {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: plex.Namespace,
+			Name:      serviceAccountName(plex),
+		},
+	}
+	r.renderServiceAccount(serviceAccount)
+	renderObjectMeta(plex, serviceAccount)
+	ctrl.SetControllerReference(plex, serviceAccount, r.Scheme)
+	return serviceAccount
+}
+
+func (r *ServiceAccountReconciler) renderServiceAccount(serviceAccount *corev1.ServiceAccount) {
+	automountServiceAccountToken := false
+	serviceAccount.AutomountServiceAccountToken = &automountServiceAccountToken
+}
+
+// serviceAccountName returns the name of Plex's ServiceAccount
+func serviceAccountName(plex *v1alpha1.PlexMediaServer) string {
+	return plex.Name
+}
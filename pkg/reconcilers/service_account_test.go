@@ -0,0 +1,95 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestServiceAccountReconcile(t *testing.T) {
+	automount := true
+	cases := []struct {
+		name                   string
+		existingServiceAccount *corev1.ServiceAccount
+		expectRequeue          bool
+		expectedEvents         []string
+	}{
+		{
+			name:           "create",
+			expectRequeue:  true,
+			expectedEvents: []string{"Normal Created Created ServiceAccount plex"},
+		},
+		{
+			name: "update token automount",
+			existingServiceAccount: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+				AutomountServiceAccountToken: &automount,
+			},
+			expectRequeue:  true,
+			expectedEvents: []string{"Normal Updated Updated ServiceAccount plex"},
+		},
+		{
+			name: "no change",
+			existingServiceAccount: &corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+					Labels:    plexLabelsDouble("plex"),
+				},
+				AutomountServiceAccountToken: new(bool),
+			},
+			expectedEvents: []string{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s := scheme.Scheme
+			require.NoError(t, v1alpha1.AddToScheme(s), "failed to add scheme")
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+			}
+			builder := fake.NewClientBuilder().WithScheme(s).WithObjects(plex)
+			if tc.existingServiceAccount != nil {
+				builder.WithObjects(tc.existingServiceAccount)
+			}
+			c := builder.Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := NewServiceAccountReconciler(c, logr.Discard(), s, recorder)
+
+			requeue, err := reconciler.Reconcile(ctx, plex)
+			require.NoError(t, err, "unexpected error from reconcile")
+			assert.Equal(t, tc.expectRequeue, requeue, "requeue result should be equal")
+			assert.Equal(t, tc.expectedEvents, recordedEvents(recorder), "recorded events should be equal")
+
+			serviceAccount := &corev1.ServiceAccount{}
+			err = c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "plex"}, serviceAccount)
+			require.NoError(t, err, "failed to get ServiceAccount")
+			require.NotNil(t, serviceAccount.AutomountServiceAccountToken, "token automount should be set")
+			assert.False(t, *serviceAccount.AutomountServiceAccountToken, "token should not be mounted")
+		})
+	}
+}
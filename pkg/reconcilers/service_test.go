@@ -18,7 +18,9 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/adambkaplan/plex-operator/api/v1alpha1"
 )
@@ -69,29 +71,34 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "discovery-0",
-						Port:     32410,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-0",
+						Port:       32410,
+						TargetPort: intstr.FromInt(32410),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-1",
-						Port:     32412,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-1",
+						Port:       32412,
+						TargetPort: intstr.FromInt(32412),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-2",
-						Port:     32413,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-2",
+						Port:       32413,
+						TargetPort: intstr.FromInt(32413),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-3",
-						Port:     32414,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-3",
+						Port:       32414,
+						TargetPort: intstr.FromInt(32414),
+						Protocol:   corev1.ProtocolUDP,
 					},
 				},
 			}),
@@ -114,14 +121,16 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "roku",
-						Port:     8324,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "roku",
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -144,19 +153,22 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "dlna-udp",
-						Port:     1900,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "dlna-udp",
+						Port:       1900,
+						TargetPort: intstr.FromInt(1900),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "dlna-tcp",
-						Port:     32469,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "dlna-tcp",
+						Port:       32469,
+						TargetPort: intstr.FromInt(32469),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -182,29 +194,34 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "discovery-0",
-						Port:     32410,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-0",
+						Port:       32410,
+						TargetPort: intstr.FromInt(32410),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-1",
-						Port:     32412,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-1",
+						Port:       32412,
+						TargetPort: intstr.FromInt(32412),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-2",
-						Port:     32413,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-2",
+						Port:       32413,
+						TargetPort: intstr.FromInt(32413),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-3",
-						Port:     32414,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-3",
+						Port:       32414,
+						TargetPort: intstr.FromInt(32414),
+						Protocol:   corev1.ProtocolUDP,
 					},
 				},
 			}),
@@ -226,29 +243,34 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "discovery-0",
-						Port:     32410,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-0",
+						Port:       32410,
+						TargetPort: intstr.FromInt(32410),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-1",
-						Port:     32412,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-1",
+						Port:       32412,
+						TargetPort: intstr.FromInt(32412),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-2",
-						Port:     32413,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-2",
+						Port:       32413,
+						TargetPort: intstr.FromInt(32413),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "discovery-3",
-						Port:     32414,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "discovery-3",
+						Port:       32414,
+						TargetPort: intstr.FromInt(32414),
+						Protocol:   corev1.ProtocolUDP,
 					},
 				},
 			}),
@@ -274,14 +296,16 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "roku",
-						Port:     8324,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "roku",
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -303,14 +327,16 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "roku",
-						Port:     8324,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "roku",
+						Port:       8324,
+						TargetPort: intstr.FromInt(8324),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -336,19 +362,22 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "dlna-udp",
-						Port:     1900,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "dlna-udp",
+						Port:       1900,
+						TargetPort: intstr.FromInt(1900),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "dlna-tcp",
-						Port:     32469,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "dlna-tcp",
+						Port:       32469,
+						TargetPort: intstr.FromInt(32469),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -370,19 +399,22 @@ func (test *serviceReconcileSuite) SetupTest() {
 				ClusterIP: corev1.ClusterIPNone,
 				Ports: []corev1.ServicePort{
 					{
-						Name:     "dlna-udp",
-						Port:     1900,
-						Protocol: corev1.ProtocolUDP,
+						Name:       "dlna-udp",
+						Port:       1900,
+						TargetPort: intstr.FromInt(1900),
+						Protocol:   corev1.ProtocolUDP,
 					},
 					{
-						Name:     "plex",
-						Port:     32400,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "plex",
+						Port:       32400,
+						TargetPort: intstr.FromInt(32400),
+						Protocol:   corev1.ProtocolTCP,
 					},
 					{
-						Name:     "dlna-tcp",
-						Port:     32469,
-						Protocol: corev1.ProtocolTCP,
+						Name:       "dlna-tcp",
+						Port:       32469,
+						TargetPort: intstr.FromInt(32469),
+						Protocol:   corev1.ProtocolTCP,
 					},
 				},
 			}),
@@ -424,9 +456,10 @@ func (test *serviceReconcileSuite) TestServiceReconcile() {
 			}
 			client := builder.Build()
 			reconciler := &ServiceReconciler{
-				Client: client,
-				Scheme: client.Scheme(),
-				Log:    log,
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
 			}
 			requeue, err := reconciler.Reconcile(ctx, tc.plex)
 			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
@@ -449,6 +482,7 @@ type serviceDoubleOptions struct {
 	ClusterIP   string
 	ServiceType corev1.ServiceType
 	Ports       []corev1.ServicePort
+	Annotations map[string]string
 }
 
 func serviceDouble(namespace, plexName string, options serviceDoubleOptions) *corev1.Service {
@@ -458,16 +492,19 @@ func serviceDouble(namespace, plexName string, options serviceDoubleOptions) *co
 	if len(options.Ports) == 0 {
 		options.Ports = []corev1.ServicePort{
 			{
-				Name:     "plex",
-				Port:     32400,
-				Protocol: corev1.ProtocolTCP,
+				Name:       "plex",
+				Port:       32400,
+				TargetPort: intstr.FromInt(32400),
+				Protocol:   corev1.ProtocolTCP,
 			},
 		}
 	}
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Namespace: namespace,
-			Name:      options.ServiceName,
+			Namespace:   namespace,
+			Name:        options.ServiceName,
+			Labels:      plexLabelsDouble(plexName),
+			Annotations: options.Annotations,
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: map[string]string{
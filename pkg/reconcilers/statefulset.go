@@ -8,6 +8,7 @@ package reconcilers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
@@ -19,6 +20,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -26,19 +28,35 @@ import (
 	plexv1alpha1 "github.com/adambkaplan/plex-operator/api/v1alpha1"
 )
 
+// restrictedUserID is the user and group ID Plex runs as with the Restricted security context
+// preset, which matches the plex user in Plex's image
+const restrictedUserID = int64(1000)
+
 // StatefulSetReconciler is a reconciler for the PlexMediaServer's StatefulSet
 type StatefulSetReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Platform Platform
+
+	// lanNetworks are the networks Plex treats as local, resolved at the start of each reconcile
+	lanNetworks []string
+	// preferences are merged into Plex's Preferences.xml, or nil if preferences are not managed
+	preferences map[string]string
+	// certificateHash is the hash of Plex's PKCS#12 certificate, or empty if the certificate has
+	// not been created
+	certificateHash string
 }
 
 // NewStatefulSetReconciler returns a Reconciler for Plex's StatefulSet
-func NewStatefulSetReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme) *StatefulSetReconciler {
+func NewStatefulSetReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder, platform Platform) *StatefulSetReconciler {
 	return &StatefulSetReconciler{
-		Client: client,
-		Log:    log,
-		Scheme: scheme,
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+		Platform: platform,
 	}
 }
 
@@ -47,11 +65,35 @@ func (r *StatefulSetReconciler) Reconcile(ctx context.Context, plex *plexv1alpha
 	origStatefulSet := &appsv1.StatefulSet{}
 	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}
 	log := r.Log.WithValues("statefulset", namespacedName)
-	err := r.Client.Get(ctx, namespacedName, origStatefulSet)
+	lanNetworks, err := resolveLANNetworks(ctx, r.Client, log, plex)
+	if err != nil {
+		log.Error(err, "failed to resolve LAN networks")
+		return true, err
+	}
+	r.lanNetworks = lanNetworks
+	customConnections, err := resolveCustomConnections(ctx, r.Client, plex)
+	if err != nil {
+		log.Error(err, "failed to resolve custom connections")
+		return true, err
+	}
+	r.preferences = plexPreferences(plex, lanNetworks, customConnections)
+	if plex.Spec.TLS != nil {
+		certificateSecret := &corev1.Secret{}
+		err = r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: pkcs12SecretName(plex)}, certificateSecret)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Error(err, "failed to get certificate")
+			return true, err
+		}
+		if err == nil {
+			r.certificateHash = secretHash(certificateSecret)
+		}
+	}
+	err = r.Client.Get(ctx, namespacedName, origStatefulSet)
 	if errors.IsNotFound(err) {
 		log.Info("creating")
 		origStatefulSet = r.createStatefulSet(plex)
 		err = r.Client.Create(ctx, origStatefulSet, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "StatefulSet", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to create object")
 			return true, err
@@ -65,19 +107,25 @@ func (r *StatefulSetReconciler) Reconcile(ctx context.Context, plex *plexv1alpha
 	}
 
 	desiredStatefulSet := origStatefulSet.DeepCopy()
+	renderObjectMeta(plex, desiredStatefulSet)
 	desiredStatefulSet.Spec = r.renderStatefulSetSpec(plex, desiredStatefulSet.Spec)
 
 	if !equality.Semantic.DeepEqual(origStatefulSet.Spec.VolumeClaimTemplates, desiredStatefulSet.Spec.VolumeClaimTemplates) {
 		log.Info("deleting because volume claim templates changed")
 		log.Info(fmt.Sprintf("diff: %s", cmp.Diff(origStatefulSet.Spec.VolumeClaimTemplates, desiredStatefulSet.Spec.VolumeClaimTemplates)))
+		r.Recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonRecreating,
+			"Deleting StatefulSet %s to recreate it because its volume claim templates changed: %s", namespacedName.Name,
+			summarizeClaimTemplateChanges(origStatefulSet.Spec.VolumeClaimTemplates, desiredStatefulSet.Spec.VolumeClaimTemplates))
 		background := metav1.DeletePropagationBackground
 		err = r.Delete(ctx, desiredStatefulSet, &client.DeleteOptions{
 			PropagationPolicy: &background,
 		})
 		if errors.IsConflict(err) {
 			log.Info("conflict on delete, requeueing")
+			recordConflict(r.Recorder, plex, "StatefulSet", namespacedName.Name)
 			return true, nil
 		}
+		recordDeleted(r.Recorder, plex, "StatefulSet", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to delete object")
 			return true, err
@@ -85,13 +133,16 @@ func (r *StatefulSetReconciler) Reconcile(ctx context.Context, plex *plexv1alpha
 		return true, nil
 	}
 
-	if !equality.Semantic.DeepEqual(origStatefulSet.Spec, desiredStatefulSet.Spec) {
+	if !equality.Semantic.DeepEqual(origStatefulSet.Spec, desiredStatefulSet.Spec) ||
+		objectMetaChanged(origStatefulSet, desiredStatefulSet) {
 		log.Info("updating")
 		err = r.Update(ctx, desiredStatefulSet, &client.UpdateOptions{})
 		if errors.IsConflict(err) {
 			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "StatefulSet", namespacedName.Name)
 			return true, nil
 		}
+		recordUpdated(r.Recorder, plex, "StatefulSet", namespacedName.Name, err)
 		if err != nil {
 			log.Error(err, "failed to update object")
 			return true, err
@@ -110,6 +161,7 @@ func (r *StatefulSetReconciler) createStatefulSet(plex *plexv1alpha1.PlexMediaSe
 			Name:      plex.Name,
 		},
 	}
+	renderObjectMeta(plex, statefulSet)
 	statefulSet.Spec = r.renderStatefulSetSpec(plex, statefulSet.Spec)
 	ctrl.SetControllerReference(plex, statefulSet, r.Scheme)
 	return statefulSet
@@ -117,27 +169,55 @@ func (r *StatefulSetReconciler) createStatefulSet(plex *plexv1alpha1.PlexMediaSe
 
 // renderStatefulSetSpec renders a StatefulSet spec for the Plex Media Server on top of the
 // existing StatefulSetSpec. This ensures that the output StatefulSetSpec aligns with the settings
-// in the PlexMediaServer configuration.
+// in the PlexMediaServer configuration. The pod template override is merged in last.
 func (r *StatefulSetReconciler) renderStatefulSetSpec(plex *plexv1alpha1.PlexMediaServer, existingStatefulSet appsv1.StatefulSetSpec) appsv1.StatefulSetSpec {
-	replicas := int32(1)
-	existingStatefulSet.Replicas = &replicas
+	existingStatefulSet.Replicas = renderReplicas(plex)
 	existingStatefulSet.ServiceName = plex.Name
 	existingStatefulSet.Selector = &metav1.LabelSelector{
 		MatchLabels: map[string]string{
 			"plex.adambkaplan.com/instance": plex.Name,
 		},
 	}
-	existingStatefulSet.Template.ObjectMeta = metav1.ObjectMeta{
-		Labels: map[string]string{
-			"plex.adambkaplan.com/instance": plex.Name,
-		},
-	}
-	existingStatefulSet.Template.Spec.Containers = r.renderContainers(plex, existingStatefulSet.Template.Spec.Containers)
-	existingStatefulSet.Template.Spec.Volumes = r.renderPlexPodVolumes(plex, existingStatefulSet.Template.Spec.Volumes)
+	lastAppliedOverride := existingStatefulSet.Template.Annotations[podTemplateOverrideAnnotation]
+	existingStatefulSet.Template = r.renderPodTemplate(plex, existingStatefulSet.Template)
 	existingStatefulSet.VolumeClaimTemplates = r.renderPlexVolumeClaims(plex, existingStatefulSet.VolumeClaimTemplates)
+	existingStatefulSet.Template = r.renderPodTemplateOverride(plex, existingStatefulSet.Template, lastAppliedOverride,
+		existingStatefulSet.VolumeClaimTemplates)
 	return existingStatefulSet
 }
 
+// renderPodTemplate renders the pod template for the Plex Media Server on top of the existing pod
+// template, without the pod template override. Labels and annotations added to the pod template by
+// other tools are kept.
+func (r *StatefulSetReconciler) renderPodTemplate(plex *plexv1alpha1.PlexMediaServer, template corev1.PodTemplateSpec) corev1.PodTemplateSpec {
+	labels, annotations := renderMetadata(plex, template.Labels, template.Annotations, plex.Spec.PodLabels, plex.Spec.PodAnnotations)
+	template.Labels = labels
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	delete(annotations, preferencesHashAnnotation)
+	delete(annotations, certificateHashAnnotation)
+	if r.preferences != nil {
+		annotations[preferencesHashAnnotation] = preferencesHash(renderPreferences(restartPreferences(r.preferences)))
+	}
+	if plex.Spec.TLS != nil && r.certificateHash != "" {
+		annotations[certificateHashAnnotation] = r.certificateHash
+	}
+	template.Annotations = nil
+	if len(annotations) > 0 {
+		template.Annotations = annotations
+	}
+	template.Spec.TerminationGracePeriodSeconds = renderTerminationGracePeriod(plex)
+	automountServiceAccountToken := false
+	template.Spec.ServiceAccountName = serviceAccountName(plex)
+	template.Spec.AutomountServiceAccountToken = &automountServiceAccountToken
+	template.Spec.SecurityContext = r.renderPodSecurityContext(plex, template.Spec.SecurityContext)
+	template.Spec.InitContainers = r.renderInitContainers(plex, template.Spec.InitContainers)
+	template.Spec.Containers = r.renderContainers(plex, template.Spec.Containers)
+	template.Spec.Volumes = r.renderPlexPodVolumes(plex, template.Spec.Volumes)
+	return template
+}
+
 func (r *StatefulSetReconciler) renderContainers(plex *plexv1alpha1.PlexMediaServer, existing []corev1.Container) []corev1.Container {
 	containers := []corev1.Container{}
 	plexContainer := corev1.Container{
@@ -150,150 +230,308 @@ func (r *StatefulSetReconciler) renderContainers(plex *plexv1alpha1.PlexMediaSer
 		}
 		containers = append(containers, c)
 	}
-	version := plex.Spec.Version
-	if version == "" {
-		version = "latest"
-	}
-	plexContainer.Image = fmt.Sprintf("docker.io/plexinc/pms-docker:%s", version)
+	plexContainer.Image = plexImage(plex)
 	plexContainer.Env = r.renderPlexEnv(plex, plexContainer.Env)
 	plexContainer.Ports = r.renderPlexContainerPorts(plex, plexContainer.Ports)
-	plexContainer.VolumeMounts = r.renderPlexContainerVolumeMounts(plexContainer.VolumeMounts)
+	plexContainer.VolumeMounts = r.renderPlexContainerVolumeMounts(plex, plexContainer.VolumeMounts)
+	plexContainer.SecurityContext = r.renderPlexSecurityContext(plex, plexContainer.SecurityContext)
+	renderPlexProbes(plex, &plexContainer)
+	plexContainer.Lifecycle = renderPlexLifecycle(plex, plexContainer.Lifecycle)
 	containers = append(containers, plexContainer)
 	return containers
 }
 
-func (r *StatefulSetReconciler) renderPlexEnv(plex *v1alpha1.PlexMediaServer, existing []corev1.EnvVar) []corev1.EnvVar {
-	claimEnv := corev1.EnvVar{
-		Name: "PLEX_CLAIM",
+// renderInitContainers renders the init containers for Plex's pod. If volume ownership is
+// configured, the volume ownership init container fixes or checks the ownership of Plex's volumes.
+// If preferences are managed, the preferences init container then merges them into Preferences.xml
+// before Plex starts.
+func (r *StatefulSetReconciler) renderInitContainers(plex *plexv1alpha1.PlexMediaServer, existing []corev1.Container) []corev1.Container {
+	containers := []corev1.Container{}
+	ownershipContainer := corev1.Container{
+		Name: volumeOwnershipContainerName,
 	}
-	envVars := []corev1.EnvVar{}
-	for _, env := range existing {
-		if env.Name == "PLEX_CLAIM" {
-			claimEnv = env
+	preferencesContainer := corev1.Container{
+		Name: "preferences",
+	}
+	for _, c := range existing {
+		if c.Name == volumeOwnershipContainerName {
+			ownershipContainer = c
 			continue
 		}
-		envVars = append(envVars, env)
+		if c.Name == "preferences" {
+			preferencesContainer = c
+			continue
+		}
+		containers = append(containers, c)
 	}
-	claimEnv.Value = plex.Spec.ClaimToken
-	envVars = append(envVars, claimEnv)
-	return envVars
+	if plex.Spec.Storage.Ownership != nil {
+		containers = append(containers, r.renderVolumeOwnershipContainer(plex, ownershipContainer))
+	}
+	if r.preferences != nil {
+		containers = append(containers, r.renderPreferencesContainer(plex, preferencesContainer))
+	}
+	if len(containers) == 0 {
+		return nil
+	}
+	return containers
 }
 
-func (r *StatefulSetReconciler) renderPlexContainerPorts(plex *v1alpha1.PlexMediaServer, existing []corev1.ContainerPort) []corev1.ContainerPort {
-	containerPorts := []corev1.ContainerPort{}
-	dlnaUDP := corev1.ContainerPort{
-		ContainerPort: 1900,
+// renderPreferencesContainer renders the init container that merges the managed preferences into
+// Preferences.xml
+func (r *StatefulSetReconciler) renderPreferencesContainer(plex *plexv1alpha1.PlexMediaServer, preferencesContainer corev1.Container) corev1.Container {
+	preferencesContainer.Image = plexImage(plex)
+	preferencesContainer.Command = []string{
+		"/bin/sh",
+		fmt.Sprintf("%s/merge-preferences.sh", preferencesMountPath),
+		fmt.Sprintf("%s/preferences", preferencesMountPath),
 	}
-	rokuPort := corev1.ContainerPort{
-		ContainerPort: 8324,
+	if plex.Spec.TLS != nil {
+		preferencesContainer.Command = append(preferencesContainer.Command, fmt.Sprintf("%s/preferences", certificateMountPath))
 	}
-	plexPort := corev1.ContainerPort{
-		ContainerPort: 32400,
+	preferencesMounts := []corev1.VolumeMount{}
+	configMount := corev1.VolumeMount{Name: "config"}
+	preferencesMount := corev1.VolumeMount{Name: "preferences"}
+	certificateMount := corev1.VolumeMount{Name: "certificate"}
+	for _, mount := range preferencesContainer.VolumeMounts {
+		if mount.Name == "config" {
+			configMount = mount
+			continue
+		}
+		if mount.Name == "preferences" {
+			preferencesMount = mount
+			continue
+		}
+		if mount.Name == "certificate" {
+			certificateMount = mount
+			continue
+		}
+		preferencesMounts = append(preferencesMounts, mount)
 	}
-	discovery0 := corev1.ContainerPort{
-		ContainerPort: 32410,
+	configMount.MountPath = "/config"
+	preferencesMount.MountPath = preferencesMountPath
+	preferencesMount.ReadOnly = true
+	preferencesMounts = append(preferencesMounts, configMount, preferencesMount)
+	if plex.Spec.TLS != nil {
+		certificateMount.MountPath = certificateMountPath
+		certificateMount.ReadOnly = true
+		preferencesMounts = append(preferencesMounts, certificateMount)
+	}
+	preferencesContainer.VolumeMounts = preferencesMounts
+	preferencesContainer.SecurityContext = r.renderPlexSecurityContext(plex, preferencesContainer.SecurityContext)
+	return preferencesContainer
+}
+
+// plexSuspended returns true if Plex is suspended, either with spec.suspend or by scaling the
+// PlexMediaServer to 0 replicas
+func plexSuspended(plex *plexv1alpha1.PlexMediaServer) bool {
+	return plex.Spec.Suspend || (plex.Spec.Replicas != nil && *plex.Spec.Replicas == 0)
+}
+
+// renderReplicas returns the number of replicas of Plex's StatefulSet. Plex is scaled to 0 replicas
+// while it is suspended.
+func renderReplicas(plex *plexv1alpha1.PlexMediaServer) *int32 {
+	replicas := int32(1)
+	if plexSuspended(plex) {
+		replicas = 0
 	}
-	discovery1 := corev1.ContainerPort{
-		ContainerPort: 32412,
+	return &replicas
+}
+
+// plexImage returns the Plex Media Server image for the PlexMediaServer's version
+func plexImage(plex *plexv1alpha1.PlexMediaServer) string {
+	return fmt.Sprintf("docker.io/plexinc/pms-docker:%s", plexVersion(plex))
+}
+
+// renderPlexSecurityContext renders the security context for Plex's containers. With the Restricted
+// preset, the container is restricted so that it can be admitted under the restricted-v2
+// SecurityContextConstraints or the restricted Pod Security Standard. The preset is not applied
+// automatically on OpenShift, since Plex's image must start as root. The fields the
+// operator manages are rendered from scratch, so that they are cleared when the PlexMediaServer no
+// longer sets them.
+func (r *StatefulSetReconciler) renderPlexSecurityContext(plex *plexv1alpha1.PlexMediaServer, existing *corev1.SecurityContext) *corev1.SecurityContext {
+	spec := plex.Spec.SecurityContext
+	securityContext := &corev1.SecurityContext{}
+	if existing != nil {
+		securityContext = existing.DeepCopy()
+	}
+	securityContext.AllowPrivilegeEscalation = nil
+	securityContext.RunAsNonRoot = nil
+	securityContext.Capabilities = nil
+	securityContext.SeccompProfile = nil
+	securityContext.ReadOnlyRootFilesystem = nil
+	if restrictedSecurityContext(plex) {
+		allowPrivilegeEscalation := false
+		runAsNonRoot := true
+		securityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		securityContext.RunAsNonRoot = &runAsNonRoot
+		securityContext.Capabilities = &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		}
+		securityContext.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		}
 	}
-	discovery2 := corev1.ContainerPort{
-		ContainerPort: 32413,
+	if spec != nil && len(spec.DropCapabilities) > 0 {
+		securityContext.Capabilities = &corev1.Capabilities{
+			Drop: spec.DropCapabilities,
+		}
 	}
-	discovery3 := corev1.ContainerPort{
-		ContainerPort: 32414,
+	if readOnlyRootFilesystem(plex) {
+		readOnly := true
+		securityContext.ReadOnlyRootFilesystem = &readOnly
 	}
-	dlnaTCP := corev1.ContainerPort{
-		ContainerPort: 32469,
+	if equality.Semantic.DeepEqual(securityContext, &corev1.SecurityContext{}) {
+		return nil
 	}
+	return securityContext
+}
 
-	for _, port := range existing {
-		if port.ContainerPort == 1900 {
-			dlnaUDP = port
-			continue
-		}
-		if port.ContainerPort == 8324 {
-			rokuPort = port
-			continue
+// renderPodSecurityContext renders the security context for Plex's pod. With the Restricted preset,
+// Plex runs as a non-root user with the runtime's default seccomp profile. On OpenShift, the user
+// and group IDs are left unset so they can be assigned from the namespace's range. The fields the
+// operator manages are rendered from scratch, so that they are cleared when the PlexMediaServer no
+// longer sets them.
+func (r *StatefulSetReconciler) renderPodSecurityContext(plex *plexv1alpha1.PlexMediaServer, existing *corev1.PodSecurityContext) *corev1.PodSecurityContext {
+	podSecurityContext := &corev1.PodSecurityContext{}
+	if existing != nil {
+		podSecurityContext = existing.DeepCopy()
+	}
+	podSecurityContext.RunAsNonRoot = nil
+	podSecurityContext.RunAsUser = nil
+	podSecurityContext.RunAsGroup = nil
+	podSecurityContext.FSGroup = nil
+	podSecurityContext.FSGroupChangePolicy = nil
+	podSecurityContext.SeccompProfile = nil
+	spec := plex.Spec.SecurityContext
+	if spec == nil {
+		if existing == nil {
+			return nil
 		}
-		if port.ContainerPort == 32400 {
-			plexPort = port
-			continue
+		return podSecurityContext
+	}
+	if restrictedSecurityContext(plex) {
+		runAsNonRoot := true
+		fsGroupChangePolicy := corev1.FSGroupChangeOnRootMismatch
+		podSecurityContext.RunAsNonRoot = &runAsNonRoot
+		podSecurityContext.FSGroupChangePolicy = &fsGroupChangePolicy
+		podSecurityContext.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
 		}
-		if port.ContainerPort == 32410 {
-			discovery0 = port
-			continue
+		if !r.Platform.OpenShift {
+			runAsUser := restrictedUserID
+			runAsGroup := restrictedUserID
+			fsGroup := restrictedUserID
+			podSecurityContext.RunAsUser = &runAsUser
+			podSecurityContext.RunAsGroup = &runAsGroup
+			podSecurityContext.FSGroup = &fsGroup
 		}
-		if port.ContainerPort == 32412 {
-			discovery1 = port
+	}
+	if spec.RunAsUser != nil {
+		podSecurityContext.RunAsUser = spec.RunAsUser
+	}
+	if spec.RunAsGroup != nil {
+		podSecurityContext.RunAsGroup = spec.RunAsGroup
+	}
+	if spec.FSGroup != nil {
+		podSecurityContext.FSGroup = spec.FSGroup
+	}
+	if spec.FSGroupChangePolicy != nil {
+		podSecurityContext.FSGroupChangePolicy = spec.FSGroupChangePolicy
+	}
+	if spec.SeccompProfile != nil {
+		podSecurityContext.SeccompProfile = spec.SeccompProfile
+	}
+	return podSecurityContext
+}
+
+// restrictedSecurityContext returns true if Plex uses the Restricted security context preset
+func restrictedSecurityContext(plex *plexv1alpha1.PlexMediaServer) bool {
+	return plex.Spec.SecurityContext != nil && plex.Spec.SecurityContext.Preset == "Restricted"
+}
+
+// readOnlyRootFilesystem returns true if Plex's containers have a read only root filesystem
+func readOnlyRootFilesystem(plex *plexv1alpha1.PlexMediaServer) bool {
+	return plex.Spec.SecurityContext != nil && plex.Spec.SecurityContext.ReadOnlyRootFilesystem
+}
+
+func (r *StatefulSetReconciler) renderPlexEnv(plex *v1alpha1.PlexMediaServer, existing []corev1.EnvVar) []corev1.EnvVar {
+	claimEnv := corev1.EnvVar{
+		Name: "PLEX_CLAIM",
+	}
+	allowedNetworksEnv := corev1.EnvVar{
+		Name: "ALLOWED_NETWORKS",
+	}
+	envVars := []corev1.EnvVar{}
+	for _, env := range existing {
+		if env.Name == "PLEX_CLAIM" {
+			claimEnv = env
 			continue
 		}
-		if port.ContainerPort == 32413 {
-			discovery2 = port
+		// Plex's custom connections are managed through its preferences, which can be changed
+		// without restarting Plex
+		if env.Name == "ADVERTISE_IP" {
 			continue
 		}
-		if port.ContainerPort == 32414 {
-			discovery3 = port
+		if env.Name == "ALLOWED_NETWORKS" {
+			allowedNetworksEnv = env
 			continue
 		}
-		if port.ContainerPort == 32469 {
-			dlnaTCP = port
+		envVars = append(envVars, env)
+	}
+	claimEnv.Value = plex.Spec.ClaimToken
+	envVars = append(envVars, claimEnv)
+	if len(r.lanNetworks) > 0 {
+		allowedNetworksEnv.Value = strings.Join(r.lanNetworks, ",")
+		envVars = append(envVars, allowedNetworksEnv)
+	}
+	return envVars
+}
+
+func (r *StatefulSetReconciler) renderPlexContainerPorts(plex *v1alpha1.PlexMediaServer, existing []corev1.ContainerPort) []corev1.ContainerPort {
+	containerPorts := []corev1.ContainerPort{}
+	existingPlexPorts := map[string]corev1.ContainerPort{}
+	for _, port := range existing {
+		if isPlexPortName(plex, port.Name) {
+			existingPlexPorts[port.Name] = port
 			continue
 		}
 		// Append any other ContainerPorts to the returned slice
 		containerPorts = append(containerPorts, port)
 	}
-
-	if plex.Spec.Networking.EnableDLNA {
-		dlnaUDP.Name = "dlna-udp"
-		dlnaUDP.Protocol = corev1.ProtocolUDP
-		containerPorts = append(containerPorts, dlnaUDP)
-	}
-
-	if plex.Spec.Networking.EnableRoku {
-		rokuPort.Name = "roku"
-		rokuPort.Protocol = corev1.ProtocolTCP
-		containerPorts = append(containerPorts, rokuPort)
-	}
-
-	plexPort.Name = "plex"
-	plexPort.Protocol = corev1.ProtocolTCP
-	containerPorts = append(containerPorts, plexPort)
-
-	if plex.Spec.Networking.EnableDiscovery {
-		discovery0.Name = "discovery-0"
-		discovery0.Protocol = corev1.ProtocolUDP
-
-		discovery1.Name = "discovery-1"
-		discovery1.Protocol = corev1.ProtocolUDP
-
-		discovery2.Name = "discovery-2"
-		discovery2.Protocol = corev1.ProtocolUDP
-
-		discovery3.Name = "discovery-3"
-		discovery3.Protocol = corev1.ProtocolUDP
-
-		containerPorts = append(containerPorts, discovery0, discovery1, discovery2, discovery3)
-	}
-
-	if plex.Spec.Networking.EnableDLNA {
-		dlnaTCP.Name = "dlna-tcp"
-		dlnaTCP.Protocol = corev1.ProtocolTCP
-		containerPorts = append(containerPorts, dlnaTCP)
+	for _, port := range plexPorts(plex) {
+		if !port.enabled {
+			continue
+		}
+		containerPort := existingPlexPorts[port.name]
+		containerPort.Name = port.name
+		containerPort.Protocol = port.protocol
+		containerPort.ContainerPort = port.targetPort
+		containerPorts = append(containerPorts, containerPort)
 	}
-
 	return containerPorts
 }
 
-func (r *StatefulSetReconciler) renderPlexContainerVolumeMounts(existing []corev1.VolumeMount) []corev1.VolumeMount {
+func (r *StatefulSetReconciler) renderPlexContainerVolumeMounts(plex *plexv1alpha1.PlexMediaServer, existing []corev1.VolumeMount) []corev1.VolumeMount {
 	volumeMounts := []corev1.VolumeMount{}
 	configMount := corev1.VolumeMount{Name: "config"}
 	transcodeMount := corev1.VolumeMount{Name: "transcode"}
 	dataMount := corev1.VolumeMount{Name: "data"}
+	certificateMount := corev1.VolumeMount{Name: "certificate"}
+	tmpMount := corev1.VolumeMount{Name: "tmp"}
+	runMount := corev1.VolumeMount{Name: "run"}
 	for _, mount := range existing {
 		if mount.Name == "config" {
 			configMount = mount
 			continue
 		}
+		if mount.Name == "tmp" {
+			tmpMount = mount
+			continue
+		}
+		if mount.Name == "run" {
+			runMount = mount
+			continue
+		}
 		if mount.Name == "transcode" {
 			transcodeMount = mount
 			continue
@@ -302,6 +540,10 @@ func (r *StatefulSetReconciler) renderPlexContainerVolumeMounts(existing []corev
 			dataMount = mount
 			continue
 		}
+		if mount.Name == "certificate" {
+			certificateMount = mount
+			continue
+		}
 		// Append any other volume mounts to the returned slice
 		volumeMounts = append(volumeMounts, mount)
 	}
@@ -309,6 +551,16 @@ func (r *StatefulSetReconciler) renderPlexContainerVolumeMounts(existing []corev
 	transcodeMount.MountPath = "/transcode"
 	dataMount.MountPath = "/data"
 	volumeMounts = append(volumeMounts, configMount, transcodeMount, dataMount)
+	if plex.Spec.TLS != nil {
+		certificateMount.MountPath = certificateMountPath
+		certificateMount.ReadOnly = true
+		volumeMounts = append(volumeMounts, certificateMount)
+	}
+	if readOnlyRootFilesystem(plex) {
+		tmpMount.MountPath = "/tmp"
+		runMount.MountPath = "/run"
+		volumeMounts = append(volumeMounts, tmpMount, runMount)
+	}
 	return volumeMounts
 }
 
@@ -317,7 +569,19 @@ func (r *StatefulSetReconciler) renderPlexPodVolumes(plex *plexv1alpha1.PlexMedi
 	configVolume := corev1.Volume{Name: "config"}
 	transcodeVolume := corev1.Volume{Name: "transcode"}
 	dataVolume := corev1.Volume{Name: "data"}
+	preferencesVolume := corev1.Volume{Name: "preferences"}
+	certificateVolume := corev1.Volume{Name: "certificate"}
+	tmpVolume := corev1.Volume{Name: "tmp"}
+	runVolume := corev1.Volume{Name: "run"}
 	for _, volume := range existing {
+		if volume.Name == "tmp" {
+			tmpVolume = volume
+			continue
+		}
+		if volume.Name == "run" {
+			runVolume = volume
+			continue
+		}
 		if volume.Name == "config" {
 			configVolume = volume
 			continue
@@ -330,6 +594,14 @@ func (r *StatefulSetReconciler) renderPlexPodVolumes(plex *plexv1alpha1.PlexMedi
 			dataVolume = volume
 			continue
 		}
+		if volume.Name == "preferences" {
+			preferencesVolume = volume
+			continue
+		}
+		if volume.Name == "certificate" {
+			certificateVolume = volume
+			continue
+		}
 		volumes = append(volumes, volume)
 	}
 	configVolume.EmptyDir = &corev1.EmptyDirVolumeSource{}
@@ -346,6 +618,30 @@ func (r *StatefulSetReconciler) renderPlexPodVolumes(plex *plexv1alpha1.PlexMedi
 	if plex.Spec.Storage.Data == nil {
 		volumes = append(volumes, dataVolume)
 	}
+
+	if r.preferences != nil {
+		if preferencesVolume.ConfigMap == nil {
+			preferencesVolume.ConfigMap = &corev1.ConfigMapVolumeSource{}
+		}
+		preferencesVolume.ConfigMap.Name = preferencesConfigMapName(plex)
+		volumes = append(volumes, preferencesVolume)
+	}
+
+	if plex.Spec.TLS != nil {
+		if certificateVolume.Secret == nil {
+			certificateVolume.Secret = &corev1.SecretVolumeSource{}
+		}
+		certificateVolume.Secret.SecretName = pkcs12SecretName(plex)
+		volumes = append(volumes, certificateVolume)
+	}
+
+	// Plex writes to /tmp and s6 writes to /run, which are mounted from emptyDir volumes if the
+	// root filesystem is read only
+	if readOnlyRootFilesystem(plex) {
+		tmpVolume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		runVolume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		volumes = append(volumes, tmpVolume, runVolume)
+	}
 	return volumes
 }
 
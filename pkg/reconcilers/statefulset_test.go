@@ -8,10 +8,13 @@ package reconcilers
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/go-logr/logr"
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -22,7 +25,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
@@ -30,15 +35,34 @@ import (
 )
 
 type statefulSetDoubleOptions struct {
-	Replicas        int32
-	Version         string
-	ClaimToken      string
-	IncludeDefaults bool
-	Ready           bool
-	Ports           []corev1.ContainerPort
-	ConfigVolume    *corev1.PersistentVolumeClaimSpec
-	TranscodeVolume *corev1.PersistentVolumeClaimSpec
-	DataVolume      *corev1.PersistentVolumeClaimSpec
+	Replicas           int32
+	Version            string
+	ClaimToken         string
+	AllowedNetworks    string
+	Restricted         bool
+	ReadOnlyRoot       bool
+	PodSecurityContext *corev1.PodSecurityContext
+	Preferences        string
+	CertificateHash    string
+	IncludeDefaults    bool
+	Ready              bool
+	Ports              []corev1.ContainerPort
+	ConfigVolume       *corev1.PersistentVolumeClaimSpec
+	TranscodeVolume    *corev1.PersistentVolumeClaimSpec
+	DataVolume         *corev1.PersistentVolumeClaimSpec
+}
+
+// restartPreferencesDouble removes the custom connections from the rendered preferences, since Plex
+// is not restarted when they change
+func restartPreferencesDouble(rendered string) string {
+	lines := &strings.Builder{}
+	for _, line := range strings.SplitAfter(rendered, "\n") {
+		if line == "" || strings.HasPrefix(line, "customConnections=") {
+			continue
+		}
+		lines.WriteString(line)
+	}
+	return lines.String()
 }
 
 func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions) *appsv1.StatefulSet {
@@ -55,10 +79,15 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 			Protocol:      corev1.ProtocolTCP,
 		}}
 	}
+	terminationGracePeriod := int64(120)
+	automountServiceAccountToken := false
+	labels := plexLabelsDouble(name)
+	labels["app.kubernetes.io/version"] = options.Version
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: namespace,
 			Name:      name,
+			Labels:    labels,
 		},
 		Spec: appsv1.StatefulSetSpec{
 			Selector: &metav1.LabelSelector{
@@ -70,9 +99,7 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 			ServiceName: name,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: map[string]string{
-						"plex.adambkaplan.com/instance": name,
-					},
+					Labels: labels,
 				},
 				Spec: corev1.PodSpec{
 					Containers: []corev1.Container{
@@ -100,8 +127,22 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 									MountPath: "/data",
 								},
 							},
+							StartupProbe:   plexProbeDouble(10, 5, 60),
+							ReadinessProbe: plexProbeDouble(10, 5, 3),
+							LivenessProbe:  plexProbeDouble(30, 10, 5),
+							Lifecycle: &corev1.Lifecycle{
+								PreStop: &corev1.Handler{
+									Exec: &corev1.ExecAction{
+										Command: []string{"/bin/sh", "-c", preStopScript, "pre-stop", "32400"},
+									},
+								},
+							},
 						},
 					},
+					TerminationGracePeriodSeconds: &terminationGracePeriod,
+					ServiceAccountName:            name,
+					AutomountServiceAccountToken:  &automountServiceAccountToken,
+					SecurityContext:               options.PodSecurityContext,
 				},
 			},
 		},
@@ -153,6 +194,108 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 			Spec: *options.DataVolume,
 		})
 	}
+	if options.AllowedNetworks != "" {
+		plexContainer := &statefulSet.Spec.Template.Spec.Containers[0]
+		plexContainer.Env = append(plexContainer.Env, corev1.EnvVar{
+			Name:  "ALLOWED_NETWORKS",
+			Value: options.AllowedNetworks,
+		})
+	}
+	if options.Restricted {
+		allowPrivilegeEscalation := false
+		runAsNonRoot := true
+		statefulSet.Spec.Template.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+			AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+			RunAsNonRoot:             &runAsNonRoot,
+			Capabilities: &corev1.Capabilities{
+				Drop: []corev1.Capability{"ALL"},
+			},
+			SeccompProfile: &corev1.SeccompProfile{
+				Type: corev1.SeccompProfileTypeRuntimeDefault,
+			},
+		}
+	}
+	if options.ReadOnlyRoot {
+		plexContainer := &statefulSet.Spec.Template.Spec.Containers[0]
+		if plexContainer.SecurityContext == nil {
+			plexContainer.SecurityContext = &corev1.SecurityContext{}
+		}
+		readOnly := true
+		plexContainer.SecurityContext.ReadOnlyRootFilesystem = &readOnly
+		plexContainer.VolumeMounts = append(plexContainer.VolumeMounts,
+			corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"},
+			corev1.VolumeMount{Name: "run", MountPath: "/run"},
+		)
+	}
+	// Preferences are always managed when Plex uses a custom certificate
+	if options.Preferences != "" || options.CertificateHash != "" {
+		statefulSet.Spec.Template.ObjectMeta.Annotations = map[string]string{
+			"plex.adambkaplan.com/preferences-hash": preferencesHash(restartPreferencesDouble(options.Preferences)),
+		}
+		statefulSet.Spec.Template.Spec.InitContainers = []corev1.Container{
+			{
+				Name:  "preferences",
+				Image: fmt.Sprintf("docker.io/plexinc/pms-docker:%s", options.Version),
+				Command: []string{
+					"/bin/sh",
+					"/etc/plex-preferences/merge-preferences.sh",
+					"/etc/plex-preferences/preferences",
+				},
+				VolumeMounts: []corev1.VolumeMount{
+					{
+						Name:      "config",
+						MountPath: "/config",
+					},
+					{
+						Name:      "preferences",
+						MountPath: "/etc/plex-preferences",
+						ReadOnly:  true,
+					},
+				},
+			},
+		}
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name: "preferences",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: fmt.Sprintf("%s-preferences", name),
+					},
+				},
+			},
+		})
+		if options.Restricted {
+			initContainer := &statefulSet.Spec.Template.Spec.InitContainers[0]
+			initContainer.SecurityContext = statefulSet.Spec.Template.Spec.Containers[0].SecurityContext.DeepCopy()
+		}
+	}
+	if options.CertificateHash != "" {
+		statefulSet.Spec.Template.ObjectMeta.Annotations["plex.adambkaplan.com/certificate-hash"] = options.CertificateHash
+		certificateMount := corev1.VolumeMount{
+			Name:      "certificate",
+			MountPath: "/etc/plex-certificate",
+			ReadOnly:  true,
+		}
+		initContainer := &statefulSet.Spec.Template.Spec.InitContainers[0]
+		initContainer.Command = append(initContainer.Command, "/etc/plex-certificate/preferences")
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, certificateMount)
+		plexContainer := &statefulSet.Spec.Template.Spec.Containers[0]
+		plexContainer.VolumeMounts = append(plexContainer.VolumeMounts, certificateMount)
+		podVolumes = append(podVolumes, corev1.Volume{
+			Name: "certificate",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: fmt.Sprintf("%s-pkcs12", name),
+				},
+			},
+		})
+	}
+	if options.ReadOnlyRoot {
+		podVolumes = append(podVolumes,
+			corev1.Volume{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			corev1.Volume{Name: "run", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		)
+	}
 	statefulSet.Spec.Template.Spec.Volumes = podVolumes
 	statefulSet.Spec.VolumeClaimTemplates = volumeClaimTemplates
 	if options.IncludeDefaults {
@@ -160,6 +303,10 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 		plexContainer.ImagePullPolicy = corev1.PullAlways
 		plexContainer.TerminationMessagePolicy = corev1.TerminationMessageReadFile
 		plexContainer.TerminationMessagePath = "/dev/termination-log"
+		for _, probe := range []*corev1.Probe{plexContainer.StartupProbe, plexContainer.ReadinessProbe, plexContainer.LivenessProbe} {
+			probe.SuccessThreshold = 1
+			probe.HTTPGet.Scheme = corev1.URISchemeHTTP
+		}
 		statefulSet.Spec.Template.Spec.Containers[0] = plexContainer
 	}
 	if options.Ready {
@@ -172,12 +319,15 @@ func doubleStatefulSet(namespace, name string, options statefulSetDoubleOptions)
 type statefulSetTestCase struct {
 	name                string
 	plex                *v1alpha1.PlexMediaServer
+	platform            Platform
 	existingStatefulSet *appsv1.StatefulSet
+	existingSecrets     []*corev1.Secret
 	expectedStatefulSet *appsv1.StatefulSet
 	errCreate           error
 	errUpdate           error
 	expectError         bool
 	expectRequeue       bool
+	expectedEvents      []string
 }
 
 type statefulSetReconcileSuite struct {
@@ -187,6 +337,11 @@ type statefulSetReconcileSuite struct {
 
 func (test *statefulSetReconcileSuite) SetupTest() {
 	storageClass := "test"
+	restrictedID := int64(1000)
+	customID := int64(2000)
+	runAsNonRoot := true
+	zeroReplicas := int32(0)
+	onRootMismatch := corev1.FSGroupChangeOnRootMismatch
 	test.cases = []statefulSetTestCase{
 		{
 			name: "create with defaults",
@@ -196,6 +351,9 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 					Name:      "test",
 				},
 			},
+			expectedEvents: []string{
+				"Normal Created Created StatefulSet test",
+			},
 			expectRequeue: true,
 			expectedStatefulSet: doubleStatefulSet("test", "test", statefulSetDoubleOptions{
 				Replicas: 1,
@@ -447,6 +605,60 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 				},
 			}),
 		},
+		{
+			name: "create with restricted preset",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "restricted",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					SecurityContext: &v1alpha1.PlexSecurityContextSpec{
+						Preset: "Restricted",
+					},
+				},
+			},
+			expectRequeue: true,
+			expectedStatefulSet: doubleStatefulSet("test", "restricted", statefulSetDoubleOptions{
+				Replicas:   1,
+				Restricted: true,
+				PodSecurityContext: &corev1.PodSecurityContext{
+					RunAsUser:           &restrictedID,
+					RunAsGroup:          &restrictedID,
+					FSGroup:             &restrictedID,
+					RunAsNonRoot:        &runAsNonRoot,
+					FSGroupChangePolicy: &onRootMismatch,
+					SeccompProfile: &corev1.SeccompProfile{
+						Type: corev1.SeccompProfileTypeRuntimeDefault,
+					},
+				},
+			}),
+		},
+		{
+			name: "create with read only root filesystem",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "read-only",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					SecurityContext: &v1alpha1.PlexSecurityContextSpec{
+						RunAsUser:              &customID,
+						FSGroup:                &customID,
+						ReadOnlyRootFilesystem: true,
+					},
+				},
+			},
+			expectRequeue: true,
+			expectedStatefulSet: doubleStatefulSet("test", "read-only", statefulSetDoubleOptions{
+				Replicas:     1,
+				ReadOnlyRoot: true,
+				PodSecurityContext: &corev1.PodSecurityContext{
+					RunAsUser: &customID,
+					FSGroup:   &customID,
+				},
+			}),
+		},
 		{
 			name: "update with version",
 			plex: &v1alpha1.PlexMediaServer{
@@ -467,6 +679,57 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 				Version:         "v1.25",
 				IncludeDefaults: true,
 			}),
+			expectedEvents: []string{
+				"Normal Updated Updated StatefulSet update-version",
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "update with suspend",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-suspend",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Suspend: true,
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-suspend", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-suspend", statefulSetDoubleOptions{
+				Replicas:        0,
+				IncludeDefaults: true,
+			}),
+			expectedEvents: []string{
+				"Normal Updated Updated StatefulSet update-suspend",
+			},
+			expectRequeue: true,
+		},
+		{
+			name: "update with scale to zero",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-scale",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Replicas: &zeroReplicas,
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-scale", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-scale", statefulSetDoubleOptions{
+				Replicas:        0,
+				IncludeDefaults: true,
+			}),
+			expectedEvents: []string{
+				"Normal Updated Updated StatefulSet update-scale",
+			},
 			expectRequeue: true,
 		},
 		{
@@ -488,7 +751,10 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 				Version:         "v1.23",
 				IncludeDefaults: true,
 			}),
-			expectError:   false,
+			expectError: false,
+			expectedEvents: []string{
+				"Normal Conflict StatefulSet no-change was modified concurrently, retrying",
+			},
 			expectRequeue: true,
 		},
 		{
@@ -513,6 +779,183 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 			}),
 			expectRequeue: true,
 		},
+		{
+			name: "update with ingress advertise URL",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-ingress",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								SecretName: "plex-cert",
+							},
+						},
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-ingress", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-ingress", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "customConnections=https://plex.example.com:443\n",
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update with LAN networks",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-lan",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						LANNetworks: &v1alpha1.PlexLANNetworksSpec{
+							CIDRs: []string{"192.168.1.0/24", "10.0.0.0/8"},
+						},
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-lan", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-lan", statefulSetDoubleOptions{
+				Replicas:        1,
+				AllowedNetworks: "192.168.1.0/24,10.0.0.0/8",
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update with preferences",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-preferences",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName:      "Living Room",
+						SecureConnections: "Preferred",
+					},
+					Networking: v1alpha1.PlexNetworkSpec{
+						LANNetworks: &v1alpha1.PlexLANNetworksSpec{
+							CIDRs: []string{"192.168.1.0/24"},
+						},
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-preferences", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-preferences", statefulSetDoubleOptions{
+				Replicas:        1,
+				AllowedNetworks: "192.168.1.0/24",
+				Preferences:     "FriendlyName=Living Room\nLanNetworksBandwidth=192.168.1.0/24\nsecureConnections=1\n",
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update with TLS certificate",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-tls",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					TLS: &v1alpha1.PlexTLSSpec{
+						SecretRef: corev1.LocalObjectReference{
+							Name: "plex-tls",
+						},
+						Domain: "plex.example.com",
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "update-tls", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			existingSecrets: []*corev1.Secret{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "update",
+						Name:      "update-tls-pkcs12",
+					},
+					Data: map[string][]byte{
+						"certificate.p12": []byte("certificate"),
+						"password":        []byte("password"),
+					},
+				},
+			},
+			expectedStatefulSet: doubleStatefulSet("update", "update-tls", statefulSetDoubleOptions{
+				Replicas: 1,
+				CertificateHash: secretHash(&corev1.Secret{
+					Data: map[string][]byte{
+						"certificate.p12": []byte("certificate"),
+						"password":        []byte("password"),
+					},
+				}),
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update remove preferences",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "remove-preferences",
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("update", "remove-preferences", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "FriendlyName=Living Room\n",
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "remove-preferences", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
+		{
+			name: "update with OpenShift route",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "update",
+					Name:      "update-openshift",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{
+							Host:           "plex.apps.example.com",
+							TLSTermination: "edge",
+						},
+					},
+				},
+			},
+			platform: Platform{OpenShift: true},
+			existingStatefulSet: doubleStatefulSet("update", "update-openshift", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			expectedStatefulSet: doubleStatefulSet("update", "update-openshift", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "customConnections=https://plex.apps.example.com:443\n",
+				IncludeDefaults: true,
+			}),
+			expectRequeue: true,
+		},
 		{
 			// Switching the storage to use a persistent volume requires the StatefulSet to be torn
 			// down and re-created.
@@ -535,6 +978,10 @@ func (test *statefulSetReconcileSuite) SetupTest() {
 				Version:         "latest",
 				IncludeDefaults: true,
 			}),
+			expectedEvents: []string{
+				"Normal Recreating Deleting StatefulSet data-pvc to recreate it because its volume claim templates changed: added data",
+				"Normal Deleted Deleted StatefulSet data-pvc",
+			},
 			expectRequeue: true,
 		},
 		{
@@ -897,18 +1344,27 @@ func (test *statefulSetReconcileSuite) TestStatefulSetReconcile() {
 				test.Require().NoError(err, "failed to set controller reference")
 				builder.WithObjects(tc.existingStatefulSet)
 			}
+			for _, secret := range tc.existingSecrets {
+				builder.WithObjects(secret)
+			}
 			client := builder.Build()
+			recorder := record.NewFakeRecorder(100)
 			reconciler := &StatefulSetReconciler{
 				Client: &errorClient{
 					Client:    client,
 					errCreate: tc.errCreate,
 					errUpdate: tc.errUpdate,
 				},
-				Scheme: client.Scheme(),
-				Log:    log,
+				Scheme:   client.Scheme(),
+				Recorder: recorder,
+				Log:      log,
+				Platform: tc.platform,
 			}
 			requeue, err := reconciler.Reconcile(ctx, tc.plex)
 			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
+			if tc.expectedEvents != nil {
+				test.Equal(tc.expectedEvents, recordedEvents(recorder), "recorded events should be equal")
+			}
 			if tc.expectError {
 				test.Error(err, "expected error was not returned")
 				return
@@ -931,6 +1387,21 @@ func (test *statefulSetReconcileSuite) TestStatefulSetReconcile() {
 	}
 }
 
+// plexProbeDouble returns a probe of Plex's /identity endpoint with the given timings
+func plexProbeDouble(periodSeconds, timeoutSeconds, failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: "/identity",
+				Port: intstr.FromInt(32400),
+			},
+		},
+		PeriodSeconds:    periodSeconds,
+		TimeoutSeconds:   timeoutSeconds,
+		FailureThreshold: failureThreshold,
+	}
+}
+
 func plexOwnsStatefulSet(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) bool {
 	for _, ref := range statefulSet.OwnerReferences {
 		if ref.Kind == "PlexMediaServer" && ref.Name == plex.Name && *ref.Controller {
@@ -940,6 +1411,95 @@ func plexOwnsStatefulSet(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.Sta
 	return false
 }
 
+func TestRenderSecurityContextPresetRemoved(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			SecurityContext: &v1alpha1.PlexSecurityContextSpec{
+				Preset:           "Restricted",
+				DropCapabilities: []corev1.Capability{"NET_RAW"},
+			},
+		},
+	}
+	reconciler := &StatefulSetReconciler{Log: logr.Discard()}
+	restricted := reconciler.renderStatefulSetSpec(plex, appsv1.StatefulSetSpec{})
+	podSecurityContext := restricted.Template.Spec.SecurityContext
+	require.NotNil(t, podSecurityContext, "pod security context should be set")
+	require.NotNil(t, podSecurityContext.RunAsUser, "user should be set")
+	require.NotNil(t, podSecurityContext.SeccompProfile, "seccomp profile should be set")
+	plexContainer := findContainer(restricted.Template.Spec.Containers, "plex")
+	require.NotNil(t, plexContainer, "plex container should be rendered")
+	require.NotNil(t, plexContainer.SecurityContext, "container security context should be set")
+	assert.Equal(t, []corev1.Capability{"NET_RAW"}, plexContainer.SecurityContext.Capabilities.Drop, "capabilities should be dropped")
+
+	// Turning off the preset keeps the capabilities that are still dropped
+	plex.Spec.SecurityContext.Preset = ""
+	unrestricted := reconciler.renderStatefulSetSpec(plex, *restricted.DeepCopy())
+	assert.Equal(t, &corev1.PodSecurityContext{}, unrestricted.Template.Spec.SecurityContext, "pod security context should be cleared")
+	plexContainer = findContainer(unrestricted.Template.Spec.Containers, "plex")
+	require.NotNil(t, plexContainer, "plex container should be rendered")
+	assert.Equal(t, &corev1.SecurityContext{
+		Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"NET_RAW"}},
+	}, plexContainer.SecurityContext, "container security context should only drop capabilities")
+
+	// Removing the security context clears everything the operator set
+	plex.Spec.SecurityContext = nil
+	removed := reconciler.renderStatefulSetSpec(plex, *unrestricted.DeepCopy())
+	assert.Equal(t, &corev1.PodSecurityContext{}, removed.Template.Spec.SecurityContext, "pod security context should be cleared")
+	for _, container := range append(removed.Template.Spec.InitContainers, removed.Template.Spec.Containers...) {
+		assert.Nil(t, container.SecurityContext, "security context of container %s should be cleared", container.Name)
+	}
+}
+
+func TestNodeAddressChangeKeepsPodTemplate(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	require.NoError(t, v1alpha1.AddToScheme(s), "failed to add scheme")
+	plex := &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex",
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			Networking: v1alpha1.PlexNetworkSpec{
+				ExternalServiceType: corev1.ServiceTypeNodePort,
+			},
+		},
+	}
+	nodePort := serviceDouble("test", "plex", serviceDoubleOptions{
+		ServiceName: "plex-ext",
+		ServiceType: corev1.ServiceTypeNodePort,
+		Ports: []corev1.ServicePort{
+			{
+				Name:       "plex",
+				Port:       32400,
+				TargetPort: intstr.FromInt(32400),
+				NodePort:   30400,
+				Protocol:   corev1.ProtocolTCP,
+			},
+		},
+	})
+	node := nodeAddressDouble("node-a", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"})
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(plex, nodePort, node).Build()
+	reconciler := NewStatefulSetReconciler(c, logr.Discard(), s, record.NewFakeRecorder(10), Platform{})
+	_, err := reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "failed to create StatefulSet")
+	created := &appsv1.StatefulSet{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "plex"}, created), "failed to get StatefulSet")
+
+	node.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.2"}}
+	require.NoError(t, c.Update(ctx, node), "failed to update Node")
+	_, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "failed to reconcile StatefulSet")
+	updated := &appsv1.StatefulSet{}
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "plex"}, updated), "failed to get StatefulSet")
+	assert.True(t, equality.Semantic.DeepEqual(created.Spec.Template, updated.Spec.Template),
+		"pod template should not change - diff: %s", cmp.Diff(created.Spec.Template, updated.Spec.Template))
+}
+
 func TestStatefulSetSuite(t *testing.T) {
 	suite.Run(t, new(statefulSetReconcileSuite))
 }
@@ -7,32 +7,43 @@ package reconcilers
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/go-logr/logr"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
 )
 
 type StatusReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Platform Platform
 }
 
-func NewStatusReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme) *StatusReconciler {
+func NewStatusReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder, platform Platform) *StatusReconciler {
 	return &StatusReconciler{
-		Client: client,
-		Log:    log,
-		Scheme: scheme,
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+		Platform: platform,
 	}
 }
 
@@ -41,56 +52,109 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMed
 	plex.Status.ObservedGeneration = plex.Generation
 	log := r.Log.WithValues("status.observedGeneration", plex.Generation)
 
-	readyCondition := v1.Condition{
-		Type:               "Ready",
-		ObservedGeneration: plex.Generation,
-		Status:             v1.ConditionUnknown,
+	routes, err := r.renderRouteStatus(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to get route status")
+		return true, err
+	}
+	plex.Status.Routes = routes
+
+	err = r.setRouteCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to get OpenShift route status")
+		return true, err
+	}
+
+	err = r.setCertificateCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to get certificate status")
+		return true, err
+	}
+
+	err = r.setPortsCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to check for port conflicts")
+		return true, err
+	}
+
+	customConnections, err := resolveCustomConnections(ctx, r.Client, plex)
+	if err != nil {
+		log.Error(err, "failed to resolve custom connections")
+		return true, err
+	}
+	plex.Status.CustomConnections = customConnections
+
+	endpoints, err := plexEndpoints(ctx, r.Client, plex)
+	if err != nil {
+		log.Error(err, "failed to resolve endpoints")
+		return true, err
+	}
+	plex.Status.Endpoints = endpoints
+	plex.Status.ExternalURL = ""
+	for _, endpoint := range endpoints {
+		if endpoint.Type != "Internal" {
+			plex.Status.ExternalURL = endpoint.URL
+			break
+		}
+	}
+
+	err = r.setDNSCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to check DNS record")
+		return true, err
+	}
+
+	err = r.setPreferencesCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to check if preferences were applied")
+		return true, err
 	}
 
 	statefulSet := &appsv1.StatefulSet{}
-	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, statefulSet)
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, statefulSet)
 	if err != nil && !errors.IsNotFound(err) {
 		log.WithValues("statefulset", types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}).
 			Error(err, "failed to get object")
 		return true, err
 	}
 	if errors.IsNotFound(err) {
-		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
-			r.conditionStatus(false),
-			"NotFound",
-			"Plex media server deployment not found",
-			readyCondition))
-		err = r.Client.Status().Update(ctx, plex, &client.UpdateOptions{})
-		if errors.IsConflict(err) {
-			log.Info("conflict updating object, requeueing")
-			return true, nil
-		}
-		if err != nil {
-			log.Error(err, "failed to update object")
-			return true, err
-		}
-		log.Info("updated status")
-		return false, nil
+		statefulSet = nil
+	}
+
+	err = r.setServiceCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to check service")
+		return true, err
 	}
 
-	ready := statefulSet.Status.ReadyReplicas > 0
+	err = r.setExternalEndpointCondition(ctx, plex)
+	if err != nil {
+		log.Error(err, "failed to check external service")
+		return true, err
+	}
 
-	if ready {
-		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
-			r.conditionStatus(true),
-			"AsExpected",
-			"Plex media server has at least 1 ready replica",
-			readyCondition,
-		))
-	} else {
-		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
-			r.conditionStatus(false),
-			"NotReady",
-			"Plex media server has no ready replicas",
-			readyCondition,
-		))
+	err = r.setStorageCondition(ctx, plex, statefulSet)
+	if err != nil {
+		log.Error(err, "failed to check storage")
+		return true, err
 	}
 
+	r.setScaleStatus(plex, statefulSet)
+
+	pod, err := r.setPodStatus(ctx, plex, statefulSet)
+	if err != nil {
+		log.Error(err, "failed to get pod status")
+		return true, err
+	}
+	r.setVolumesAccessibleCondition(plex, pod)
+	r.setPodTemplateOverrideCondition(plex, statefulSet)
+	r.setUpdatesStatus(ctx, plex)
+
+	r.setDegradedCondition(plex)
+	r.setProgressingCondition(plex, statefulSet)
+	r.setPlexReachableCondition(ctx, plex, statefulSet)
+	r.setReadyCondition(plex, statefulSet)
+
 	if equality.Semantic.DeepEqual(plex.Status, origPlex.Status) {
 		return false, nil
 	}
@@ -98,6 +162,7 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMed
 	err = r.Client.Status().Update(ctx, plex, &client.UpdateOptions{})
 	if errors.IsConflict(err) {
 		log.Info("conflict updating status, requeuing")
+		r.Recorder.Eventf(plex, corev1.EventTypeNormal, eventReasonConflict, "PlexMediaServer %s status was modified concurrently, retrying", plex.Name)
 		return true, nil
 	}
 	if err != nil {
@@ -105,6 +170,8 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMed
 		return true, err
 	}
 	log.Info("updated status")
+	recordConditionTransitions(r.Recorder, plex, origPlex.Status.Conditions)
+	recordUncleanShutdown(r.Recorder, plex, origPlex.Status.Pod)
 	return false, nil
 }
 
@@ -121,3 +188,938 @@ func (r *StatusReconciler) conditionStatus(b bool) v1.ConditionStatus {
 	}
 	return v1.ConditionFalse
 }
+
+// unstructuredConditionStatus returns the status of a condition read from an unstructured object.
+// Any status other than True or False is reported as Unknown.
+func (r *StatusReconciler) unstructuredConditionStatus(status string) v1.ConditionStatus {
+	switch v1.ConditionStatus(status) {
+	case v1.ConditionTrue, v1.ConditionFalse:
+		return v1.ConditionStatus(status)
+	}
+	return v1.ConditionUnknown
+}
+
+// renderRouteStatus returns the status of the Gateway API routes managed for the Plex Media Server.
+// The Accepted and ResolvedRefs conditions reported by the route's parent Gateways are aggregated,
+// so that a condition is false if any parent reports it as false.
+func (r *StatusReconciler) renderRouteStatus(ctx context.Context, plex *v1alpha1.PlexMediaServer) ([]v1alpha1.PlexRouteStatus, error) {
+	var routeStatuses []v1alpha1.PlexRouteStatus
+	for _, route := range gatewayRoutes(plex) {
+		if !route.enabled {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(route.gvk)
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: route.name}, obj)
+		if meta.IsNoMatchError(err) || errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		routeStatus := v1alpha1.PlexRouteStatus{
+			Kind: route.gvk.Kind,
+			Name: route.name,
+		}
+		parents, _, err := unstructured.NestedSlice(obj.Object, "status", "parents")
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			parentMap, ok := parent.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditions, _, err := unstructured.NestedSlice(parentMap, "conditions")
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range conditions {
+				conditionMap, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				condition := v1.Condition{}
+				err = runtime.DefaultUnstructuredConverter.FromUnstructured(conditionMap, &condition)
+				if err != nil {
+					return nil, err
+				}
+				if condition.Type != "Accepted" && condition.Type != "ResolvedRefs" {
+					continue
+				}
+				existing := meta.FindStatusCondition(routeStatus.Conditions, condition.Type)
+				if existing != nil && routeConditionSeverity(existing.Status) >= routeConditionSeverity(condition.Status) {
+					continue
+				}
+				meta.SetStatusCondition(&routeStatus.Conditions, condition)
+			}
+		}
+		routeStatuses = append(routeStatuses, routeStatus)
+	}
+	return routeStatuses, nil
+}
+
+// setPortsCondition sets the PortsAvailable condition, which reports if ports on Plex's external
+// Service collide with another Plex Media Server. The condition is removed if there is no external
+// Service.
+func (r *StatusReconciler) setPortsCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	if plex.Spec.Networking.ExternalServiceType == "" {
+		if meta.FindStatusCondition(plex.Status.Conditions, "PortsAvailable") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "PortsAvailable")
+		}
+		return nil
+	}
+	conflicts, err := portConflicts(ctx, r.Client, plex)
+	if err != nil {
+		return err
+	}
+	portsCondition := v1.Condition{
+		Type:               "PortsAvailable",
+		ObservedGeneration: plex.Generation,
+	}
+	if len(conflicts) > 0 {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"PortConflict",
+			strings.Join(conflicts, "; "),
+			portsCondition))
+		return nil
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server ports do not conflict with other servers",
+		portsCondition))
+	return nil
+}
+
+// setDNSCondition sets the DNSResolved condition, which reports if Plex's DNS host name resolves
+// to the external Service's load balancer. If the load balancer only has host names, the record is
+// resolved if the host name has any address. The condition and DNS status are removed if a DNS
+// record is not published.
+func (r *StatusReconciler) setDNSCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	mode := dnsMode(plex)
+	if mode == "" {
+		plex.Status.DNS = nil
+		if meta.FindStatusCondition(plex.Status.Conditions, "DNSResolved") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "DNSResolved")
+		}
+		return nil
+	}
+	hostname := plex.Spec.Networking.DNS.Hostname
+	dnsCondition := v1.Condition{
+		Type:               "DNSResolved",
+		ObservedGeneration: plex.Generation,
+	}
+	plex.Status.DNS = &v1alpha1.PlexDNSStatus{
+		Hostname: hostname,
+	}
+	if mode == "DNSEndpoint" {
+		endpoint := &unstructured.Unstructured{}
+		endpoint.SetGroupVersionKind(DNSEndpointGVK)
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, endpoint)
+		if meta.IsNoMatchError(err) {
+			meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+				r.conditionStatus(false),
+				"DNSEndpointAPINotFound",
+				"The externaldns.k8s.io API is not installed on this cluster",
+				dnsCondition))
+			return nil
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	addresses, err := lookupHost(ctx, hostname)
+	if err != nil {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotResolved",
+			fmt.Sprintf("Plex media server host name %s does not resolve: %v", hostname, err),
+			dnsCondition))
+		return nil
+	}
+	sort.Strings(addresses)
+	plex.Status.DNS.Addresses = addresses
+	ips, err := externalServiceIPs(ctx, r.Client, plex)
+	if err != nil {
+		return err
+	}
+	resolved := len(ips) == 0 && len(addresses) > 0
+	for _, address := range addresses {
+		if ips[address] {
+			resolved = true
+			break
+		}
+	}
+	if !resolved {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"AddressMismatch",
+			fmt.Sprintf("Plex media server host name %s does not resolve to the external service's load balancer", hostname),
+			dnsCondition))
+		return nil
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		fmt.Sprintf("Plex media server host name %s resolves to the external service", hostname),
+		dnsCondition))
+	return nil
+}
+
+// setPreferencesCondition sets the PreferencesApplied condition, which reports if Plex was started
+// with the preferences on the PlexMediaServer. Preferences are applied when Plex starts, so they
+// drift from the spec until the StatefulSet's pods are restarted. The condition is removed if
+// preferences are not managed.
+func (r *StatusReconciler) setPreferencesCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	prefs, err := resolvePreferences(ctx, r.Client, r.Log, plex)
+	if err != nil {
+		return err
+	}
+	if prefs == nil {
+		if meta.FindStatusCondition(plex.Status.Conditions, "PreferencesApplied") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "PreferencesApplied")
+		}
+		return nil
+	}
+	preferencesCondition := v1.Condition{
+		Type:               "PreferencesApplied",
+		ObservedGeneration: plex.Generation,
+	}
+	statefulSet := &appsv1.StatefulSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, statefulSet)
+	if errors.IsNotFound(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server deployment not found",
+			preferencesCondition))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	hash := preferencesHash(renderPreferences(restartPreferences(prefs)))
+	if statefulSet.Spec.Template.Annotations[preferencesHashAnnotation] != hash {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"Pending",
+			"Plex media server preferences have not been rolled out",
+			preferencesCondition))
+		return nil
+	}
+	if statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"RollingOut",
+			"Plex media server is restarting to apply preferences",
+			preferencesCondition))
+		return nil
+	}
+	if statefulSet.Status.ReadyReplicas > 0 && plex.Spec.TokenSecretRef != nil {
+		drifted, err := r.driftedPreferences(ctx, plex, prefs)
+		if err != nil {
+			r.Log.Info("failed to get preferences from Plex media server", "error", err.Error())
+		}
+		if len(drifted) > 0 {
+			meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+				r.conditionStatus(false),
+				"Drifted",
+				fmt.Sprintf("Plex media server preferences differ from the desired preferences: %s", strings.Join(drifted, ", ")),
+				preferencesCondition))
+			return nil
+		}
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server was started with the desired preferences",
+		preferencesCondition))
+	return nil
+}
+
+// driftedPreferences returns the sorted names of the desired preferences that the running server
+// reports a different value for. Preferences the server does not report are not checked.
+func (r *StatusReconciler) driftedPreferences(ctx context.Context, plex *v1alpha1.PlexMediaServer, prefs map[string]string) ([]string, error) {
+	plexAPI, err := newPlexAPIClient(ctx, r.Client, plex)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := plexAPI.Preferences(ctx)
+	if err != nil {
+		return nil, err
+	}
+	drifted := []string{}
+	for _, setting := range settings {
+		if desired, ok := prefs[setting.ID]; ok && desired != string(setting.Value) {
+			drifted = append(drifted, setting.ID)
+		}
+	}
+	sort.Strings(drifted)
+	return drifted, nil
+}
+
+// setServiceCondition sets the ServiceAvailable condition, which reports if Plex's headless Service
+// exists.
+func (r *StatusReconciler) setServiceCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	serviceCondition := v1.Condition{
+		Type:               "ServiceAvailable",
+		ObservedGeneration: plex.Generation,
+	}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, &corev1.Service{})
+	if errors.IsNotFound(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server service not found",
+			serviceCondition))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server service is available",
+		serviceCondition))
+	return nil
+}
+
+// setExternalEndpointCondition sets the ExternalEndpointAvailable condition, which reports if the
+// external Service has been assigned a load balancer address or node port. The condition is
+// removed if an external Service is not requested.
+func (r *StatusReconciler) setExternalEndpointCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	serviceType := plex.Spec.Networking.ExternalServiceType
+	if serviceType == "" {
+		if meta.FindStatusCondition(plex.Status.Conditions, "ExternalEndpointAvailable") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "ExternalEndpointAvailable")
+		}
+		return nil
+	}
+	endpointCondition := v1.Condition{
+		Type:               "ExternalEndpointAvailable",
+		ObservedGeneration: plex.Generation,
+	}
+	service := &corev1.Service{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: fmt.Sprintf("%s-ext", plex.Name)}, service)
+	if errors.IsNotFound(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server external service not found",
+			endpointCondition))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if serviceType == corev1.ServiceTypeNodePort {
+		nodePort := int32(0)
+		for _, port := range service.Spec.Ports {
+			if port.Name == "plex" {
+				nodePort = port.NodePort
+			}
+		}
+		if nodePort == 0 {
+			meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+				r.conditionStatus(false),
+				"NodePortPending",
+				"Plex media server external service has not been assigned a node port",
+				endpointCondition))
+			return nil
+		}
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(true),
+			"NodePortAssigned",
+			fmt.Sprintf("Plex media server is available on node port %d", nodePort),
+			endpointCondition))
+		return nil
+	}
+	addresses := []string{}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		}
+		if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+	if len(addresses) == 0 {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"LoadBalancerPending",
+			"Plex media server external service has not been assigned a load balancer address",
+			endpointCondition))
+		return nil
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"LoadBalancerAssigned",
+		fmt.Sprintf("Plex media server is available at load balancer address %s", strings.Join(addresses, ", ")),
+		endpointCondition))
+	return nil
+}
+
+// setStorageCondition sets the StorageReady condition, which reports if the persistent volume
+// claims created from the StatefulSet's volume claim templates are bound. Volumes without a claim
+// template use ephemeral storage, which is always ready.
+func (r *StatusReconciler) setStorageCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) error {
+	storageCondition := v1.Condition{
+		Type:               "StorageReady",
+		ObservedGeneration: plex.Generation,
+	}
+	if statefulSet == nil {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server deployment not found",
+			storageCondition))
+		return nil
+	}
+	pending := []string{}
+	lost := []string{}
+	for _, template := range statefulSet.Spec.VolumeClaimTemplates {
+		claimName := fmt.Sprintf("%s-%s-0", template.Name, statefulSet.Name)
+		claim := &corev1.PersistentVolumeClaim{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: claimName}, claim)
+		if errors.IsNotFound(err) {
+			pending = append(pending, claimName)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		switch claim.Status.Phase {
+		case corev1.ClaimBound:
+		case corev1.ClaimLost:
+			lost = append(lost, claimName)
+		default:
+			pending = append(pending, claimName)
+		}
+	}
+	switch {
+	case len(lost) > 0:
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"ClaimLost",
+			fmt.Sprintf("Plex media server volume claims lost their volumes: %s", strings.Join(lost, ", ")),
+			storageCondition))
+	case len(pending) > 0:
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"ClaimPending",
+			fmt.Sprintf("Plex media server volume claims are not bound: %s", strings.Join(pending, ", ")),
+			storageCondition))
+	default:
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(true),
+			"AsExpected",
+			"Plex media server storage is ready",
+			storageCondition))
+	}
+	return nil
+}
+
+// setPodStatus reports the state of the StatefulSet's pod. The pod status is removed if the pod
+// does not exist.
+func (r *StatusReconciler) setPodStatus(ctx context.Context, plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) (*corev1.Pod, error) {
+	if statefulSet == nil {
+		plex.Status.Pod = nil
+		return nil, nil
+	}
+	pod := &corev1.Pod{}
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: fmt.Sprintf("%s-0", statefulSet.Name)}, pod)
+	if errors.IsNotFound(err) {
+		plex.Status.Pod = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	plex.Status.Pod = plexPodStatus(pod)
+	return pod, nil
+}
+
+// setScaleStatus reports the number of Plex pods and their label selector, which are read by the
+// scale subresource
+func (r *StatusReconciler) setScaleStatus(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) {
+	plex.Status.Selector = fmt.Sprintf("plex.adambkaplan.com/instance=%s", plex.Name)
+	plex.Status.Replicas = 0
+	if statefulSet != nil {
+		plex.Status.Replicas = statefulSet.Status.Replicas
+	}
+}
+
+// setVolumesAccessibleCondition sets the VolumesAccessible condition, which reports the problems
+// found by the volume ownership init container. The condition is unknown until the init container
+// has run, and is removed if volume ownership is not configured. If the Chown mode is not allowed,
+// the condition is false with the ChownNotAllowed reason.
+func (r *StatusReconciler) setVolumesAccessibleCondition(plex *v1alpha1.PlexMediaServer, pod *corev1.Pod) {
+	if plex.Spec.Storage.Ownership == nil {
+		if meta.FindStatusCondition(plex.Status.Conditions, "VolumesAccessible") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "VolumesAccessible")
+		}
+		return
+	}
+	volumesCondition := v1.Condition{
+		Type:               "VolumesAccessible",
+		ObservedGeneration: plex.Generation,
+	}
+	problems, checked := volumesAccessibleMessage(pod)
+	if chownNotAllowed(plex) {
+		// The volumes are checked instead, which is reported along with any problems found
+		message := "Plex media server volume ownership cannot be changed with the Restricted security context, volumes are only checked"
+		if problems != "" {
+			message = fmt.Sprintf("%s: %s", message, problems)
+		}
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"ChownNotAllowed",
+			message,
+			volumesCondition))
+		return
+	}
+	if !checked {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			v1.ConditionUnknown,
+			"NotChecked",
+			"Plex media server volumes have not been checked",
+			volumesCondition))
+		return
+	}
+	if problems != "" {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotAccessible",
+			fmt.Sprintf("Plex media server volumes are not accessible: %s", problems),
+			volumesCondition))
+		return
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server volumes are accessible",
+		volumesCondition))
+}
+
+// setPodTemplateOverrideCondition sets the PodTemplateOverrideApplied condition, which reports if
+// the pod template override could be merged into the StatefulSet's pod template. The condition is
+// removed if there is no override.
+func (r *StatusReconciler) setPodTemplateOverrideCondition(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) {
+	if podTemplateOverride(plex) == "" {
+		if meta.FindStatusCondition(plex.Status.Conditions, "PodTemplateOverrideApplied") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "PodTemplateOverrideApplied")
+		}
+		return
+	}
+	if statefulSet == nil {
+		return
+	}
+	overrideCondition := v1.Condition{
+		Type:               "PodTemplateOverrideApplied",
+		ObservedGeneration: plex.Generation,
+	}
+	err := podTemplateOverrideError(plex, statefulSet)
+	if err != nil {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"InvalidOverride",
+			fmt.Sprintf("Plex media server pod template override is not valid: %v", err),
+			overrideCondition))
+		return
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server pod template override is applied",
+		overrideCondition))
+}
+
+// setProgressingCondition sets the Progressing condition, which reports if the StatefulSet is
+// being created, rolled out, or is waiting for Plex to start. Plex is not progressing if it is
+// degraded. The condition is mirrored to the Reconciling condition read by kstatus.
+func (r *StatusReconciler) setProgressingCondition(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) {
+	progressingCondition := v1.Condition{
+		Type:               "Progressing",
+		ObservedGeneration: plex.Generation,
+	}
+	desiredReplicas := int32(1)
+	if statefulSet != nil && statefulSet.Spec.Replicas != nil {
+		desiredReplicas = *statefulSet.Spec.Replicas
+	}
+	degraded := meta.IsStatusConditionTrue(plex.Status.Conditions, "Degraded")
+	switch {
+	case degraded:
+		progressingCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"Degraded",
+			"Plex media server is degraded",
+			progressingCondition)
+	case statefulSet == nil:
+		progressingCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"Creating",
+			"Plex media server deployment is being created",
+			progressingCondition)
+	case statefulSet.Status.ObservedGeneration < statefulSet.Generation ||
+		statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision:
+		progressingCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"RollingOut",
+			"Plex media server is rolling out a new revision",
+			progressingCondition)
+	case statefulSet.Status.ReadyReplicas < desiredReplicas:
+		progressingCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"Starting",
+			"Plex media server is starting",
+			progressingCondition)
+	default:
+		progressingCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"AsExpected",
+			"Plex media server is up to date",
+			progressingCondition)
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, progressingCondition)
+	progressingCondition.Type = "Reconciling"
+	meta.SetStatusCondition(&plex.Status.Conditions, progressingCondition)
+}
+
+// setDegradedCondition sets the Degraded condition, which reports failures that Plex cannot
+// recover from without intervention: lost storage, an invalid pod template override, or a pod that
+// is crashing, cannot pull its image, or cannot be scheduled. The condition is mirrored to the
+// Stalled condition read by kstatus.
+func (r *StatusReconciler) setDegradedCondition(plex *v1alpha1.PlexMediaServer) {
+	degradedCondition := v1.Condition{
+		Type:               "Degraded",
+		ObservedGeneration: plex.Generation,
+	}
+	storage := meta.FindStatusCondition(plex.Status.Conditions, "StorageReady")
+	override := meta.FindStatusCondition(plex.Status.Conditions, "PodTemplateOverrideApplied")
+	pod := plex.Status.Pod
+	switch {
+	case storage != nil && storage.Reason == "ClaimLost":
+		degradedCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"StorageLost",
+			storage.Message,
+			degradedCondition)
+	case override != nil && override.Reason == "InvalidOverride":
+		degradedCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"InvalidPodTemplateOverride",
+			override.Message,
+			degradedCondition)
+	case pod != nil && degradedPodReasons[pod.Reason]:
+		degradedCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			pod.Reason,
+			podFailureMessage(pod),
+			degradedCondition)
+	default:
+		degradedCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"AsExpected",
+			"Plex media server is not degraded",
+			degradedCondition)
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, degradedCondition)
+	degradedCondition.Type = "Stalled"
+	meta.SetStatusCondition(&plex.Status.Conditions, degradedCondition)
+}
+
+// setPlexReachableCondition sets the PlexReachable condition, which reports if the operator can
+// reach Plex's HTTP API, and reports the identity of the running server. Plex's root endpoint
+// requires a token, so only the identity endpoint's fields are reported if Plex rejects the
+// operator's token. The last reported identity is kept if Plex cannot be reached.
+func (r *StatusReconciler) setPlexReachableCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) {
+	reachableCondition := v1.Condition{
+		Type:               "PlexReachable",
+		ObservedGeneration: plex.Generation,
+	}
+	if statefulSet == nil || statefulSet.Status.ReadyReplicas == 0 {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotRunning",
+			"Plex media server has no ready replicas",
+			reachableCondition))
+		return
+	}
+	plexAPI, err := newPlexAPIClient(ctx, r.Client, plex)
+	if err != nil {
+		r.Log.Error(err, "failed to create Plex API client")
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"ClientError",
+			"Plex media server API client could not be created",
+			reachableCondition))
+		return
+	}
+	identity, err := plexAPI.Identity(ctx)
+	if err != nil {
+		r.Log.Info("failed to get Plex media server identity", "error", err.Error())
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"Unreachable",
+			"Plex media server is not responding to API requests",
+			reachableCondition))
+		return
+	}
+	server := &v1alpha1.PlexServerStatus{
+		MachineIdentifier: identity.MachineIdentifier,
+		Version:           identity.Version,
+		Claimed:           identity.Claimed,
+		AppURL:            plexAppURL(identity.MachineIdentifier),
+	}
+	info, err := plexAPI.ServerInfo(ctx)
+	switch {
+	case err == nil:
+		server.FriendlyName = info.FriendlyName
+		server.Platform = info.Platform
+		server.MyPlexSigninState = info.MyPlexSigninState
+		server.MyPlexUsername = info.MyPlexUsername
+	case !plexapi.IsUnauthorized(err):
+		r.Log.Info("failed to get Plex media server info", "error", err.Error())
+	}
+	plex.Status.Server = server
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(true),
+		"AsExpected",
+		"Plex media server is responding to API requests",
+		reachableCondition))
+}
+
+// setReadyCondition sets the Ready condition from the other conditions, following kstatus
+// conventions: Plex is ready once it is running, its Service and storage are available, and it is
+// neither progressing nor degraded. The reason of the first unmet condition is reported, or the
+// pod's reason if Plex is not running. Ready is false with the Suspended reason while Plex is
+// suspended.
+// PlexReachable is not required, since a NetworkPolicy may block the operator from reaching Plex.
+func (r *StatusReconciler) setReadyCondition(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) {
+	readyCondition := v1.Condition{
+		Type:               "Ready",
+		ObservedGeneration: plex.Generation,
+	}
+	conditions := plex.Status.Conditions
+	unmet := func(conditionType string, status v1.ConditionStatus) *v1.Condition {
+		condition := meta.FindStatusCondition(conditions, conditionType)
+		if condition != nil && condition.Status != status {
+			return condition
+		}
+		return nil
+	}
+	switch {
+	case statefulSet == nil:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server deployment not found",
+			readyCondition)
+	case plexSuspended(plex):
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"Suspended",
+			"Plex media server is suspended",
+			readyCondition)
+	case unmet("Degraded", v1.ConditionFalse) != nil:
+		degraded := unmet("Degraded", v1.ConditionFalse)
+		readyCondition = r.setStatusInfo(r.conditionStatus(false), degraded.Reason, degraded.Message, readyCondition)
+	case statefulSet.Status.ReadyReplicas == 0 && plex.Status.Pod != nil && plex.Status.Pod.Reason != "":
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			plex.Status.Pod.Reason,
+			podFailureMessage(plex.Status.Pod),
+			readyCondition)
+	case statefulSet.Status.ReadyReplicas == 0:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotReady",
+			"Plex media server has no ready replicas",
+			readyCondition)
+	case unmet("ServiceAvailable", v1.ConditionTrue) != nil:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"ServiceUnavailable",
+			unmet("ServiceAvailable", v1.ConditionTrue).Message,
+			readyCondition)
+	case unmet("StorageReady", v1.ConditionTrue) != nil:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"StorageNotReady",
+			unmet("StorageReady", v1.ConditionTrue).Message,
+			readyCondition)
+	case unmet("ExternalEndpointAvailable", v1.ConditionTrue) != nil:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(false),
+			"ExternalEndpointUnavailable",
+			unmet("ExternalEndpointAvailable", v1.ConditionTrue).Message,
+			readyCondition)
+	case unmet("Progressing", v1.ConditionFalse) != nil:
+		progressing := unmet("Progressing", v1.ConditionFalse)
+		readyCondition = r.setStatusInfo(r.conditionStatus(false), progressing.Reason, progressing.Message, readyCondition)
+	default:
+		readyCondition = r.setStatusInfo(
+			r.conditionStatus(true),
+			"AsExpected",
+			"Plex media server has at least 1 ready replica",
+			readyCondition)
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, readyCondition)
+}
+
+// setCertificateCondition sets the CertificateReady condition based on the Ready condition of the
+// cert-manager Certificate for Plex's Ingress. The condition is removed if a Certificate is not
+// requested.
+func (r *StatusReconciler) setCertificateCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	if !certificateRequested(plex) {
+		if meta.FindStatusCondition(plex.Status.Conditions, "CertificateReady") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "CertificateReady")
+		}
+		return nil
+	}
+	certificateCondition := v1.Condition{
+		Type:               "CertificateReady",
+		ObservedGeneration: plex.Generation,
+	}
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(CertificateGVK)
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, certificate)
+	if meta.IsNoMatchError(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"CertificateAPINotFound",
+			"The cert-manager.io API is not installed on this cluster",
+			certificateCondition))
+		return nil
+	}
+	if errors.IsNotFound(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server certificate not found",
+			certificateCondition))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	conditions, _, err := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	if err != nil {
+		return err
+	}
+	for _, c := range conditions {
+		conditionMap, ok := c.(map[string]interface{})
+		if !ok || conditionMap["type"] != "Ready" {
+			continue
+		}
+		status, _, _ := unstructured.NestedString(conditionMap, "status")
+		reason, _, _ := unstructured.NestedString(conditionMap, "reason")
+		message, _, _ := unstructured.NestedString(conditionMap, "message")
+		if reason == "" {
+			reason = "Ready"
+		}
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.unstructuredConditionStatus(status),
+			reason,
+			message,
+			certificateCondition))
+		return nil
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		v1.ConditionUnknown,
+		"Pending",
+		"Plex media server certificate has not been issued by cert-manager",
+		certificateCondition))
+	return nil
+}
+
+// routeConditionSeverity orders the statuses of a route's conditions, so that a parent that rejects
+// the route takes precedence over a parent that has not processed it, which takes precedence over a
+// parent that accepted it
+func routeConditionSeverity(status v1.ConditionStatus) int {
+	switch status {
+	case v1.ConditionFalse:
+		return 2
+	case v1.ConditionUnknown:
+		return 1
+	}
+	return 0
+}
+
+// setRouteCondition sets the RouteAdmitted condition based on the status of Plex's OpenShift Route.
+// The condition is removed if a Route is not requested.
+func (r *StatusReconciler) setRouteCondition(ctx context.Context, plex *v1alpha1.PlexMediaServer) error {
+	if plex.Spec.Networking.Route == nil {
+		if meta.FindStatusCondition(plex.Status.Conditions, "RouteAdmitted") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "RouteAdmitted")
+		}
+		return nil
+	}
+	routeCondition := v1.Condition{
+		Type:               "RouteAdmitted",
+		ObservedGeneration: plex.Generation,
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(RouteGVK)
+	err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, route)
+	if meta.IsNoMatchError(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"RouteAPINotFound",
+			"The route.openshift.io API is not installed on this cluster",
+			routeCondition))
+		return nil
+	}
+	if errors.IsNotFound(err) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"NotFound",
+			"Plex media server route not found",
+			routeCondition))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ingresses, _, err := unstructured.NestedSlice(route.Object, "status", "ingress")
+	if err != nil {
+		return err
+	}
+	for _, ingress := range ingresses {
+		ingressMap, ok := ingress.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditions, _, err := unstructured.NestedSlice(ingressMap, "conditions")
+		if err != nil {
+			return err
+		}
+		for _, c := range conditions {
+			conditionMap, ok := c.(map[string]interface{})
+			if !ok || conditionMap["type"] != "Admitted" {
+				continue
+			}
+			status, _, _ := unstructured.NestedString(conditionMap, "status")
+			reason, _, _ := unstructured.NestedString(conditionMap, "reason")
+			message, _, _ := unstructured.NestedString(conditionMap, "message")
+			if reason == "" {
+				reason = "Admitted"
+			}
+			meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+				r.unstructuredConditionStatus(status),
+				reason,
+				message,
+				routeCondition))
+			return nil
+		}
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		v1.ConditionUnknown,
+		"Pending",
+		"Plex media server route has not been admitted by a router",
+		routeCondition))
+	return nil
+}
@@ -7,18 +7,27 @@ package reconcilers
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"testing"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+	fakeplex "github.com/adambkaplan/plex-operator/pkg/plexapi/fake"
 	"github.com/go-logr/logr"
 	"github.com/stretchr/testify/suite"
 )
@@ -28,6 +37,16 @@ type statusTestCase struct {
 	plex                *v1alpha1.PlexMediaServer
 	expectedStatus      v1alpha1.PlexMediaServerStatus
 	existingStatefulSet *appsv1.StatefulSet
+	existingRoutes      []*unstructured.Unstructured
+	existingCerts       []*unstructured.Unstructured
+	missingAPIs         []schema.GroupVersionKind
+	existingServices    []*corev1.Service
+	existingSecrets     []*corev1.Secret
+	existingClaims      []*corev1.PersistentVolumeClaim
+	existingPods        []*corev1.Pod
+	resolvedHosts       map[string][]string
+	plexSettings        []plexapi.Setting
+	plexReachable       bool
 	expectError         bool
 	expectRequeue       bool
 }
@@ -73,40 +92,1301 @@ func (test *statusReconcileSuite) SetupTest() {
 				Replicas:        1,
 				IncludeDefaults: true,
 			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "not-ready", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "NotReady",
+						Message: "Plex media server has no ready replicas",
+					},
+					{
+						Type:    "Progressing",
+						Status:  metav1.ConditionTrue,
+						Reason:  "Starting",
+						Message: "Plex media server is starting",
+					},
+					{
+						Type:    "Reconciling",
+						Status:  metav1.ConditionTrue,
+						Reason:  "Starting",
+						Message: "Plex media server is starting",
+					},
+					{
+						Type:    "PlexReachable",
+						Status:  metav1.ConditionFalse,
+						Reason:  "NotRunning",
+						Message: "Plex media server has no ready replicas",
+					},
+				},
+			},
+		},
+		{
+			name: "ready",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "ready",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "ready", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "ready", serviceDoubleOptions{}),
+			},
+			plexReachable: true,
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+					{
+						Type:    "ServiceAvailable",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server service is available",
+					},
+					{
+						Type:    "StorageReady",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server storage is ready",
+					},
+					{
+						Type:    "Progressing",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is up to date",
+					},
+					{
+						Type:    "Degraded",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is not degraded",
+					},
+					{
+						Type:    "Stalled",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is not degraded",
+					},
+					{
+						Type:    "PlexReachable",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server is responding to API requests",
+					},
+				},
+				Server: &v1alpha1.PlexServerStatus{
+					MachineIdentifier: "0123456789abcdef0123456789abcdef01234567",
+					Version:           "1.32.5.7349-8f4248874",
+					Claimed:           true,
+					AppURL:            "https://app.plex.tv/desktop/#!/media/0123456789abcdef0123456789abcdef01234567/com.plexapp.plugins.library",
+				},
+			},
+		},
+		{
+			name: "suspended",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "suspended",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Suspend: true,
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "suspended", statefulSetDoubleOptions{
+				IncludeDefaults: true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "suspended", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "Suspended",
+						Message: "Plex media server is suspended",
+					},
+					{
+						Type:    "Progressing",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is up to date",
+					},
+					{
+						Type:    "Degraded",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is not degraded",
+					},
+				},
+				Selector: "plex.adambkaplan.com/instance=suspended",
+			},
+		},
+		{
+			name: "server info",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "server-info",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					TokenSecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "plex-token"},
+						Key:                  "token",
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "server-info", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "server-info", serviceDoubleOptions{}),
+			},
+			existingSecrets: []*corev1.Secret{
+				plexTokenSecretDouble("test", "plex-token", "token"),
+			},
+			plexReachable: true,
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+				},
+				Server: &v1alpha1.PlexServerStatus{
+					MachineIdentifier: "0123456789abcdef0123456789abcdef01234567",
+					Version:           "1.32.5.7349-8f4248874",
+					Claimed:           true,
+					FriendlyName:      "plex",
+					Platform:          "Linux",
+					MyPlexSigninState: "ok",
+					AppURL:            "https://app.plex.tv/desktop/#!/media/0123456789abcdef0123456789abcdef01234567/com.plexapp.plugins.library",
+				},
+			},
+		},
+		{
+			name: "server unreachable",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "server-unreachable",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "server-unreachable", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "server-unreachable", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+					{
+						Type:    "PlexReachable",
+						Status:  metav1.ConditionFalse,
+						Reason:  "Unreachable",
+						Message: "Plex media server is not responding to API requests",
+					},
+				},
+			},
+		},
+		{
+			name: "rolling out",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "rolling-out",
+					Generation: int64(2),
+				},
+			},
+			existingStatefulSet: statefulSetRevisionsDouble(doubleStatefulSet("test", "rolling-out", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}), "rolling-out-1", "rolling-out-2"),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "rolling-out", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(2),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "RollingOut",
+						Message: "Plex media server is rolling out a new revision",
+					},
+					{
+						Type:    "Progressing",
+						Status:  metav1.ConditionTrue,
+						Reason:  "RollingOut",
+						Message: "Plex media server is rolling out a new revision",
+					},
+					{
+						Type:    "Reconciling",
+						Status:  metav1.ConditionTrue,
+						Reason:  "RollingOut",
+						Message: "Plex media server is rolling out a new revision",
+					},
+				},
+			},
+		},
+		{
+			name: "crash loop",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "crash-loop",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "crash-loop", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "crash-loop", serviceDoubleOptions{}),
+			},
+			existingPods: []*corev1.Pod{
+				podDouble("test", "crash-loop-0", corev1.PodStatus{
+					Phase: corev1.PodRunning,
+					ContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: "plex",
+							State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{
+								Reason:  "CrashLoopBackOff",
+								Message: "back-off 10s restarting failed container",
+							}},
+							LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: 1,
+								Reason:   "Error",
+								Message:  "database disk image is malformed",
+							}},
+							RestartCount: 2,
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:   "Ready",
+						Status: metav1.ConditionFalse,
+						Reason: "CrashLoopBackOff",
+						Message: "Plex media server pod crash-loop-0 is failing with CrashLoopBackOff: container plex is waiting: " +
+							"back-off 10s restarting failed container; container plex last exited with code 1 (Error): database disk image is malformed",
+					},
+					{
+						Type:   "Degraded",
+						Status: metav1.ConditionTrue,
+						Reason: "CrashLoopBackOff",
+						Message: "Plex media server pod crash-loop-0 is failing with CrashLoopBackOff: container plex is waiting: " +
+							"back-off 10s restarting failed container; container plex last exited with code 1 (Error): database disk image is malformed",
+					},
+					{
+						Type:    "Progressing",
+						Status:  metav1.ConditionFalse,
+						Reason:  "Degraded",
+						Message: "Plex media server is degraded",
+					},
+				},
+				Pod: &v1alpha1.PlexPodStatus{
+					Name:         "crash-loop-0",
+					Phase:        "Running",
+					Reason:       "CrashLoopBackOff",
+					Message:      "container plex is waiting: back-off 10s restarting failed container",
+					RestartCount: 2,
+					LastTermination: &v1alpha1.PlexContainerTermination{
+						Container: "plex",
+						ExitCode:  1,
+						Reason:    "Error",
+						Message:   "database disk image is malformed",
+					},
+				},
+			},
+		},
+		{
+			name: "unschedulable",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "unschedulable",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "unschedulable", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "unschedulable", serviceDoubleOptions{}),
+			},
+			existingPods: []*corev1.Pod{
+				podDouble("test", "unschedulable-0", corev1.PodStatus{
+					Phase: corev1.PodPending,
+					Conditions: []corev1.PodCondition{
+						{
+							Type:    corev1.PodScheduled,
+							Status:  corev1.ConditionFalse,
+							Reason:  "Unschedulable",
+							Message: "0/3 nodes are available: 3 Insufficient memory.",
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "Unschedulable",
+						Message: "Plex media server pod unschedulable-0 is failing with Unschedulable: 0/3 nodes are available: 3 Insufficient memory.",
+					},
+					{
+						Type:    "Stalled",
+						Status:  metav1.ConditionTrue,
+						Reason:  "Unschedulable",
+						Message: "Plex media server pod unschedulable-0 is failing with Unschedulable: 0/3 nodes are available: 3 Insufficient memory.",
+					},
+				},
+				Pod: &v1alpha1.PlexPodStatus{
+					Name:    "unschedulable-0",
+					Phase:   "Pending",
+					Reason:  "Unschedulable",
+					Message: "0/3 nodes are available: 3 Insufficient memory.",
+				},
+			},
+		},
+		{
+			name: "storage pending",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "storage-pending",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: statefulSetClaimsDouble(doubleStatefulSet("test", "storage-pending", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}), "config", "data"),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "storage-pending", serviceDoubleOptions{}),
+			},
+			existingClaims: []*corev1.PersistentVolumeClaim{
+				claimDouble("test", "config-storage-pending-0", corev1.ClaimBound),
+				claimDouble("test", "data-storage-pending-0", corev1.ClaimPending),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "StorageNotReady",
+						Message: "Plex media server volume claims are not bound: data-storage-pending-0",
+					},
+					{
+						Type:    "StorageReady",
+						Status:  metav1.ConditionFalse,
+						Reason:  "ClaimPending",
+						Message: "Plex media server volume claims are not bound: data-storage-pending-0",
+					},
+					{
+						Type:    "Degraded",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AsExpected",
+						Message: "Plex media server is not degraded",
+					},
+				},
+			},
+		},
+		{
+			name: "storage lost",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "storage-lost",
+					Generation: int64(1),
+				},
+			},
+			existingStatefulSet: statefulSetClaimsDouble(doubleStatefulSet("test", "storage-lost", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}), "config"),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "storage-lost", serviceDoubleOptions{}),
+			},
+			existingClaims: []*corev1.PersistentVolumeClaim{
+				claimDouble("test", "config-storage-lost-0", corev1.ClaimLost),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "StorageLost",
+						Message: "Plex media server volume claims lost their volumes: config-storage-lost-0",
+					},
+					{
+						Type:    "Degraded",
+						Status:  metav1.ConditionTrue,
+						Reason:  "StorageLost",
+						Message: "Plex media server volume claims lost their volumes: config-storage-lost-0",
+					},
+					{
+						Type:    "Stalled",
+						Status:  metav1.ConditionTrue,
+						Reason:  "StorageLost",
+						Message: "Plex media server volume claims lost their volumes: config-storage-lost-0",
+					},
+				},
+			},
+		},
+		{
+			name: "load balancer pending",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "lb-pending",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "lb-pending", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "lb-pending", serviceDoubleOptions{}),
+				serviceDouble("test", "lb-pending", serviceDoubleOptions{
+					ServiceName: "lb-pending-ext",
+					ServiceType: corev1.ServiceTypeLoadBalancer,
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionFalse,
+						Reason:  "ExternalEndpointUnavailable",
+						Message: "Plex media server external service has not been assigned a load balancer address",
+					},
+					{
+						Type:    "ExternalEndpointAvailable",
+						Status:  metav1.ConditionFalse,
+						Reason:  "LoadBalancerPending",
+						Message: "Plex media server external service has not been assigned a load balancer address",
+					},
+				},
+			},
+		},
+		{
+			name: "load balancer assigned",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "lb-assigned",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "lb-assigned", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "lb-assigned", serviceDoubleOptions{}),
+				externalServiceIPDouble("test", "lb-assigned", "203.0.113.10"),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+					{
+						Type:    "ExternalEndpointAvailable",
+						Status:  metav1.ConditionTrue,
+						Reason:  "LoadBalancerAssigned",
+						Message: "Plex media server is available at load balancer address 203.0.113.10",
+					},
+				},
+				CustomConnections: []string{"http://203.0.113.10:32400"},
+				Endpoints: []v1alpha1.PlexEndpoint{
+					{Type: "Internal", URL: "http://lb-assigned.test.svc:32400"},
+					{Type: "LoadBalancer", URL: "http://203.0.113.10:32400"},
+				},
+				ExternalURL: "http://203.0.113.10:32400",
+			},
+		},
+		{
+			name: "route status",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "route",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+						},
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "route", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "route", serviceDoubleOptions{}),
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "test", "route", routeDoubleOptions{
+					PlexName:  "route",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+					Parents: []interface{}{
+						map[string]interface{}{
+							"parentRef":      map[string]interface{}{"name": "gateway"},
+							"controllerName": "example.com/gateway-controller",
+							"conditions": []interface{}{
+								map[string]interface{}{
+									"type":               "Accepted",
+									"status":             "True",
+									"reason":             "Accepted",
+									"message":            "Route was accepted",
+									"lastTransitionTime": "2021-01-01T00:00:00Z",
+								},
+								map[string]interface{}{
+									"type":               "ResolvedRefs",
+									"status":             "False",
+									"reason":             "BackendNotFound",
+									"message":            "Service not found",
+									"lastTransitionTime": "2021-01-01T00:00:00Z",
+								},
+							},
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+				},
+				Routes: []v1alpha1.PlexRouteStatus{
+					{
+						Kind: "HTTPRoute",
+						Name: "route",
+						Conditions: []metav1.Condition{
+							{
+								Type:    "Accepted",
+								Status:  metav1.ConditionTrue,
+								Reason:  "Accepted",
+								Message: "Route was accepted",
+							},
+							{
+								Type:    "ResolvedRefs",
+								Status:  metav1.ConditionFalse,
+								Reason:  "BackendNotFound",
+								Message: "Service not found",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "route status with multiple parents",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "route-parents",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Gateway: &v1alpha1.PlexGatewaySpec{
+							ParentRef: v1alpha1.PlexGatewayParentRef{
+								Name: "gateway",
+							},
+						},
+					},
+				},
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				routeDouble(HTTPRouteGVK, "test", "route-parents", routeDoubleOptions{
+					PlexName:  "route-parents",
+					ParentRef: map[string]interface{}{"name": "gateway"},
+					Port:      32400,
+					Parents: []interface{}{
+						map[string]interface{}{
+							"parentRef":      map[string]interface{}{"name": "gateway"},
+							"controllerName": "example.com/gateway-controller",
+							"conditions": []interface{}{
+								map[string]interface{}{
+									"type":               "Accepted",
+									"status":             "False",
+									"reason":             "NotAllowedByListeners",
+									"message":            "Route is not allowed by the listeners",
+									"lastTransitionTime": "2021-01-01T00:00:00Z",
+								},
+							},
+						},
+						map[string]interface{}{
+							"parentRef":      map[string]interface{}{"name": "other-gateway"},
+							"controllerName": "example.com/gateway-controller",
+							"conditions": []interface{}{
+								map[string]interface{}{
+									"type":               "Accepted",
+									"status":             "True",
+									"reason":             "Accepted",
+									"message":            "Route was accepted",
+									"lastTransitionTime": "2021-01-01T00:00:00Z",
+								},
+							},
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Routes: []v1alpha1.PlexRouteStatus{
+					{
+						Kind: "HTTPRoute",
+						Name: "route-parents",
+						Conditions: []metav1.Condition{
+							{
+								Type:    "Accepted",
+								Status:  metav1.ConditionFalse,
+								Reason:  "NotAllowedByListeners",
+								Message: "Route is not allowed by the listeners",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "openshift route admitted",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "openshift",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{},
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "openshift", statefulSetDoubleOptions{
+				Replicas:        1,
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "openshift", serviceDoubleOptions{}),
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				withRouteIngress(openShiftRouteDouble("test", "openshift", map[string]interface{}{
+					"host": "openshift-test.apps.example.com",
+					"to": map[string]interface{}{
+						"kind": "Service",
+						"name": "openshift",
+					},
+				}), map[string]interface{}{
+					"host":       "openshift-test.apps.example.com",
+					"routerName": "default",
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":   "Admitted",
+							"status": "True",
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "Ready",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server has at least 1 ready replica",
+					},
+					{
+						Type:   "RouteAdmitted",
+						Status: metav1.ConditionTrue,
+						Reason: "Admitted",
+					},
+				},
+			},
+		},
+		{
+			name: "preferences applied",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "preferences-applied",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName: "Living Room",
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "preferences-applied", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "FriendlyName=Living Room\n",
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "preferences-applied", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "PreferencesApplied",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server was started with the desired preferences",
+					},
+				},
+			},
+		},
+		{
+			name: "preferences drifted",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "preferences-drifted",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName: "Living Room",
+					},
+					TokenSecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "plex-token"},
+						Key:                  "token",
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "preferences-drifted", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "FriendlyName=Living Room\n",
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "preferences-drifted", serviceDoubleOptions{}),
+			},
+			existingSecrets: []*corev1.Secret{
+				plexTokenSecretDouble("test", "plex-token", "token"),
+			},
+			plexReachable: true,
+			plexSettings: []plexapi.Setting{
+				{ID: "FriendlyName", Type: "text", Value: "Basement"},
+				{ID: "secureConnections", Type: "int", Value: "1"},
+			},
 			expectedStatus: v1alpha1.PlexMediaServerStatus{
 				ObservedGeneration: int64(1),
 				Conditions: []metav1.Condition{
 					{
-						Type:    "Ready",
+						Type:    "PreferencesApplied",
 						Status:  metav1.ConditionFalse,
-						Reason:  "NotReady",
-						Message: "Plex media server has no ready replicas",
+						Reason:  "Drifted",
+						Message: "Plex media server preferences differ from the desired preferences: FriendlyName",
 					},
 				},
+				Server: &v1alpha1.PlexServerStatus{
+					MachineIdentifier: "0123456789abcdef0123456789abcdef01234567",
+					Version:           "1.32.5.7349-8f4248874",
+					Claimed:           true,
+					FriendlyName:      "plex",
+					Platform:          "Linux",
+					MyPlexSigninState: "ok",
+					AppURL:            "https://app.plex.tv/desktop/#!/media/0123456789abcdef0123456789abcdef01234567/com.plexapp.plugins.library",
+				},
 			},
 		},
 		{
-			name: "ready",
+			name: "preferences not rolled out",
 			plex: &v1alpha1.PlexMediaServer{
 				ObjectMeta: metav1.ObjectMeta{
 					Namespace:  "test",
-					Name:       "ready",
+					Name:       "preferences-pending",
+					Generation: int64(2),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Preferences: &v1alpha1.PlexPreferencesSpec{
+						FriendlyName: "Basement",
+					},
+				},
+			},
+			existingStatefulSet: doubleStatefulSet("test", "preferences-pending", statefulSetDoubleOptions{
+				Replicas:        1,
+				Preferences:     "FriendlyName=Living Room\n",
+				IncludeDefaults: true,
+				Ready:           true,
+			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "preferences-pending", serviceDoubleOptions{}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(2),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "PreferencesApplied",
+						Status:  metav1.ConditionFalse,
+						Reason:  "Pending",
+						Message: "Plex media server preferences have not been rolled out",
+					},
+				},
+			},
+		},
+		{
+			name: "custom connections",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "custom-connections",
 					Generation: int64(1),
 				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						CustomConnections: []string{"http://192.168.1.10:32400"},
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+						},
+					},
+				},
 			},
-			existingStatefulSet: doubleStatefulSet("test", "ready", statefulSetDoubleOptions{
+			existingStatefulSet: doubleStatefulSet("test", "custom-connections", statefulSetDoubleOptions{
 				Replicas:        1,
+				Preferences:     "customConnections=http://192.168.1.10:32400,http://plex.example.com:80\n",
 				IncludeDefaults: true,
 				Ready:           true,
 			}),
+			existingServices: []*corev1.Service{
+				serviceDouble("test", "custom-connections", serviceDoubleOptions{}),
+			},
 			expectedStatus: v1alpha1.PlexMediaServerStatus{
 				ObservedGeneration: int64(1),
 				Conditions: []metav1.Condition{
 					{
-						Type:    "Ready",
+						Type:    "PreferencesApplied",
 						Status:  metav1.ConditionTrue,
 						Reason:  "AsExpected",
-						Message: "Plex media server has at least 1 ready replica",
+						Message: "Plex media server was started with the desired preferences",
+					},
+				},
+				CustomConnections: []string{"http://192.168.1.10:32400", "http://plex.example.com:80"},
+				Endpoints: []v1alpha1.PlexEndpoint{
+					{Type: "Internal", URL: "http://custom-connections.test.svc:32400"},
+					{Type: "Ingress", URL: "http://plex.example.com:80"},
+				},
+				ExternalURL: "http://plex.example.com:80",
+			},
+		},
+		{
+			name: "DNS resolved",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "dns-resolved",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						DNS: &v1alpha1.PlexDNSSpec{
+							Hostname: "plex.example.com",
+						},
+					},
+				},
+			},
+			existingServices: []*corev1.Service{
+				externalServiceIPDouble("test", "dns-resolved", "203.0.113.10"),
+			},
+			resolvedHosts: map[string][]string{
+				"plex.example.com": {"203.0.113.10"},
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "DNSResolved",
+						Status:  metav1.ConditionTrue,
+						Reason:  "AsExpected",
+						Message: "Plex media server host name plex.example.com resolves to the external service",
+					},
+				},
+				CustomConnections: []string{"http://plex.example.com:32400", "http://203.0.113.10:32400"},
+				ExternalURL:       "http://plex.example.com:32400",
+				DNS: &v1alpha1.PlexDNSStatus{
+					Hostname:  "plex.example.com",
+					Addresses: []string{"203.0.113.10"},
+				},
+			},
+		},
+		{
+			name: "DNS address mismatch",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "dns-mismatch",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						DNS: &v1alpha1.PlexDNSSpec{
+							Hostname: "plex.example.com",
+						},
+					},
+				},
+			},
+			existingServices: []*corev1.Service{
+				externalServiceIPDouble("test", "dns-mismatch", "203.0.113.10"),
+			},
+			resolvedHosts: map[string][]string{
+				"plex.example.com": {"198.51.100.1"},
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "DNSResolved",
+						Status:  metav1.ConditionFalse,
+						Reason:  "AddressMismatch",
+						Message: "Plex media server host name plex.example.com does not resolve to the external service's load balancer",
+					},
+				},
+				CustomConnections: []string{"http://plex.example.com:32400", "http://203.0.113.10:32400"},
+				ExternalURL:       "http://plex.example.com:32400",
+				DNS: &v1alpha1.PlexDNSStatus{
+					Hostname:  "plex.example.com",
+					Addresses: []string{"198.51.100.1"},
+				},
+			},
+		},
+		{
+			name: "DNS not resolved",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "dns-not-resolved",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						ExternalServiceType: corev1.ServiceTypeLoadBalancer,
+						DNS: &v1alpha1.PlexDNSSpec{
+							Hostname: "plex.example.com",
+							Mode:     "DNSEndpoint",
+						},
+					},
+				},
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "DNSResolved",
+						Status:  metav1.ConditionFalse,
+						Reason:  "NotResolved",
+						Message: "Plex media server host name plex.example.com does not resolve: lookup plex.example.com: no such host",
+					},
+				},
+				DNS: &v1alpha1.PlexDNSStatus{
+					Hostname: "plex.example.com",
+				},
+			},
+		},
+		{
+			name: "openshift route not found",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "openshift-missing",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{},
+					},
+				},
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "RouteAdmitted",
+						Status:  metav1.ConditionFalse,
+						Reason:  "NotFound",
+						Message: "Plex media server route not found",
+					},
+				},
+			},
+		},
+		{
+			name: "openshift route admitted with unexpected status",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "openshift-unexpected",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Route: &v1alpha1.PlexRouteSpec{},
+					},
+				},
+			},
+			existingRoutes: []*unstructured.Unstructured{
+				withRouteIngress(openShiftRouteDouble("test", "openshift-unexpected", map[string]interface{}{
+					"to": map[string]interface{}{
+						"kind": "Service",
+						"name": "openshift-unexpected",
+					},
+				}), map[string]interface{}{
+					"host":       "openshift-unexpected-test.apps.example.com",
+					"routerName": "default",
+					"conditions": []interface{}{
+						map[string]interface{}{
+							"type":    "Admitted",
+							"reason":  "Pending",
+							"message": "route is being admitted",
+						},
+					},
+				}),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				Conditions: []metav1.Condition{
+					{
+						Type:    "RouteAdmitted",
+						Status:  metav1.ConditionUnknown,
+						Reason:  "Pending",
+						Message: "route is being admitted",
+					},
+				},
+			},
+		},
+		{
+			name: "certificate ready",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "cert-ready",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			existingCerts: []*unstructured.Unstructured{
+				withCertificateReady(certificateDouble("test", "cert-ready", "cert-ready-tls", "plex.example.com", "letsencrypt", "Issuer"),
+					"True", "Ready", "Certificate is up to date and has not expired"),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				CustomConnections:  []string{"https://plex.example.com:443"},
+				ExternalURL:        "https://plex.example.com:443",
+				Conditions: []metav1.Condition{
+					{
+						Type:    "CertificateReady",
+						Status:  metav1.ConditionTrue,
+						Reason:  "Ready",
+						Message: "Certificate is up to date and has not expired",
+					},
+				},
+			},
+		},
+		{
+			name: "certificate ready with unexpected status",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "cert-unexpected",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			existingCerts: []*unstructured.Unstructured{
+				withCertificateReady(certificateDouble("test", "cert-unexpected", "cert-unexpected-tls", "plex.example.com", "letsencrypt", "Issuer"),
+					"Issuing", "Issuing", "Issuing certificate as Secret does not exist"),
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				CustomConnections:  []string{"https://plex.example.com:443"},
+				ExternalURL:        "https://plex.example.com:443",
+				Conditions: []metav1.Condition{
+					{
+						Type:    "CertificateReady",
+						Status:  metav1.ConditionUnknown,
+						Reason:  "Issuing",
+						Message: "Issuing certificate as Secret does not exist",
+					},
+				},
+			},
+		},
+		{
+			name: "certificate not found",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "cert-missing",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				CustomConnections:  []string{"https://plex.example.com:443"},
+				ExternalURL:        "https://plex.example.com:443",
+				Conditions: []metav1.Condition{
+					{
+						Type:    "CertificateReady",
+						Status:  metav1.ConditionFalse,
+						Reason:  "NotFound",
+						Message: "Plex media server certificate not found",
+					},
+				},
+			},
+		},
+		{
+			name: "certificate API not found",
+			plex: &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:  "test",
+					Name:       "cert-no-api",
+					Generation: int64(1),
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ingress: &v1alpha1.PlexIngressSpec{
+							Host: "plex.example.com",
+							TLS: &v1alpha1.PlexIngressTLS{
+								IssuerRef: &v1alpha1.PlexCertificateIssuerRef{
+									Name: "letsencrypt",
+								},
+							},
+						},
+					},
+				},
+			},
+			missingAPIs: []schema.GroupVersionKind{CertificateGVK},
+			expectedStatus: v1alpha1.PlexMediaServerStatus{
+				ObservedGeneration: int64(1),
+				CustomConnections:  []string{"https://plex.example.com:443"},
+				ExternalURL:        "https://plex.example.com:443",
+				Conditions: []metav1.Condition{
+					{
+						Type:    "CertificateReady",
+						Status:  metav1.ConditionFalse,
+						Reason:  "CertificateAPINotFound",
+						Message: "The cert-manager.io API is not installed on this cluster",
 					},
 				},
 			},
@@ -116,6 +1396,10 @@ func (test *statusReconcileSuite) SetupTest() {
 
 func (test *statusReconcileSuite) TestStatusReconcile() {
 	log := logr.Discard()
+	origNewPlexAPIClient := newPlexAPIClient
+	defer func() {
+		newPlexAPIClient = origNewPlexAPIClient
+	}()
 
 	for _, tc := range test.cases {
 		test.Run(tc.name, func() {
@@ -132,11 +1416,53 @@ func (test *statusReconcileSuite) TestStatusReconcile() {
 				test.Require().NoError(err, "failed to set controller reference")
 				builder.WithObjects(tc.existingStatefulSet)
 			}
-			client := builder.Build()
+			for _, route := range tc.existingRoutes {
+				builder.WithObjects(route)
+			}
+			for _, certificate := range tc.existingCerts {
+				builder.WithObjects(certificate)
+			}
+			for _, service := range tc.existingServices {
+				builder.WithObjects(service)
+			}
+			for _, secret := range tc.existingSecrets {
+				builder.WithObjects(secret)
+			}
+			for _, claim := range tc.existingClaims {
+				builder.WithObjects(claim)
+			}
+			for _, pod := range tc.existingPods {
+				builder.WithObjects(pod)
+			}
+			plexServer := fakeplex.NewServer("token")
+			plexServer.Settings = tc.plexSettings
+			defer plexServer.Close()
+			newPlexAPIClient = func(ctx context.Context, c client.Reader, plex *v1alpha1.PlexMediaServer) (*plexapi.Client, error) {
+				if !tc.plexReachable {
+					return plexapi.NewClient("http://127.0.0.1:0", "", nil)
+				}
+				token, err := plexapi.TokenFromSecret(ctx, c, plex)
+				if err != nil && err != plexapi.ErrNoToken {
+					return nil, err
+				}
+				return plexServer.Client(token), nil
+			}
+			lookupHost = func(ctx context.Context, host string) ([]string, error) {
+				addresses, ok := tc.resolvedHosts[host]
+				if !ok {
+					return nil, fmt.Errorf("lookup %s: no such host", host)
+				}
+				return addresses, nil
+			}
+			defer func() {
+				lookupHost = net.DefaultResolver.LookupHost
+			}()
+			client := &noMatchClient{Client: builder.Build(), missingAPIs: tc.missingAPIs}
 			reconciler := &StatusReconciler{
-				Client: client,
-				Scheme: client.Scheme(),
-				Log:    log,
+				Client:   client,
+				Scheme:   client.Scheme(),
+				Recorder: record.NewFakeRecorder(100),
+				Log:      log,
 			}
 			requeue, err := reconciler.Reconcile(ctx, tc.plex)
 			test.Equal(tc.expectRequeue, requeue, "requeue result should be equal")
@@ -158,6 +1484,37 @@ func (test *statusReconcileSuite) TestStatusReconcile() {
 				test.Equal(c.Reason, updated.Reason, "condition reasons for %s are not equal", c.Type)
 				test.Equal(c.Message, updated.Message, "condition messages for %s are not equal", c.Type)
 			}
+			test.Equal(tc.expectedStatus.CustomConnections, updatedPlex.Status.CustomConnections, "custom connections should be equal")
+			test.Equal(tc.expectedStatus.DNS, updatedPlex.Status.DNS, "DNS status should be equal")
+			test.Equal(tc.expectedStatus.Server, updatedPlex.Status.Server, "server status should be equal")
+			test.Equal(tc.expectedStatus.Pod, updatedPlex.Status.Pod, "pod status should be equal")
+			test.Equal(tc.expectedStatus.ExternalURL, updatedPlex.Status.ExternalURL, "external URL should be equal")
+			if tc.expectedStatus.Selector != "" {
+				test.Equal(tc.expectedStatus.Selector, updatedPlex.Status.Selector, "selector should be equal")
+				test.Equal(tc.expectedStatus.Replicas, updatedPlex.Status.Replicas, "replicas should be equal")
+			}
+			if tc.expectedStatus.Endpoints != nil {
+				test.Equal(tc.expectedStatus.Endpoints, updatedPlex.Status.Endpoints, "endpoints should be equal")
+			}
+			test.Equal(len(tc.expectedStatus.Routes), len(updatedPlex.Status.Routes), "number of route statuses should be equal")
+			for i, route := range tc.expectedStatus.Routes {
+				if i >= len(updatedPlex.Status.Routes) {
+					break
+				}
+				updatedRoute := updatedPlex.Status.Routes[i]
+				test.Equal(route.Kind, updatedRoute.Kind, "route kinds should be equal")
+				test.Equal(route.Name, updatedRoute.Name, "route names should be equal")
+				for _, c := range route.Conditions {
+					updated := meta.FindStatusCondition(updatedRoute.Conditions, c.Type)
+					test.NotNil(updated, "route condition %s not found", c.Type)
+					if updated == nil {
+						continue
+					}
+					test.Equal(c.Status, updated.Status, "route condition statuses for %s are not equal", c.Type)
+					test.Equal(c.Reason, updated.Reason, "route condition reasons for %s are not equal", c.Type)
+					test.Equal(c.Message, updated.Message, "route condition messages for %s are not equal", c.Type)
+				}
+			}
 
 		})
 	}
@@ -166,3 +1523,86 @@ func (test *statusReconcileSuite) TestStatusReconcile() {
 func TestStatusSuite(t *testing.T) {
 	suite.Run(t, new(statusReconcileSuite))
 }
+
+// noMatchClient returns a no match error when getting objects whose APIs are not installed
+type noMatchClient struct {
+	client.Client
+	missingAPIs []schema.GroupVersionKind
+}
+
+func (c *noMatchClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	for _, missing := range c.missingAPIs {
+		if gvk == missing {
+			return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+		}
+	}
+	return c.Client.Get(ctx, key, obj)
+}
+
+func withCertificateReady(certificate *unstructured.Unstructured, status, reason, message string) *unstructured.Unstructured {
+	certificate.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":    "Ready",
+				"status":  status,
+				"reason":  reason,
+				"message": message,
+			},
+		},
+	}
+	return certificate
+}
+
+func plexTokenSecretDouble(namespace, name, token string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Data: map[string][]byte{
+			"token": []byte(token),
+		},
+	}
+}
+
+// statefulSetRevisionsDouble sets the current and update revisions of the StatefulSet's status
+func statefulSetRevisionsDouble(statefulSet *appsv1.StatefulSet, current, update string) *appsv1.StatefulSet {
+	statefulSet.Status.CurrentRevision = current
+	statefulSet.Status.UpdateRevision = update
+	return statefulSet
+}
+
+// statefulSetClaimsDouble adds volume claim templates with the given names to the StatefulSet
+func statefulSetClaimsDouble(statefulSet *appsv1.StatefulSet, names ...string) *appsv1.StatefulSet {
+	for _, name := range names {
+		statefulSet.Spec.VolumeClaimTemplates = append(statefulSet.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+		})
+	}
+	return statefulSet
+}
+
+func claimDouble(namespace, name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Status: corev1.PersistentVolumeClaimStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func podDouble(namespace, name string, status corev1.PodStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Status: status,
+	}
+}
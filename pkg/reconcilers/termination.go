@@ -0,0 +1,109 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// defaultTerminationGracePeriodSeconds gives Plex time to stop transcodes and flush its
+	// database before it is killed
+	defaultTerminationGracePeriodSeconds = int64(120)
+
+	// preStopScript stops Plex's active transcodes, then stops the Plex service so that Plex
+	// closes its database before the container is sent SIGTERM. The script is passed Plex's port.
+	// Requests are authenticated with the server's own token from Preferences.xml. Plex is stopped
+	// through the image's s6 supervisor, which would otherwise restart it.
+	preStopScript = `prefs_file="/config/Library/Application Support/Plex Media Server/Preferences.xml"
+url="http://127.0.0.1:${1}"
+token=""
+if [ -f "${prefs_file}" ]; then
+  token=$(sed -n 's/.*PlexOnlineToken="\([^"]*\)".*/\1/p' "${prefs_file}")
+fi
+sessions=$(curl -fsS -H "X-Plex-Token: ${token}" "${url}/transcode/sessions" | sed -n 's/.*<TranscodeSession [^>]*key="\([^"]*\)".*/\1/p')
+for session in ${sessions}; do
+  curl -fsS -o /dev/null -H "X-Plex-Token: ${token}" "${url}/video/:/transcode/universal/stop?session=${session}" || true
+done
+for service in /run/service/plex /var/run/s6/services/plex; do
+  if [ -d "${service}" ] && command -v s6-svc > /dev/null; then
+    s6-svc -wD -d "${service}"
+    exit 0
+  fi
+done
+`
+)
+
+// renderTerminationGracePeriod returns the termination grace period of Plex's pod
+func renderTerminationGracePeriod(plex *v1alpha1.PlexMediaServer) *int64 {
+	gracePeriod := defaultTerminationGracePeriodSeconds
+	if plex.Spec.Termination.GracePeriodSeconds != nil {
+		gracePeriod = *plex.Spec.Termination.GracePeriodSeconds
+	}
+	return &gracePeriod
+}
+
+// renderPlexLifecycle renders the lifecycle hooks of the Plex container on top of the existing
+// hooks. The operator owns the preStop hook, which is removed if it is disabled.
+func renderPlexLifecycle(plex *v1alpha1.PlexMediaServer, existing *corev1.Lifecycle) *corev1.Lifecycle {
+	lifecycle := &corev1.Lifecycle{}
+	if existing != nil {
+		lifecycle = existing.DeepCopy()
+	}
+	lifecycle.PreStop = nil
+	if !plex.Spec.Termination.DisablePreStopHook {
+		port := findPlexPort(plex, "plex").targetPort
+		lifecycle.PreStop = &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"/bin/sh", "-c", preStopScript, "pre-stop", strconv.Itoa(int(port))},
+			},
+		}
+	}
+	if lifecycle.PreStop == nil && lifecycle.PostStart == nil {
+		return nil
+	}
+	return lifecycle
+}
+
+// cleanExitCodes are the exit codes of a Plex container that shut down cleanly: Plex exited on its
+// own, or its supervisor exited on SIGTERM
+var cleanExitCodes = map[int32]bool{
+	0:   true,
+	143: true,
+}
+
+// recordUncleanShutdown records a warning event on the PlexMediaServer if the Plex container
+// terminated with an unclean exit code since the previous pod status was reported
+func recordUncleanShutdown(recorder record.EventRecorder, plex *v1alpha1.PlexMediaServer, previous *v1alpha1.PlexPodStatus) {
+	if plex.Status.Pod == nil || plex.Status.Pod.LastTermination == nil {
+		return
+	}
+	termination := plex.Status.Pod.LastTermination
+	if termination.Container != "plex" || cleanExitCodes[termination.ExitCode] {
+		return
+	}
+	if previous != nil && previous.Name == plex.Status.Pod.Name && previous.LastTermination != nil &&
+		previous.LastTermination.Container == termination.Container &&
+		previous.LastTermination.FinishedAt.Equal(&termination.FinishedAt) {
+		return
+	}
+	message := fmt.Sprintf("Plex container in pod %s did not shut down cleanly: exited with code %d",
+		plex.Status.Pod.Name, termination.ExitCode)
+	if termination.Reason != "" {
+		message = fmt.Sprintf("%s (%s)", message, termination.Reason)
+	}
+	if termination.ExitCode == 137 && termination.Reason != "OOMKilled" {
+		message = fmt.Sprintf("%s. Plex may have been killed before it finished shutting down; consider increasing spec.termination.gracePeriodSeconds from %d",
+			message, *renderTerminationGracePeriod(plex))
+	}
+	recorder.Event(plex, corev1.EventTypeWarning, "UncleanShutdown", message)
+}
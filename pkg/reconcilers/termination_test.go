@@ -0,0 +1,184 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestRenderPlexLifecycle(t *testing.T) {
+	postStart := &corev1.Handler{
+		Exec: &corev1.ExecAction{
+			Command: []string{"/bin/true"},
+		},
+	}
+	preStop := &corev1.Handler{
+		Exec: &corev1.ExecAction{
+			Command: []string{"/bin/sh", "-c", preStopScript, "pre-stop", "32400"},
+		},
+	}
+	cases := []struct {
+		name        string
+		termination v1alpha1.PlexTerminationSpec
+		existing    *corev1.Lifecycle
+		expected    *corev1.Lifecycle
+	}{
+		{
+			name:     "default",
+			expected: &corev1.Lifecycle{PreStop: preStop},
+		},
+		{
+			name: "keep post start hook",
+			existing: &corev1.Lifecycle{
+				PostStart: postStart,
+				PreStop: &corev1.Handler{
+					Exec: &corev1.ExecAction{
+						Command: []string{"/bin/false"},
+					},
+				},
+			},
+			expected: &corev1.Lifecycle{PostStart: postStart, PreStop: preStop},
+		},
+		{
+			name:        "disabled",
+			termination: v1alpha1.PlexTerminationSpec{DisablePreStopHook: true},
+			existing:    &corev1.Lifecycle{PreStop: preStop},
+		},
+		{
+			name:        "disabled with post start hook",
+			termination: v1alpha1.PlexTerminationSpec{DisablePreStopHook: true},
+			existing:    &corev1.Lifecycle{PostStart: postStart, PreStop: preStop},
+			expected:    &corev1.Lifecycle{PostStart: postStart},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Networking: v1alpha1.PlexNetworkSpec{
+						Ports: []v1alpha1.PlexPortSpec{
+							{Name: "plex", Port: 443},
+						},
+					},
+					Termination: tc.termination,
+				},
+			}
+			assert.Equal(t, tc.expected, renderPlexLifecycle(plex, tc.existing), "lifecycle should be equal")
+		})
+	}
+}
+
+func TestRenderTerminationGracePeriod(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{}
+	assert.Equal(t, int64(120), *renderTerminationGracePeriod(plex), "default grace period should be equal")
+	gracePeriod := int64(600)
+	plex.Spec.Termination.GracePeriodSeconds = &gracePeriod
+	assert.Equal(t, int64(600), *renderTerminationGracePeriod(plex), "grace period should be equal")
+}
+
+func TestRecordUncleanShutdown(t *testing.T) {
+	finishedAt := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	cases := []struct {
+		name        string
+		termination *v1alpha1.PlexContainerTermination
+		previous    *v1alpha1.PlexPodStatus
+		expected    []string
+	}{
+		{
+			name:     "no termination",
+			expected: []string{},
+		},
+		{
+			name: "clean shutdown",
+			termination: &v1alpha1.PlexContainerTermination{
+				Container:  "plex",
+				ExitCode:   0,
+				Reason:     "Completed",
+				FinishedAt: finishedAt,
+			},
+			expected: []string{},
+		},
+		{
+			name: "killed after grace period",
+			termination: &v1alpha1.PlexContainerTermination{
+				Container:  "plex",
+				ExitCode:   137,
+				Reason:     "Error",
+				FinishedAt: finishedAt,
+			},
+			expected: []string{
+				"Warning UncleanShutdown Plex container in pod plex-0 did not shut down cleanly: exited with code 137 (Error). " +
+					"Plex may have been killed before it finished shutting down; consider increasing spec.termination.gracePeriodSeconds from 120",
+			},
+		},
+		{
+			name: "out of memory",
+			termination: &v1alpha1.PlexContainerTermination{
+				Container:  "plex",
+				ExitCode:   137,
+				Reason:     "OOMKilled",
+				FinishedAt: finishedAt,
+			},
+			expected: []string{
+				"Warning UncleanShutdown Plex container in pod plex-0 did not shut down cleanly: exited with code 137 (OOMKilled)",
+			},
+		},
+		{
+			name: "already recorded",
+			termination: &v1alpha1.PlexContainerTermination{
+				Container:  "plex",
+				ExitCode:   1,
+				FinishedAt: finishedAt,
+			},
+			previous: &v1alpha1.PlexPodStatus{
+				Name: "plex-0",
+				LastTermination: &v1alpha1.PlexContainerTermination{
+					Container:  "plex",
+					ExitCode:   1,
+					FinishedAt: finishedAt,
+				},
+			},
+			expected: []string{},
+		},
+		{
+			name: "init container",
+			termination: &v1alpha1.PlexContainerTermination{
+				Container:  "preferences",
+				ExitCode:   1,
+				FinishedAt: finishedAt,
+			},
+			expected: []string{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+				Status: v1alpha1.PlexMediaServerStatus{
+					Pod: &v1alpha1.PlexPodStatus{
+						Name:            "plex-0",
+						LastTermination: tc.termination,
+					},
+				},
+			}
+			recorder := record.NewFakeRecorder(10)
+			recordUncleanShutdown(recorder, plex, tc.previous)
+			assert.Equal(t, tc.expected, recordedEvents(recorder), "recorded events should be equal")
+		})
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"software.sslmate.com/src/go-pkcs12"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// certificateHashAnnotation is set on Plex's pod template so that Plex is restarted when its
+	// certificate is renewed
+	certificateHashAnnotation = "plex.adambkaplan.com/certificate-hash"
+
+	// sourceHashAnnotation records the hash of the TLS Secret that the PKCS#12 Secret was
+	// converted from
+	sourceHashAnnotation = "plex.adambkaplan.com/source-hash"
+
+	// certificateMountPath is where the PKCS#12 Secret is mounted in Plex's containers
+	certificateMountPath = "/etc/plex-certificate"
+)
+
+// pkcs12SecretName returns the name of the Secret holding Plex's PKCS#12 certificate
+func pkcs12SecretName(plex *v1alpha1.PlexMediaServer) string {
+	return fmt.Sprintf("%s-pkcs12", plex.Name)
+}
+
+// secretHash returns the hash of the Secret's data
+func secretHash(secret *corev1.Secret) string {
+	keys := []string{}
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s\n", key)
+		hash.Write(secret.Data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// TLSSecretReconciler reconciles the Secret holding Plex Media Server's certificate in PKCS#12
+// format. The certificate is converted from the kubernetes.io/tls Secret referenced on the
+// PlexMediaServer.
+type TLSSecretReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+// NewTLSSecretReconciler returns a new Reconciler that reconciles the PKCS#12 Secret for Plex
+// Media Server
+func NewTLSSecretReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *TLSSecretReconciler {
+	return &TLSSecretReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+// Reconcile reconciles the PKCS#12 Secret with the desired state of the PlexMediaServer
+func (r *TLSSecretReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	origSecret := &corev1.Secret{}
+	namespacedName := types.NamespacedName{Namespace: plex.Namespace, Name: pkcs12SecretName(plex)}
+	log := r.Log.WithValues("secret", namespacedName)
+	err := r.Client.Get(ctx, namespacedName, origSecret)
+	if err != nil && !errors.IsNotFound(err) {
+		return true, err
+	}
+	exists := err == nil
+
+	// If TLS is removed, we no longer need the Secret
+	if plex.Spec.TLS == nil {
+		if !exists {
+			return false, nil
+		}
+		log.Info("deleting")
+		background := metav1.DeletePropagationBackground
+		err = r.Client.Delete(ctx, origSecret, &client.DeleteOptions{
+			PropagationPolicy: &background,
+		})
+		recordDeleted(r.Recorder, plex, "Secret", namespacedName.Name, err)
+		if err != nil {
+			return true, err
+		}
+		return true, nil
+	}
+
+	sourceSecret := &corev1.Secret{}
+	sourceName := types.NamespacedName{Namespace: plex.Namespace, Name: plex.Spec.TLS.SecretRef.Name}
+	err = r.Client.Get(ctx, sourceName, sourceSecret)
+	if errors.IsNotFound(err) {
+		// The Secret is watched, so Plex is reconciled again once it is created
+		log.Info("waiting for TLS secret", "tlsSecret", sourceName)
+		return false, nil
+	}
+	if err != nil {
+		return true, err
+	}
+
+	if !exists {
+		log.Info("creating")
+		origSecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: plex.Namespace,
+				Name:      pkcs12SecretName(plex),
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		if err = r.renderSecret(plex, sourceSecret, origSecret); err != nil {
+			log.Error(err, "failed to convert TLS secret", "tlsSecret", sourceName)
+			return true, err
+		}
+		renderObjectMeta(plex, origSecret)
+		ctrl.SetControllerReference(plex, origSecret, r.Scheme)
+		err = r.Client.Create(ctx, origSecret, &client.CreateOptions{})
+		recordCreated(r.Recorder, plex, "Secret", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to create object")
+			return true, err
+		}
+		log.Info("created object")
+		return true, nil
+	}
+
+	desiredSecret := origSecret.DeepCopy()
+	renderObjectMeta(plex, desiredSecret)
+	if err = r.renderSecret(plex, sourceSecret, desiredSecret); err != nil {
+		log.Error(err, "failed to convert TLS secret", "tlsSecret", sourceName)
+		return true, err
+	}
+	if !equality.Semantic.DeepEqual(origSecret.Data, desiredSecret.Data) ||
+		objectMetaChanged(origSecret, desiredSecret) {
+		log.Info("updating")
+		err = r.Update(ctx, desiredSecret, &client.UpdateOptions{})
+		if errors.IsConflict(err) {
+			log.Info("conflict on update, requeueing")
+			recordConflict(r.Recorder, plex, "Secret", namespacedName.Name)
+			return true, nil
+		}
+		recordUpdated(r.Recorder, plex, "Secret", namespacedName.Name, err)
+		if err != nil {
+			log.Error(err, "failed to update object")
+			return true, err
+		}
+		log.Info("updated object")
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// renderSecret renders the PKCS#12 certificate, its password, and the preferences Plex needs to
+// load it on top of the Secret. The certificate is only converted when the TLS Secret changes,
+// since each conversion uses a new random salt.
+func (r *TLSSecretReconciler) renderSecret(plex *v1alpha1.PlexMediaServer, source *corev1.Secret, secret *corev1.Secret) error {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	sourceHash := secretHash(&corev1.Secret{
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       source.Data[corev1.TLSCertKey],
+			corev1.TLSPrivateKeyKey: source.Data[corev1.TLSPrivateKeyKey],
+		},
+	})
+	password := string(secret.Data["password"])
+	if password == "" || len(secret.Data["certificate.p12"]) == 0 || secret.Annotations[sourceHashAnnotation] != sourceHash {
+		if password == "" {
+			passwordBytes := make([]byte, 32)
+			if _, err := rand.Read(passwordBytes); err != nil {
+				return err
+			}
+			password = hex.EncodeToString(passwordBytes)
+		}
+		pfxData, err := encodePKCS12(source.Data[corev1.TLSCertKey], source.Data[corev1.TLSPrivateKeyKey], password)
+		if err != nil {
+			return err
+		}
+		secret.Data["certificate.p12"] = pfxData
+		secret.Data["password"] = []byte(password)
+		secret.Annotations[sourceHashAnnotation] = sourceHash
+	}
+	secret.Data["preferences"] = []byte(renderPreferences(map[string]string{
+		"customCertificatePath":   fmt.Sprintf("%s/certificate.p12", certificateMountPath),
+		"customCertificateKey":    password,
+		"customCertificateDomain": plex.Spec.TLS.Domain,
+	}))
+	return nil
+}
+
+// encodePKCS12 converts a PEM certificate chain and private key into a password protected PKCS#12
+// file. The first certificate in the chain must be the certificate for the private key.
+func encodePKCS12(certPEM []byte, keyPEM []byte, password string) ([]byte, error) {
+	var certificate *x509.Certificate
+	caCerts := []*x509.Certificate{}
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if certificate == nil {
+			certificate = cert
+			continue
+		}
+		caCerts = append(caCerts, cert)
+	}
+	if certificate == nil {
+		return nil, fmt.Errorf("no certificate found in %s", corev1.TLSCertKey)
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, fmt.Errorf("no private key found in %s", corev1.TLSPrivateKeyKey)
+	}
+	privateKey, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return pkcs12.Modern.Encode(privateKey, certificate, caCerts, password)
+}
+
+// parsePrivateKey parses a DER encoded PKCS#1, PKCS#8, or EC private key
+func parsePrivateKey(der []byte) (crypto.PrivateKey, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key in %s", corev1.TLSPrivateKeyKey)
+}
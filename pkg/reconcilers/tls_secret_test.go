@@ -0,0 +1,171 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestTLSSecretReconcile(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	err := v1alpha1.AddToScheme(s)
+	require.NoError(t, err, "failed to add scheme")
+	plex := tlsPlexDouble("tls", "plex", "plex.example.com")
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(plex).Build()
+	reconciler := NewTLSSecretReconciler(c, logr.Discard(), s, record.NewFakeRecorder(100))
+	namespacedName := types.NamespacedName{Namespace: "tls", Name: "plex-pkcs12"}
+
+	// Wait for the TLS secret to be issued
+	requeue, err := reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.False(t, requeue, "should not requeue while the TLS secret does not exist")
+	err = c.Get(ctx, namespacedName, &corev1.Secret{})
+	assert.True(t, errors.IsNotFound(err), "expected PKCS#12 secret to not exist")
+
+	// Convert the TLS secret
+	tlsSecret := tlsSecretDouble(t, "tls", "plex-tls", "plex.example.com")
+	err = c.Create(ctx, tlsSecret)
+	require.NoError(t, err, "failed to create TLS secret")
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after creating the PKCS#12 secret")
+	created := &corev1.Secret{}
+	err = c.Get(ctx, namespacedName, created)
+	require.NoError(t, err, "failed to get PKCS#12 secret")
+	password := string(created.Data["password"])
+	require.NotEmpty(t, password, "password should be generated")
+	_, certificate, _, err := pkcs12.DecodeChain(created.Data["certificate.p12"], password)
+	require.NoError(t, err, "failed to decode PKCS#12 certificate")
+	assert.Equal(t, "plex.example.com", certificate.Subject.CommonName, "certificate common name should be equal")
+	assert.Equal(t, "customCertificateDomain=plex.example.com\n"+
+		"customCertificateKey="+password+"\n"+
+		"customCertificatePath=/etc/plex-certificate/certificate.p12\n",
+		string(created.Data["preferences"]), "certificate preferences should be equal")
+
+	// The certificate is not converted again if the TLS secret does not change
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.False(t, requeue, "should not requeue if the TLS secret did not change")
+
+	// Renewing the certificate converts it again with the same password
+	renewed := tlsSecretDouble(t, "tls", "plex-tls", "plex.example.com")
+	tlsSecret.Data = renewed.Data
+	err = c.Update(ctx, tlsSecret)
+	require.NoError(t, err, "failed to update TLS secret")
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after updating the PKCS#12 secret")
+	updated := &corev1.Secret{}
+	err = c.Get(ctx, namespacedName, updated)
+	require.NoError(t, err, "failed to get PKCS#12 secret")
+	assert.Equal(t, password, string(updated.Data["password"]), "password should not change")
+	assert.NotEqual(t, created.Data["certificate.p12"], updated.Data["certificate.p12"], "certificate should be converted again")
+	assert.NotEqual(t, secretHash(created), secretHash(updated), "certificate hash should change")
+
+	// Removing TLS deletes the secret
+	plex.Spec.TLS = nil
+	requeue, err = reconciler.Reconcile(ctx, plex)
+	require.NoError(t, err, "unexpected error from reconcile")
+	assert.True(t, requeue, "should requeue after deleting the PKCS#12 secret")
+	err = c.Get(ctx, namespacedName, &corev1.Secret{})
+	assert.True(t, errors.IsNotFound(err), "expected PKCS#12 secret to be deleted")
+}
+
+func TestTLSSecretReconcileInvalid(t *testing.T) {
+	ctx := context.TODO()
+	s := scheme.Scheme
+	err := v1alpha1.AddToScheme(s)
+	require.NoError(t, err, "failed to add scheme")
+	plex := tlsPlexDouble("tls", "invalid", "plex.example.com")
+	tlsSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "tls",
+			Name:      "plex-tls",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("not a certificate"),
+			corev1.TLSPrivateKeyKey: []byte("not a key"),
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(plex, tlsSecret).Build()
+	reconciler := NewTLSSecretReconciler(c, logr.Discard(), s, record.NewFakeRecorder(100))
+	requeue, err := reconciler.Reconcile(ctx, plex)
+	assert.Error(t, err, "expected error converting invalid TLS secret")
+	assert.True(t, requeue, "should requeue on error")
+}
+
+func tlsPlexDouble(namespace, name, domain string) *v1alpha1.PlexMediaServer {
+	return &v1alpha1.PlexMediaServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Spec: v1alpha1.PlexMediaServerSpec{
+			TLS: &v1alpha1.PlexTLSSpec{
+				SecretRef: corev1.LocalObjectReference{
+					Name: "plex-tls",
+				},
+				Domain: domain,
+			},
+		},
+	}
+}
+
+// tlsSecretDouble returns a kubernetes.io/tls Secret with a new self-signed certificate
+func tlsSecretDouble(t *testing.T, namespace, name, commonName string) *corev1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err, "failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject: pkix.Name{
+			CommonName: commonName,
+		},
+		DNSNames:  []string{commonName},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err, "failed to create certificate")
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err, "failed to marshal key")
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+			corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
@@ -0,0 +1,357 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+)
+
+const (
+	// versionAnnotation records the version of Plex Media Server the operator deploys with
+	// automatic updates
+	versionAnnotation = "plex.adambkaplan.com/version"
+
+	// defaultUpdatePollInterval is how often plex.tv is checked for a new release by default
+	defaultUpdatePollInterval = 6 * time.Hour
+
+	// plexDownloadsPlatform and plexImageDistro select the build of Plex Media Server that the
+	// plexinc/pms-docker image installs
+	plexDownloadsPlatform = "Linux"
+	plexImageDistro       = "debian"
+)
+
+// newPlexDownloadsClient returns a client for plex.tv's downloads endpoint. Requests are
+// authenticated with the referenced X-Plex-Token, if any, which the Beta channel requires. Tests
+// replace it to use a fake Plex server.
+var newPlexDownloadsClient = func(ctx context.Context, c client.Reader, plex *v1alpha1.PlexMediaServer) (*plexapi.Client, error) {
+	token, err := plexapi.TokenFromSecret(ctx, c, plex)
+	if err != nil && err != plexapi.ErrNoToken {
+		return nil, err
+	}
+	return plexapi.NewClient(plexapi.PlexTVURL, token, nil)
+}
+
+// timeNow returns the current time. Tests replace it to check for updates at a fixed time.
+var timeNow = time.Now
+
+// UpdatesReconciler deploys new versions of Plex Media Server found by automatic updates. The
+// version the operator deploys is recorded in an annotation on the PlexMediaServer, so that it is
+// kept if the PlexMediaServer's status is lost. It runs before the other reconcilers, so that every
+// object is rendered for the same version.
+type UpdatesReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+}
+
+func NewUpdatesReconciler(client client.Client, log logr.Logger, scheme *runtime.Scheme, recorder record.EventRecorder) *UpdatesReconciler {
+	return &UpdatesReconciler{
+		Client:   client,
+		Log:      log,
+		Scheme:   scheme,
+		Recorder: recorder,
+	}
+}
+
+func (r *UpdatesReconciler) Reconcile(ctx context.Context, plex *v1alpha1.PlexMediaServer) (bool, error) {
+	current, recorded := plex.Annotations[versionAnnotation]
+	if plex.Spec.Updates == nil {
+		if !recorded {
+			return false, nil
+		}
+		return r.updateVersionAnnotation(ctx, plex, "")
+	}
+
+	version := current
+	switch {
+	case versionPinned(plex):
+		version = plex.Spec.Version
+	case version == "":
+		statefulSet := &appsv1.StatefulSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}, statefulSet)
+		if err != nil && !errors.IsNotFound(err) {
+			r.Log.WithValues("statefulset", types.NamespacedName{Namespace: plex.Namespace, Name: plex.Name}).
+				Error(err, "failed to get object")
+			return true, err
+		}
+		if errors.IsNotFound(err) {
+			statefulSet = nil
+		}
+		version = deployedVersion(plex, statefulSet)
+	}
+	previous := version
+	if !versionPinned(plex) && updatePending(plex, version) {
+		open, _, err := inMaintenanceWindow(plex.Spec.Updates, timeNow())
+		if err == nil && open {
+			version = plex.Status.Updates.AvailableVersion
+		}
+	}
+	if recorded && version == current {
+		return false, nil
+	}
+	requeue, err := r.updateVersionAnnotation(ctx, plex, version)
+	if err == nil && version != previous {
+		r.Recorder.Eventf(plex, corev1.EventTypeNormal, "Updating", "Updating Plex media server from %s to %s", previous, version)
+	}
+	return requeue, err
+}
+
+// updateVersionAnnotation records the version of Plex Media Server the operator deploys on the
+// PlexMediaServer, or removes the annotation if the version is empty
+func (r *UpdatesReconciler) updateVersionAnnotation(ctx context.Context, plex *v1alpha1.PlexMediaServer, version string) (bool, error) {
+	log := r.Log.WithValues("annotation", versionAnnotation, "version", version)
+	origPlex := plex.DeepCopy()
+	annotations := map[string]string{}
+	for k, v := range plex.Annotations {
+		annotations[k] = v
+	}
+	if version == "" {
+		delete(annotations, versionAnnotation)
+	} else {
+		annotations[versionAnnotation] = version
+	}
+	plex.SetAnnotations(annotations)
+	err := r.Client.Patch(ctx, plex, client.MergeFrom(origPlex))
+	if err != nil {
+		log.Error(err, "failed to update the deployed version")
+		return true, err
+	}
+	log.Info("updated the deployed version")
+	return false, nil
+}
+
+// plexVersion returns the version of Plex Media Server to deploy. With automatic updates, this is
+// the version the operator last updated Plex to, unless the PlexMediaServer pins a version.
+func plexVersion(plex *v1alpha1.PlexMediaServer) string {
+	if plex.Spec.Updates != nil && !versionPinned(plex) && plex.Annotations[versionAnnotation] != "" {
+		return plex.Annotations[versionAnnotation]
+	}
+	if plex.Spec.Version == "" {
+		return "latest"
+	}
+	return plex.Spec.Version
+}
+
+// versionPinned returns true if the PlexMediaServer sets a version other than latest, which pauses
+// automatic updates
+func versionPinned(plex *v1alpha1.PlexMediaServer) bool {
+	return plex.Spec.Version != "" && plex.Spec.Version != "latest"
+}
+
+// updatePending returns true if a version newer than the given version is available
+func updatePending(plex *v1alpha1.PlexMediaServer, version string) bool {
+	return plex.Status.Updates != nil && newerVersion(plex.Status.Updates.AvailableVersion, version)
+}
+
+// deployedVersion returns the version of Plex Media Server in the StatefulSet's plex container, or
+// the PlexMediaServer's version if the StatefulSet does not exist
+func deployedVersion(plex *v1alpha1.PlexMediaServer, statefulSet *appsv1.StatefulSet) string {
+	if statefulSet != nil {
+		for _, container := range statefulSet.Spec.Template.Spec.Containers {
+			if container.Name != "plex" {
+				continue
+			}
+			image := container.Image[strings.LastIndex(container.Image, "/")+1:]
+			if i := strings.LastIndex(image, ":"); i >= 0 {
+				return image[i+1:]
+			}
+		}
+	}
+	if plex.Spec.Version == "" {
+		return "latest"
+	}
+	return plex.Spec.Version
+}
+
+// updatePollInterval returns how often plex.tv is checked for a new release
+func updatePollInterval(updates *v1alpha1.PlexUpdatesSpec) time.Duration {
+	if updates.PollInterval == nil || updates.PollInterval.Duration <= 0 {
+		return defaultUpdatePollInterval
+	}
+	return updates.PollInterval.Duration
+}
+
+// setUpdatesStatus checks plex.tv for a new release once per poll interval, and reports the
+// available and deployed versions. The UpToDate condition reports whether an update is pending,
+// and why. The UpdatesReconciler deploys the available version when the maintenance window opens.
+func (r *StatusReconciler) setUpdatesStatus(ctx context.Context, plex *v1alpha1.PlexMediaServer) {
+	updates := plex.Spec.Updates
+	if updates == nil {
+		plex.Status.Updates = nil
+		if meta.FindStatusCondition(plex.Status.Conditions, "UpToDate") != nil {
+			meta.RemoveStatusCondition(&plex.Status.Conditions, "UpToDate")
+		}
+		return
+	}
+	status := &v1alpha1.PlexUpdatesStatus{}
+	if plex.Status.Updates != nil {
+		status = plex.Status.Updates.DeepCopy()
+	}
+	status.CurrentVersion = plexVersion(plex)
+	plex.Status.Updates = status
+
+	now := timeNow()
+	if status.LastChecked == nil || !now.Before(status.LastChecked.Add(updatePollInterval(updates))) {
+		// plex.tv is not checked again until the next poll, even if the check failed
+		checked := metav1.NewTime(now.Truncate(time.Second))
+		status.LastChecked = &checked
+		version, err := r.latestVersion(ctx, plex)
+		if err != nil {
+			r.Log.Error(err, "failed to check plex.tv for updates")
+			r.Recorder.Eventf(plex, corev1.EventTypeWarning, "UpdateCheckFailed", "Failed to check plex.tv for Plex media server updates: %v", err)
+		} else {
+			status.AvailableVersion = version
+		}
+	}
+
+	upToDateCondition := metav1.Condition{
+		Type:               "UpToDate",
+		ObservedGeneration: plex.Generation,
+	}
+	if status.AvailableVersion == "" {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			metav1.ConditionUnknown,
+			"NotChecked",
+			"Plex media server has not been checked for updates",
+			upToDateCondition))
+		return
+	}
+	if !newerVersion(status.AvailableVersion, status.CurrentVersion) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(true),
+			"AsExpected",
+			fmt.Sprintf("Plex media server %s is up to date", status.CurrentVersion),
+			upToDateCondition))
+		return
+	}
+	if versionPinned(plex) {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"VersionPinned",
+			fmt.Sprintf("Plex media server version is pinned to %s, remove spec.version to update to %s", status.CurrentVersion, status.AvailableVersion),
+			upToDateCondition))
+		return
+	}
+	_, next, err := inMaintenanceWindow(updates, now)
+	if err != nil {
+		meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+			r.conditionStatus(false),
+			"InvalidMaintenanceWindow",
+			fmt.Sprintf("Plex media server will not be updated to %s: %v", status.AvailableVersion, err),
+			upToDateCondition))
+		return
+	}
+	message := fmt.Sprintf("Plex media server will be updated to %s", status.AvailableVersion)
+	if !next.IsZero() {
+		message = fmt.Sprintf("%s when the maintenance window opens at %s", message, next.Format(time.RFC3339))
+	}
+	meta.SetStatusCondition(&plex.Status.Conditions, r.setStatusInfo(
+		r.conditionStatus(false),
+		"UpdatePending",
+		message,
+		upToDateCondition))
+}
+
+// latestVersion returns the newest version of Plex Media Server on the PlexMediaServer's update
+// channel that the plexinc/pms-docker image can be built with
+func (r *StatusReconciler) latestVersion(ctx context.Context, plex *v1alpha1.PlexMediaServer) (string, error) {
+	plexTV, err := newPlexDownloadsClient(ctx, r.Client, plex)
+	if err != nil {
+		return "", err
+	}
+	beta := plex.Spec.Updates.Channel == "Beta"
+	downloads, err := plexTV.Downloads(ctx, beta)
+	if err == plexapi.ErrNoToken {
+		return "", fmt.Errorf("the Beta channel requires an X-Plex-Token: %v", err)
+	}
+	if err != nil {
+		return "", err
+	}
+	return downloads.Version(plexDownloadsPlatform, plexImageDistro)
+}
+
+// newerVersion returns true if the available version of Plex Media Server is newer than the
+// current version. Plex versions are four dot-separated numbers followed by a build hash, such as
+// 1.32.5.7349-8f4248874. If the current version is not a Plex version, such as latest, any
+// available version is newer.
+func newerVersion(available string, current string) bool {
+	availableParts, ok := parsePlexVersion(available)
+	if !ok {
+		return false
+	}
+	currentParts, ok := parsePlexVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range availableParts {
+		if i >= len(currentParts) {
+			return true
+		}
+		if availableParts[i] != currentParts[i] {
+			return availableParts[i] > currentParts[i]
+		}
+	}
+	return false
+}
+
+// parsePlexVersion returns the numbers of a Plex version, ignoring the build hash
+func parsePlexVersion(version string) ([]int, bool) {
+	if version == "" {
+		return nil, false
+	}
+	version = strings.SplitN(version, "-", 2)[0]
+	parts := []int{}
+	for _, part := range strings.Split(version, ".") {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, number)
+	}
+	return parts, true
+}
+
+// UpdatesRequeueAfter returns how long until the PlexMediaServer should be reconciled again, to
+// check plex.tv for a new release or to update Plex when the maintenance window opens. Zero is
+// returned if automatic updates are not enabled.
+func UpdatesRequeueAfter(plex *v1alpha1.PlexMediaServer, now time.Time) time.Duration {
+	updates := plex.Spec.Updates
+	status := plex.Status.Updates
+	if updates == nil || status == nil || status.LastChecked == nil {
+		return 0
+	}
+	after := status.LastChecked.Add(updatePollInterval(updates)).Sub(now)
+	if !versionPinned(plex) && updatePending(plex, plexVersion(plex)) {
+		open, next, err := inMaintenanceWindow(updates, now)
+		if err == nil && !open && !next.IsZero() && next.Sub(now) < after {
+			after = next.Sub(now)
+		}
+	}
+	if after < time.Second {
+		after = time.Second
+	}
+	return after
+}
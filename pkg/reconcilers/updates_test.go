@@ -0,0 +1,446 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+	"github.com/adambkaplan/plex-operator/pkg/plexapi"
+	fakeplex "github.com/adambkaplan/plex-operator/pkg/plexapi/fake"
+)
+
+const (
+	oldPlexVersion    = "1.32.4.7195-7c8f9d3b6"
+	publicPlexVersion = "1.32.5.7349-8f4248874"
+	betaPlexVersion   = "1.32.6.7371-b7d1a3e9c"
+)
+
+func TestSetUpdatesStatus(t *testing.T) {
+	// 2023-06-14 is a Wednesday
+	now := time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC)
+	checked := metav1.NewTime(now)
+	lastHour := metav1.NewTime(now.Add(-time.Hour))
+	nightly := &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * * *"}
+	tokenSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Name:      "plex-token",
+		},
+		Data: map[string][]byte{
+			"token": []byte("token"),
+		},
+	}
+	cases := []struct {
+		name            string
+		version         string
+		deployedVersion string
+		updates         *v1alpha1.PlexUpdatesSpec
+		tokenSecretRef  *corev1.SecretKeySelector
+		status          *v1alpha1.PlexUpdatesStatus
+		expectedStatus  *v1alpha1.PlexUpdatesStatus
+		expectedReason  string
+		expectedMessage string
+		expectedEvents  []string
+	}{
+		{
+			name:    "disabled",
+			version: oldPlexVersion,
+			status: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   publicPlexVersion,
+				LastChecked:      &lastHour,
+			},
+		},
+		{
+			name:            "update pending",
+			deployedVersion: oldPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: nightly},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "UpdatePending",
+			expectedMessage: "Plex media server will be updated to 1.32.5.7349-8f4248874 when the maintenance window opens at 2023-06-15T03:00:00Z",
+		},
+		{
+			name:            "up to date",
+			deployedVersion: publicPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   publicPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "AsExpected",
+			expectedMessage: "Plex media server 1.32.5.7349-8f4248874 is up to date",
+		},
+		{
+			name:            "check not due",
+			deployedVersion: oldPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: nightly},
+			status: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: oldPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &lastHour,
+			},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: oldPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &lastHour,
+			},
+			expectedReason:  "AsExpected",
+			expectedMessage: "Plex media server 1.32.4.7195-7c8f9d3b6 is up to date",
+		},
+		{
+			name:            "check due",
+			deployedVersion: oldPlexVersion,
+			updates: &v1alpha1.PlexUpdatesSpec{
+				PollInterval:      &metav1.Duration{Duration: 30 * time.Minute},
+				MaintenanceWindow: nightly,
+			},
+			status: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: oldPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &lastHour,
+			},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "UpdatePending",
+			expectedMessage: "Plex media server will be updated to 1.32.5.7349-8f4248874 when the maintenance window opens at 2023-06-15T03:00:00Z",
+		},
+		{
+			name:            "beta",
+			deployedVersion: betaPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{Channel: "Beta"},
+			tokenSecretRef:  &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "plex-token"}, Key: "token"},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: betaPlexVersion,
+				CurrentVersion:   betaPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "AsExpected",
+			expectedMessage: "Plex media server 1.32.6.7371-b7d1a3e9c is up to date",
+		},
+		{
+			name:            "beta without token",
+			deployedVersion: oldPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{Channel: "Beta"},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				CurrentVersion: oldPlexVersion,
+				LastChecked:    &checked,
+			},
+			expectedReason:  "NotChecked",
+			expectedMessage: "Plex media server has not been checked for updates",
+			expectedEvents: []string{
+				"Warning UpdateCheckFailed Failed to check plex.tv for Plex media server updates: the Beta channel requires an X-Plex-Token: no X-Plex-Token secret is referenced",
+			},
+		},
+		{
+			name:            "no downgrade",
+			deployedVersion: betaPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   betaPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "AsExpected",
+			expectedMessage: "Plex media server 1.32.6.7371-b7d1a3e9c is up to date",
+		},
+		{
+			name:            "pinned",
+			version:         oldPlexVersion,
+			deployedVersion: publicPlexVersion,
+			updates:         &v1alpha1.PlexUpdatesSpec{},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "VersionPinned",
+			expectedMessage: "Plex media server version is pinned to 1.32.4.7195-7c8f9d3b6, remove spec.version to update to 1.32.5.7349-8f4248874",
+		},
+		{
+			name:            "invalid window",
+			deployedVersion: oldPlexVersion,
+			updates: &v1alpha1.PlexUpdatesSpec{
+				MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * *"},
+			},
+			expectedStatus: &v1alpha1.PlexUpdatesStatus{
+				AvailableVersion: publicPlexVersion,
+				CurrentVersion:   oldPlexVersion,
+				LastChecked:      &checked,
+			},
+			expectedReason:  "InvalidMaintenanceWindow",
+			expectedMessage: `Plex media server will not be updated to 1.32.5.7349-8f4248874: schedule "0 3 * *" must have 5 fields, found 4`,
+		},
+	}
+
+	origNewPlexDownloadsClient := newPlexDownloadsClient
+	origTimeNow := timeNow
+	defer func() {
+		newPlexDownloadsClient = origNewPlexDownloadsClient
+		timeNow = origTimeNow
+	}()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plexServer := fakeplex.NewServer("token")
+			defer plexServer.Close()
+			newPlexDownloadsClient = func(ctx context.Context, c client.Reader, plex *v1alpha1.PlexMediaServer) (*plexapi.Client, error) {
+				token, err := plexapi.TokenFromSecret(ctx, c, plex)
+				if err != nil && err != plexapi.ErrNoToken {
+					return nil, err
+				}
+				return plexServer.Client(token), nil
+			}
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Version:        tc.version,
+					Updates:        tc.updates,
+					TokenSecretRef: tc.tokenSecretRef,
+				},
+				Status: v1alpha1.PlexMediaServerStatus{
+					Updates: tc.status,
+				},
+			}
+			if tc.deployedVersion != "" {
+				plex.Annotations = map[string]string{versionAnnotation: tc.deployedVersion}
+			}
+			recorder := record.NewFakeRecorder(10)
+			reconciler := &StatusReconciler{
+				Client:   fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tokenSecret).Build(),
+				Log:      logr.Discard(),
+				Recorder: recorder,
+			}
+
+			reconciler.setUpdatesStatus(context.TODO(), plex)
+			assert.Equal(t, tc.expectedStatus, plex.Status.Updates, "updates status should be equal")
+			condition := meta.FindStatusCondition(plex.Status.Conditions, "UpToDate")
+			if tc.expectedReason == "" {
+				assert.Nil(t, condition, "UpToDate condition should not be set")
+			} else if assert.NotNil(t, condition, "UpToDate condition should be set") {
+				assert.Equal(t, tc.expectedReason, condition.Reason, "reason should be equal")
+				assert.Equal(t, tc.expectedMessage, condition.Message, "message should be equal")
+			}
+			if tc.expectedEvents == nil {
+				tc.expectedEvents = []string{}
+			}
+			assert.Equal(t, tc.expectedEvents, recordedEvents(recorder), "events should be equal")
+			if tc.expectedStatus != nil {
+				assert.Equal(t, "docker.io/plexinc/pms-docker:"+tc.expectedStatus.CurrentVersion, plexImage(plex), "image should deploy the current version")
+			}
+		})
+	}
+}
+
+func TestUpdatesReconcile(t *testing.T) {
+	// 2023-06-14 is a Wednesday
+	now := time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC)
+	nightly := &v1alpha1.PlexMaintenanceWindow{Schedule: "0 3 * * *"}
+	noon := &v1alpha1.PlexMaintenanceWindow{Schedule: "0 12 * * *"}
+	cases := []struct {
+		name               string
+		version            string
+		annotation         string
+		updates            *v1alpha1.PlexUpdatesSpec
+		availableVersion   string
+		statefulSetVersion string
+		expectedAnnotation string
+		expectedEvents     []string
+	}{
+		{
+			name:       "disabled",
+			annotation: publicPlexVersion,
+		},
+		{
+			name:               "new server",
+			updates:            &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: nightly},
+			expectedAnnotation: "latest",
+		},
+		{
+			name:               "deployed version",
+			updates:            &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: nightly},
+			statefulSetVersion: oldPlexVersion,
+			expectedAnnotation: oldPlexVersion,
+		},
+		{
+			name:               "outside window",
+			annotation:         oldPlexVersion,
+			updates:            &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: nightly},
+			availableVersion:   publicPlexVersion,
+			expectedAnnotation: oldPlexVersion,
+		},
+		{
+			name:               "inside window",
+			annotation:         oldPlexVersion,
+			updates:            &v1alpha1.PlexUpdatesSpec{MaintenanceWindow: noon},
+			availableVersion:   publicPlexVersion,
+			expectedAnnotation: publicPlexVersion,
+			expectedEvents: []string{
+				"Normal Updating Updating Plex media server from 1.32.4.7195-7c8f9d3b6 to 1.32.5.7349-8f4248874",
+			},
+		},
+		{
+			name:               "pinned",
+			version:            oldPlexVersion,
+			annotation:         publicPlexVersion,
+			updates:            &v1alpha1.PlexUpdatesSpec{},
+			availableVersion:   publicPlexVersion,
+			expectedAnnotation: oldPlexVersion,
+		},
+	}
+
+	origTimeNow := timeNow
+	defer func() {
+		timeNow = origTimeNow
+	}()
+	timeNow = func() time.Time {
+		return now
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.TODO()
+			s := scheme.Scheme
+			require.NoError(t, v1alpha1.AddToScheme(s), "failed to add scheme")
+			plex := &v1alpha1.PlexMediaServer{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "test",
+					Name:      "plex",
+				},
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Version: tc.version,
+					Updates: tc.updates,
+				},
+			}
+			if tc.annotation != "" {
+				plex.Annotations = map[string]string{versionAnnotation: tc.annotation}
+			}
+			if tc.availableVersion != "" {
+				plex.Status.Updates = &v1alpha1.PlexUpdatesStatus{AvailableVersion: tc.availableVersion}
+			}
+			builder := fake.NewClientBuilder().WithScheme(s).WithObjects(plex.DeepCopy())
+			if tc.statefulSetVersion != "" {
+				builder.WithObjects(&appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Namespace: "test",
+						Name:      "plex",
+					},
+					Spec: appsv1.StatefulSetSpec{
+						Template: corev1.PodTemplateSpec{
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{
+									{
+										Name:  "plex",
+										Image: "docker.io/plexinc/pms-docker:" + tc.statefulSetVersion,
+									},
+								},
+							},
+						},
+					},
+				})
+			}
+			c := builder.Build()
+			recorder := record.NewFakeRecorder(10)
+			reconciler := NewUpdatesReconciler(c, logr.Discard(), s, recorder)
+
+			requeue, err := reconciler.Reconcile(ctx, plex)
+			require.NoError(t, err, "failed to reconcile updates")
+			assert.False(t, requeue, "should not requeue")
+
+			updated := &v1alpha1.PlexMediaServer{}
+			err = c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "plex"}, updated)
+			require.NoError(t, err, "failed to get PlexMediaServer")
+			annotation, ok := updated.Annotations[versionAnnotation]
+			assert.Equal(t, tc.expectedAnnotation != "", ok, "annotation should be set if updates are enabled")
+			assert.Equal(t, tc.expectedAnnotation, annotation, "annotation should be equal")
+			if tc.updates != nil {
+				assert.Equal(t, tc.expectedAnnotation, plexVersion(plex), "version should be deployed")
+			}
+			if tc.expectedEvents == nil {
+				tc.expectedEvents = []string{}
+			}
+			assert.Equal(t, tc.expectedEvents, recordedEvents(recorder), "events should be equal")
+		})
+	}
+}
+
+func TestNewerVersion(t *testing.T) {
+	cases := []struct {
+		name      string
+		available string
+		current   string
+		expected  bool
+	}{
+		{name: "newer build", available: "1.32.5.7349-8f4248874", current: "1.32.4.7195-7c8f9d3b6", expected: true},
+		{name: "newer minor", available: "1.40.0.7998-c29d4c0c8", current: "1.32.5.7349-8f4248874", expected: true},
+		{name: "same", available: "1.32.5.7349-8f4248874", current: "1.32.5.7349-8f4248874"},
+		{name: "older", available: "1.32.4.7195-7c8f9d3b6", current: "1.32.5.7349-8f4248874"},
+		{name: "latest", available: "1.32.5.7349-8f4248874", current: "latest", expected: true},
+		{name: "none available", current: "1.32.5.7349-8f4248874"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, newerVersion(tc.available, tc.current), "newer should be equal")
+		})
+	}
+}
+
+func TestUpdatesRequeueAfter(t *testing.T) {
+	now := time.Date(2023, time.June, 14, 12, 0, 0, 0, time.UTC)
+	lastHour := metav1.NewTime(now.Add(-time.Hour))
+	plex := &v1alpha1.PlexMediaServer{}
+	assert.Zero(t, UpdatesRequeueAfter(plex, now), "updates are not enabled")
+
+	plex.Annotations = map[string]string{versionAnnotation: publicPlexVersion}
+	plex.Spec.Updates = &v1alpha1.PlexUpdatesSpec{
+		MaintenanceWindow: &v1alpha1.PlexMaintenanceWindow{Schedule: "0 15 * * *"},
+	}
+	plex.Status.Updates = &v1alpha1.PlexUpdatesStatus{
+		AvailableVersion: publicPlexVersion,
+		LastChecked:      &lastHour,
+	}
+	assert.Equal(t, 5*time.Hour, UpdatesRequeueAfter(plex, now), "should requeue at the next poll")
+
+	plex.Annotations[versionAnnotation] = oldPlexVersion
+	assert.Equal(t, 3*time.Hour, UpdatesRequeueAfter(plex, now), "should requeue when the window opens")
+
+	plex.Spec.Version = oldPlexVersion
+	assert.Equal(t, 5*time.Hour, UpdatesRequeueAfter(plex, now), "pinned version should requeue at the next poll")
+
+	plex.Spec.Version = ""
+	plex.Spec.Updates.MaintenanceWindow.Schedule = "0 23 * * *"
+	assert.Equal(t, 5*time.Hour, UpdatesRequeueAfter(plex, now), "should requeue at the next poll before the window opens")
+}
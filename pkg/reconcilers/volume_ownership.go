@@ -0,0 +1,197 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+const (
+	// volumeOwnershipContainerName is the name of the init container that fixes or checks the
+	// ownership of Plex's volumes
+	volumeOwnershipContainerName = "volume-ownership"
+
+	// volumeOwnershipScript changes or checks the ownership of Plex's volumes. It is passed the
+	// mode, the owner as uid:gid, and each path prefixed with r: if Plex reads it or w: if Plex
+	// writes to it. Problems are written to the container's termination message, which is reported
+	// in the VolumesAccessible status condition. The script does not fail, so that Plex starts and
+	// reports its own errors.
+	volumeOwnershipScript = `mode="$1"
+owner="$2"
+shift 2
+problems=""
+for arg in "$@"; do
+  access="${arg%%:*}"
+  path="${arg#*:}"
+  if [ "${mode}" = "Chown" ]; then
+    if ! find "${path}" \( ! -user "${owner%:*}" -o ! -group "${owner#*:}" \) -exec chown -h "${owner}" {} +; then
+      problems="${problems}could not change the owner of ${path}; "
+    fi
+    continue
+  fi
+  if [ ! -r "${path}" ] || [ ! -x "${path}" ]; then
+    problems="${problems}${path} is not readable; "
+    continue
+  fi
+  file=$(find "${path}" ! -readable -print -quit 2> /dev/null)
+  if [ -n "${file}" ]; then
+    problems="${problems}${file} is not readable; "
+  fi
+  if [ "${access}" = "w" ]; then
+    file=$(find "${path}" ! -writable -print -quit 2> /dev/null)
+    if [ -n "${file}" ]; then
+      problems="${problems}${file} is not writable; "
+    fi
+  fi
+done
+printf '%s' "${problems%; }" > /dev/termination-log
+`
+)
+
+// volumeOwner returns the user and group IDs that should own Plex's volumes
+func volumeOwner(plex *v1alpha1.PlexMediaServer) (int64, int64) {
+	uid := restrictedUserID
+	gid := restrictedUserID
+	if securityContext := plex.Spec.SecurityContext; securityContext != nil {
+		if securityContext.RunAsUser != nil {
+			uid = *securityContext.RunAsUser
+		}
+		if securityContext.RunAsGroup != nil {
+			gid = *securityContext.RunAsGroup
+		}
+	}
+	ownership := plex.Spec.Storage.Ownership
+	if ownership.UID != nil {
+		uid = *ownership.UID
+	}
+	if ownership.GID != nil {
+		gid = *ownership.GID
+	}
+	return uid, gid
+}
+
+// volumeOwnershipMode returns the mode of the volume ownership init container. Changing ownership
+// requires root, which the Restricted preset does not allow, so the Chown mode falls back to the
+// Check mode.
+func volumeOwnershipMode(plex *v1alpha1.PlexMediaServer) string {
+	mode := plex.Spec.Storage.Ownership.Mode
+	if chownNotAllowed(plex) {
+		return "Check"
+	}
+	return mode
+}
+
+// chownNotAllowed returns true if the Chown mode is requested, but the volume ownership init
+// container is not allowed to run as root
+func chownNotAllowed(plex *v1alpha1.PlexMediaServer) bool {
+	ownership := plex.Spec.Storage.Ownership
+	if ownership == nil || ownership.Mode != "Chown" {
+		return false
+	}
+	return restrictedSecurityContext(plex)
+}
+
+// renderVolumeOwnershipContainer renders the init container that fixes or checks the ownership of
+// Plex's volumes. Changing ownership requires root, so in the Chown mode the container runs as root
+// with only the capabilities needed to read and change the owner of any file. In the Check mode
+// the container runs as the volumes' owner and mounts the volumes read only. With the Restricted
+// preset, the volumes are only checked.
+func (r *StatefulSetReconciler) renderVolumeOwnershipContainer(plex *v1alpha1.PlexMediaServer, container corev1.Container) corev1.Container {
+	mode := volumeOwnershipMode(plex)
+	uid, gid := volumeOwner(plex)
+	container.Image = plexImage(plex)
+	container.Command = []string{
+		"/bin/sh",
+		"-c",
+		volumeOwnershipScript,
+		volumeOwnershipContainerName,
+		mode,
+		fmt.Sprintf("%d:%d", uid, gid),
+		"w:/config",
+		"w:/transcode",
+		"r:/data",
+	}
+
+	volumeMounts := []corev1.VolumeMount{}
+	configMount := corev1.VolumeMount{Name: "config"}
+	transcodeMount := corev1.VolumeMount{Name: "transcode"}
+	dataMount := corev1.VolumeMount{Name: "data"}
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == "config" {
+			configMount = mount
+			continue
+		}
+		if mount.Name == "transcode" {
+			transcodeMount = mount
+			continue
+		}
+		if mount.Name == "data" {
+			dataMount = mount
+			continue
+		}
+		volumeMounts = append(volumeMounts, mount)
+	}
+	readOnly := mode == "Check"
+	configMount.MountPath = "/config"
+	configMount.ReadOnly = readOnly
+	transcodeMount.MountPath = "/transcode"
+	transcodeMount.ReadOnly = readOnly
+	dataMount.MountPath = "/data"
+	dataMount.ReadOnly = readOnly
+	container.VolumeMounts = append(volumeMounts, configMount, transcodeMount, dataMount)
+
+	securityContext := r.renderPlexSecurityContext(plex, container.SecurityContext)
+	if securityContext == nil {
+		securityContext = &corev1.SecurityContext{}
+	}
+	if mode == "Chown" {
+		root := int64(0)
+		runAsNonRoot := false
+		allowPrivilegeEscalation := false
+		securityContext.RunAsUser = &root
+		securityContext.RunAsGroup = &root
+		securityContext.RunAsNonRoot = &runAsNonRoot
+		securityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		securityContext.Capabilities = &corev1.Capabilities{
+			Add:  []corev1.Capability{"CHOWN", "DAC_READ_SEARCH"},
+			Drop: []corev1.Capability{"ALL"},
+		}
+	} else {
+		securityContext.RunAsUser = &uid
+		securityContext.RunAsGroup = &gid
+	}
+	container.SecurityContext = securityContext
+	return container
+}
+
+// volumesAccessibleMessage returns the problems the volume ownership init container reported, and
+// true if the container has run
+func volumesAccessibleMessage(pod *corev1.Pod) (string, bool) {
+	if pod == nil {
+		return "", false
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.Name != volumeOwnershipContainerName {
+			continue
+		}
+		terminated := status.State.Terminated
+		if terminated == nil {
+			terminated = status.LastTerminationState.Terminated
+		}
+		if terminated == nil {
+			return "", false
+		}
+		if terminated.ExitCode != 0 && terminated.Message == "" {
+			return fmt.Sprintf("%s container exited with code %d", volumeOwnershipContainerName, terminated.ExitCode), true
+		}
+		return terminated.Message, true
+	}
+	return "", false
+}
@@ -0,0 +1,292 @@
+/*
+Copyright Adam B Kaplan
+
+SPDX-License-Identifier: Apache-2.0
+*/
+package reconcilers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/adambkaplan/plex-operator/api/v1alpha1"
+)
+
+func TestVolumeOwner(t *testing.T) {
+	runAsUser := int64(2000)
+	runAsGroup := int64(3000)
+	ownerID := int64(4000)
+	cases := []struct {
+		name            string
+		securityContext *v1alpha1.PlexSecurityContextSpec
+		ownership       v1alpha1.PlexVolumeOwnershipSpec
+		expectedUID     int64
+		expectedGID     int64
+	}{
+		{
+			name:        "default",
+			expectedUID: 1000,
+			expectedGID: 1000,
+		},
+		{
+			name: "security context",
+			securityContext: &v1alpha1.PlexSecurityContextSpec{
+				RunAsUser:  &runAsUser,
+				RunAsGroup: &runAsGroup,
+			},
+			expectedUID: 2000,
+			expectedGID: 3000,
+		},
+		{
+			name: "explicit owner",
+			securityContext: &v1alpha1.PlexSecurityContextSpec{
+				RunAsUser:  &runAsUser,
+				RunAsGroup: &runAsGroup,
+			},
+			ownership: v1alpha1.PlexVolumeOwnershipSpec{
+				UID: &ownerID,
+				GID: &ownerID,
+			},
+			expectedUID: 4000,
+			expectedGID: 4000,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ownership := tc.ownership
+			plex := &v1alpha1.PlexMediaServer{
+				Spec: v1alpha1.PlexMediaServerSpec{
+					SecurityContext: tc.securityContext,
+					Storage: v1alpha1.PlexStorageSpec{
+						Ownership: &ownership,
+					},
+				},
+			}
+			uid, gid := volumeOwner(plex)
+			assert.Equal(t, tc.expectedUID, uid, "uid should be equal")
+			assert.Equal(t, tc.expectedGID, gid, "gid should be equal")
+		})
+	}
+}
+
+func TestRenderVolumeOwnershipContainer(t *testing.T) {
+	cases := []struct {
+		name             string
+		mode             string
+		preset           string
+		platform         Platform
+		expectedMode     string
+		expectedReadOnly bool
+		expectedUser     int64
+	}{
+		{
+			name:         "chown",
+			mode:         "Chown",
+			expectedUser: 0,
+		},
+		{
+			name:             "check",
+			mode:             "Check",
+			expectedReadOnly: true,
+			expectedUser:     1000,
+		},
+		{
+			name:             "chown with restricted preset",
+			mode:             "Chown",
+			preset:           "Restricted",
+			expectedMode:     "Check",
+			expectedReadOnly: true,
+			expectedUser:     1000,
+		},
+		{
+			name:         "chown on OpenShift",
+			mode:         "Chown",
+			platform:     Platform{OpenShift: true},
+			expectedUser: 0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			plex := &v1alpha1.PlexMediaServer{
+				Spec: v1alpha1.PlexMediaServerSpec{
+					Storage: v1alpha1.PlexStorageSpec{
+						Ownership: &v1alpha1.PlexVolumeOwnershipSpec{
+							Mode: tc.mode,
+						},
+					},
+				},
+			}
+			if tc.preset != "" {
+				plex.Spec.SecurityContext = &v1alpha1.PlexSecurityContextSpec{Preset: tc.preset}
+			}
+			if tc.expectedMode == "" {
+				tc.expectedMode = tc.mode
+			}
+			reconciler := &StatefulSetReconciler{Platform: tc.platform}
+			existing := corev1.Container{
+				Name:            volumeOwnershipContainerName,
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "config", MountPath: "/old-config"},
+				},
+			}
+			container := reconciler.renderVolumeOwnershipContainer(plex, existing)
+
+			assert.Equal(t, corev1.PullIfNotPresent, container.ImagePullPolicy, "existing fields should be kept")
+			assert.Equal(t, []string{
+				"/bin/sh",
+				"-c",
+				volumeOwnershipScript,
+				volumeOwnershipContainerName,
+				tc.expectedMode,
+				"1000:1000",
+				"w:/config",
+				"w:/transcode",
+				"r:/data",
+			}, container.Command, "command should be equal")
+			assert.Equal(t, []corev1.VolumeMount{
+				{Name: "config", MountPath: "/config", ReadOnly: tc.expectedReadOnly},
+				{Name: "transcode", MountPath: "/transcode", ReadOnly: tc.expectedReadOnly},
+				{Name: "data", MountPath: "/data", ReadOnly: tc.expectedReadOnly},
+			}, container.VolumeMounts, "volume mounts should be equal")
+			if assert.NotNil(t, container.SecurityContext, "security context should be set") {
+				assert.Equal(t, tc.expectedUser, *container.SecurityContext.RunAsUser, "user should be equal")
+				if tc.expectedMode == "Check" {
+					capabilities := container.SecurityContext.Capabilities
+					assert.True(t, capabilities == nil || len(capabilities.Add) == 0, "no capabilities should be added")
+				}
+			}
+		})
+	}
+}
+
+func TestVolumesAccessibleMessage(t *testing.T) {
+	cases := []struct {
+		name            string
+		pod             *corev1.Pod
+		expectedMessage string
+		expectedChecked bool
+	}{
+		{
+			name: "no pod",
+		},
+		{
+			name: "not run",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: volumeOwnershipContainerName,
+							State: corev1.ContainerState{
+								Running: &corev1.ContainerStateRunning{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "accessible",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: volumeOwnershipContainerName,
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{},
+							},
+						},
+					},
+				},
+			},
+			expectedChecked: true,
+		},
+		{
+			name: "not readable after restart",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: volumeOwnershipContainerName,
+							LastTerminationState: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									Message: "/data/movies is not readable",
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedMessage: "/data/movies is not readable",
+			expectedChecked: true,
+		},
+		{
+			name: "failed",
+			pod: &corev1.Pod{
+				Status: corev1.PodStatus{
+					InitContainerStatuses: []corev1.ContainerStatus{
+						{
+							Name: volumeOwnershipContainerName,
+							State: corev1.ContainerState{
+								Terminated: &corev1.ContainerStateTerminated{
+									ExitCode: 127,
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedMessage: "volume-ownership container exited with code 127",
+			expectedChecked: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			message, checked := volumesAccessibleMessage(tc.pod)
+			assert.Equal(t, tc.expectedMessage, message, "message should be equal")
+			assert.Equal(t, tc.expectedChecked, checked, "checked should be equal")
+		})
+	}
+}
+
+func TestVolumesAccessibleChownNotAllowed(t *testing.T) {
+	plex := &v1alpha1.PlexMediaServer{
+		Spec: v1alpha1.PlexMediaServerSpec{
+			SecurityContext: &v1alpha1.PlexSecurityContextSpec{
+				Preset: "Restricted",
+			},
+			Storage: v1alpha1.PlexStorageSpec{
+				Ownership: &v1alpha1.PlexVolumeOwnershipSpec{
+					Mode: "Chown",
+				},
+			},
+		},
+	}
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: volumeOwnershipContainerName,
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{
+							Message: "/config is not writable",
+						},
+					},
+				},
+			},
+		},
+	}
+	reconciler := &StatusReconciler{}
+	reconciler.setVolumesAccessibleCondition(plex, pod)
+	condition := meta.FindStatusCondition(plex.Status.Conditions, "VolumesAccessible")
+	if assert.NotNil(t, condition, "condition should be set") {
+		assert.Equal(t, metav1.ConditionFalse, condition.Status, "status should be equal")
+		assert.Equal(t, "ChownNotAllowed", condition.Reason, "reason should be equal")
+		assert.Equal(t, "Plex media server volume ownership cannot be changed with the Restricted security context, volumes are only checked: /config is not writable", condition.Message, "message should be equal")
+	}
+}